@@ -0,0 +1,56 @@
+package notify
+
+import "strings"
+
+// Action styles
+const (
+	ActionPrimary = "primary"
+	ActionDanger  = "danger"
+)
+
+// Action is a button shown below a message: a link button opening URL, or
+// a callback button reporting ID and Value when clicked
+type Action struct {
+	// ID identifies a callback button in the interactions it triggers
+	ID string `json:"id,omitempty"`
+
+	// Label is the button text
+	Label string `json:"label"`
+
+	// URL makes the button a link
+	URL string `json:"url,omitempty"`
+
+	// Value is passed back with the interaction (optional)
+	Value string `json:"value,omitempty"`
+
+	// Style is ActionPrimary or ActionDanger where supported (optional)
+	Style string `json:"style,omitempty"`
+}
+
+// LinkAction returns a button opening url
+func LinkAction(label, url string) Action {
+	return Action{Label: label, URL: url}
+}
+
+// CallbackAction returns a button reporting id and value when clicked
+func CallbackAction(id, label, value string) Action {
+	return Action{ID: id, Label: label, Value: value}
+}
+
+// IsLink reports whether the action is a link button
+func (a Action) IsLink() bool {
+	return a.URL != ""
+}
+
+// ActionsText renders link actions as "Label: URL" lines, for providers
+// without buttons. Callback actions are left out, since clicks can only be
+// received from providers with buttons.
+func ActionsText(actions []Action) string {
+	var lines []string
+	for _, action := range actions {
+		if action.IsLink() {
+			lines = append(lines, action.Label+": "+action.URL)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
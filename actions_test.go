@@ -0,0 +1,19 @@
+package notify
+
+import "testing"
+
+func TestActionsText(t *testing.T) {
+	actions := []Action{
+		CallbackAction("ack", "Acknowledge", ""),
+		LinkAction("Open runbook", "https://runbooks.example.com/db"),
+		LinkAction("Dashboard", "https://grafana.example.com/d/db"),
+	}
+
+	want := "Open runbook: https://runbooks.example.com/db\nDashboard: https://grafana.example.com/d/db"
+	if got := ActionsText(actions); got != want {
+		t.Errorf("ActionsText() = %q, want %q", got, want)
+	}
+	if got := ActionsText(actions[:1]); got != "" {
+		t.Errorf("Expected callback actions to be left out, got %q", got)
+	}
+}
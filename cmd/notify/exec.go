@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/milano15662/notify"
+	"github.com/milano15662/notify/richtext"
+)
+
+// Exit codes used when the command can't be run, following shell conventions
+const (
+	exitCannotExecute = 126
+	exitNotFound      = 127
+)
+
+// runExec implements "notify exec": it runs a command, passes its output and
+// exit code through, and reports the outcome
+func runExec(env *environment, args []string) int {
+	var (
+		config    string
+		providers stringList
+		route     string
+		on        string
+		title     string
+		channel   string
+		tailLines int
+		timeout   time.Duration
+		jsonOut   bool
+	)
+
+	fs := newFlagSet(env, "exec", "-- command [args...]")
+	fs.StringVar(&config, "config", "", "config file (default $NOTIFY_CONFIG, then NOTIFY_* variables)")
+	fs.Var(&providers, "provider", "provider instance to report to (repeatable, default all)")
+	fs.StringVar(&route, "route", "", "route to report to")
+	fs.StringVar(&on, "on", "failure", "when to report: failure or always")
+	fs.StringVar(&title, "title", "", "message title (default describes the outcome)")
+	fs.StringVar(&channel, "channel", "", "target channel or chat (provider-specific)")
+	fs.IntVar(&tailLines, "tail", 20, "number of output lines to include")
+	fs.DurationVar(&timeout, "timeout", 30*time.Second, "delivery timeout")
+	fs.BoolVar(&jsonOut, "json", false, "print the JSON report to stderr on success too")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if on != "failure" && on != "always" {
+		return fail(env, fmt.Errorf("exec: --on must be failure or always, got %q", on))
+	}
+
+	argv := fs.Args()
+	if len(argv) == 0 {
+		return fail(env, fmt.Errorf("exec: command is required"))
+	}
+
+	// Load providers first so a broken config is reported before the command runs
+	manager, err := loadManager(config)
+	if err != nil {
+		return fail(env, err)
+	}
+
+	outcome := execute(env, argv, tailLines)
+	if outcome.exitCode == 0 && on == "failure" {
+		return outcome.exitCode
+	}
+
+	msg := outcome.message(title)
+	msg.Channel = channel
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	results, err := dispatch(ctx, manager, providers, route, msg)
+	if err != nil {
+		fmt.Fprintf(env.stderr, "notify: %v\n", err)
+	} else {
+		writeReport(env.stderr, results, jsonOut)
+	}
+
+	return outcome.exitCode
+}
+
+// execOutcome describes a finished command
+type execOutcome struct {
+	argv     []string
+	exitCode int
+	duration time.Duration
+	output   string
+	err      error
+}
+
+// execute runs argv with output copied to env and to a tail buffer
+func execute(env *environment, argv []string, tailLines int) *execOutcome {
+	tail := newTailBuffer(tailLines, 3000)
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = env.stdin
+
+	var mu sync.Mutex
+	cmd.Stdout = &lockedTee{mu: &mu, dst: env.stdout, tail: tail}
+	if sameOutput(env.stdout, env.stderr) {
+		// One writer makes the command share a pipe for both streams, so
+		// the tail has its output in the order it was written
+		cmd.Stderr = cmd.Stdout
+	} else {
+		// Separate pipes are read concurrently: the lock keeps lines whole,
+		// but output written to both streams at once may be reordered
+		cmd.Stderr = &lockedTee{mu: &mu, dst: env.stderr, tail: tail}
+	}
+
+	start := time.Now()
+	err := runRelayingSignals(cmd)
+	outcome := &execOutcome{
+		argv:     argv,
+		duration: time.Since(start),
+		err:      err,
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		outcome.exitCode = 0
+	case errors.As(err, &exitErr):
+		outcome.exitCode = exitErr.ExitCode()
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			outcome.exitCode = 128 + int(status.Signal())
+		}
+	case errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist):
+		outcome.exitCode = exitNotFound
+		fmt.Fprintf(env.stderr, "notify: %v\n", err)
+	default:
+		outcome.exitCode = exitCannotExecute
+		fmt.Fprintf(env.stderr, "notify: %v\n", err)
+	}
+
+	outcome.output = tail.String()
+	return outcome
+}
+
+// runRelayingSignals runs cmd, relaying SIGINT and SIGTERM to it instead of
+// exiting, so that the command can exit and its outcome still be reported
+func runRelayingSignals(cmd *exec.Cmd) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return cmd.Wait()
+}
+
+// sameOutput reports whether stdout and stderr are the same file, as in a
+// terminal, under cron or with 2>&1
+func sameOutput(stdout, stderr io.Writer) bool {
+	outFile, ok := stdout.(*os.File)
+	if !ok {
+		return false
+	}
+	errFile, ok := stderr.(*os.File)
+	if !ok {
+		return false
+	}
+	if outFile == errFile {
+		return true
+	}
+
+	outInfo, err := outFile.Stat()
+	if err != nil {
+		return false
+	}
+	errInfo, err := errFile.Stat()
+	return err == nil && os.SameFile(outInfo, errInfo)
+}
+
+// lockedTee writes a command output stream to its destination and to the
+// shared tail while holding the lock shared with the other stream
+type lockedTee struct {
+	mu   *sync.Mutex
+	dst  io.Writer
+	tail io.Writer
+}
+
+func (w *lockedTee) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.tail.Write(p)
+	return w.dst.Write(p)
+}
+
+// message formats the outcome as a notification
+func (o *execOutcome) message(title string) *notify.Message {
+	command := strings.Join(o.argv, " ")
+	duration := o.duration.Round(time.Millisecond)
+
+	msg := &notify.Message{}
+	body := richtext.New().Code(command)
+	att := notify.Attachment{
+		Fields: []notify.Field{
+			{Title: "Exit code", Value: fmt.Sprint(o.exitCode), Short: true},
+			{Title: "Duration", Value: duration.String(), Short: true},
+		},
+	}
+
+	if host, err := os.Hostname(); err == nil {
+		att.Fields = append(att.Fields, notify.Field{Title: "Host", Value: host, Short: true})
+	}
+
+	if o.exitCode == 0 {
+		msg.Title = "Command succeeded"
+		body.Text(fmt.Sprintf(" finished in %s", duration))
+		msg.Priority = notify.PriorityLow
+		att.Color = "good"
+	} else {
+		msg.Title = "Command failed"
+		if o.err != nil && o.exitCode >= exitCannotExecute && o.exitCode <= exitNotFound {
+			body.Text(fmt.Sprintf(" could not be run: %v", o.err))
+		} else {
+			body.Text(fmt.Sprintf(" exited with status %d after %s", o.exitCode, duration))
+		}
+		msg.Priority = notify.PriorityHigh
+		att.Color = "danger"
+	}
+
+	if title != "" {
+		msg.Title = title
+	}
+
+	// the output tail is a code block in each provider's own markup
+	if o.output != "" {
+		body.Pre("", o.output)
+	} else {
+		body.Paragraph("(no output)")
+	}
+
+	msg.Rich = body.Document()
+	msg.Text = msg.Rich.Plain()
+	msg.Attachments = []notify.Attachment{att}
+	return msg
+}
+
+// tailBuffer keeps the last lines written to it, up to a byte limit
+type tailBuffer struct {
+	mu       sync.Mutex
+	buf      []byte
+	lines    int
+	maxBytes int
+}
+
+func newTailBuffer(lines, maxBytes int) *tailBuffer {
+	return &tailBuffer{lines: lines, maxBytes: maxBytes}
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > 2*t.maxBytes {
+		t.buf = append([]byte(nil), t.buf[len(t.buf)-t.maxBytes:]...)
+	}
+	return len(p), nil
+}
+
+// String returns the retained tail without trailing newlines
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := bytes.TrimRight(t.buf, "\r\n")
+	if len(out) > t.maxBytes {
+		out = out[len(out)-t.maxBytes:]
+	}
+
+	if t.lines > 0 {
+		lines := bytes.Split(out, []byte("\n"))
+		if len(lines) > t.lines {
+			lines = lines[len(lines)-t.lines:]
+		}
+		out = bytes.Join(lines, []byte("\n"))
+	} else {
+		out = nil
+	}
+
+	return strings.ToValidUTF8(string(out), "")
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/milano15662/notify"
+	"github.com/milano15662/notify/richtext"
+)
+
+// runCLIToFile runs the CLI with stdout and stderr writing to one file, like
+// a terminal, and returns the exit code and the output
+func runCLIToFile(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	out, err := os.Create(filepath.Join(t.TempDir(), "output"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	code := run(args, &environment{stdin: strings.NewReader(""), stdout: out, stderr: out})
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code, string(data)
+}
+
+// execOutput returns the output code block of an exec report
+func execOutput(t *testing.T, msg *notify.Message) string {
+	t.Helper()
+	for _, node := range msg.Rich.Nodes {
+		if pre, ok := node.(richtext.Pre); ok {
+			return pre.Value
+		}
+	}
+	t.Fatalf("Expected an output code block, got %+v", msg.Rich)
+	return ""
+}
+
+func TestExecReportsFailure(t *testing.T) {
+	code, stdout := runCLIToFile(t, "exec", "--config", testConfig(t), "--provider", "ok", "--tail", "2",
+		"--", "sh", "-c", "echo one; echo two; echo three >&2; exit 3")
+	if code != 3 {
+		t.Fatalf("Expected child exit code 3, got %d", code)
+	}
+
+	if !strings.Contains(stdout, "one") {
+		t.Errorf("Expected child output to pass through, got %q", stdout)
+	}
+
+	msg := lastSent(t, "ok")
+	if msg.Priority != notify.PriorityHigh || !strings.Contains(msg.Text, "exited with status 3") {
+		t.Errorf("Unexpected message: %+v", msg)
+	}
+
+	if output := execOutput(t, msg); !strings.Contains(output, "two\nthree") || strings.Contains(output, "one") {
+		t.Errorf("Expected the last 2 output lines, got %q", output)
+	}
+	if code, ok := msg.Rich.Nodes[0].(richtext.Code); !ok || !strings.HasPrefix(code.Value, "sh -c") {
+		t.Errorf("Expected the command as inline code, got %+v", msg.Rich.Nodes[0])
+	}
+
+	att := msg.Attachments[0]
+	if att.Fields[0].Value != "3" {
+		t.Errorf("Expected exit code field 3, got %q", att.Fields[0].Value)
+	}
+}
+
+func TestExecOnFailureSkipsSuccess(t *testing.T) {
+	before := len(sentTo("ok"))
+
+	code, _, _ := runCLI("", "exec", "--config", testConfig(t), "--provider", "ok", "--", "true")
+	if code != 0 {
+		t.Fatalf("Expected exit 0, got %d", code)
+	}
+
+	if len(sentTo("ok")) != before {
+		t.Error("Expected no notification for a successful command")
+	}
+
+	code, _, _ = runCLI("", "exec", "--config", testConfig(t), "--provider", "ok", "--on", "always", "--", "true")
+	if code != 0 {
+		t.Fatalf("Expected exit 0, got %d", code)
+	}
+
+	if msg := lastSent(t, "ok"); msg.Priority != notify.PriorityLow {
+		t.Errorf("Expected low priority success report, got %+v", msg)
+	}
+}
+
+func TestExecPassesThroughExitCodeOnDeliveryFailure(t *testing.T) {
+	code, _, stderr := runCLI("", "exec", "--config", testConfig(t), "--provider", "broken", "--", "sh", "-c", "exit 5")
+	if code != 5 {
+		t.Fatalf("Expected child exit code 5, got %d", code)
+	}
+
+	if !strings.Contains(stderr, `"ok": false`) {
+		t.Errorf("Expected JSON delivery report on stderr, got %q", stderr)
+	}
+}
+
+func TestExecCommandNotFound(t *testing.T) {
+	code, _, _ := runCLI("", "exec", "--config", testConfig(t), "--provider", "ok", "--", "notify-test-missing-command")
+	if code != exitNotFound {
+		t.Fatalf("Expected exit %d, got %d", exitNotFound, code)
+	}
+}
+
+// signalWriter closes ready on the first write
+type signalWriter struct {
+	once  sync.Once
+	ready chan struct{}
+}
+
+func (w *signalWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.ready) })
+	return len(p), nil
+}
+
+func TestExecRelaysSignals(t *testing.T) {
+	stdout := &signalWriter{ready: make(chan struct{})}
+	var stderr bytes.Buffer
+
+	result := make(chan int, 1)
+	go func() {
+		result <- run([]string{"exec", "--config", testConfig(t), "--provider", "ok", "--",
+			"sh", "-c", "trap 'echo stopping >&2; exit 7' TERM; echo ready; while :; do sleep 0.05; done"},
+			&environment{stdin: strings.NewReader(""), stdout: stdout, stderr: &stderr})
+	}()
+
+	select {
+	case <-stdout.ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the command to start")
+	}
+
+	self, _ := os.FindProcess(os.Getpid())
+	if err := self.Signal(syscall.SIGTERM); err != nil {
+		t.Skipf("Can't send SIGTERM: %v", err)
+	}
+
+	select {
+	case code := <-result:
+		if code != 7 {
+			t.Fatalf("Expected the command's exit code 7, got %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the command to exit")
+	}
+
+	msg := lastSent(t, "ok")
+	if !strings.Contains(msg.Text, "exited with status 7") || !strings.Contains(execOutput(t, msg), "stopping") {
+		t.Errorf("Expected a report of the interrupted command, got %+v", msg)
+	}
+}
@@ -0,0 +1,196 @@
+// Command notify sends notifications through the providers configured in a
+// notify config file or in NOTIFY_* environment variables.
+//
+// Usage:
+//
+//	notify send --provider telegram --title "Backup" --priority high "Backup failed"
+//	echo "disk almost full" | notify broadcast --title "Disk"
+//	notify exec --on failure --route oncall -- /usr/local/bin/backup.sh
+//	notify serve --addr :8080 --token "$NOTIFY_SERVER_TOKEN"
+//
+// Providers are loaded from --config, $NOTIFY_CONFIG, or the environment
+// (see notify.FromEnv with prefix NOTIFY). On delivery failure notify prints
+// a JSON report to stdout and exits with status 1; usage and configuration
+// errors exit with status 2.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/milano15662/notify"
+)
+
+// Exit codes
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// command is a notify subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(env *environment, args []string) int
+}
+
+// environment holds the standard streams used by a command
+type environment struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "send", summary: "send a message to providers or a route", run: runSend},
+		{name: "broadcast", summary: "send a message to every provider", run: runBroadcast},
+		{name: "exec", summary: "run a command and report its outcome", run: runExec},
+		{name: "serve", summary: "expose the providers over an HTTP API", run: runServe},
+		{name: "providers", summary: "list configured providers and routes", run: runProviders},
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], &environment{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}))
+}
+
+// run executes the command line and returns the exit code
+func run(args []string, env *environment) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		usage(env.stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(env, args[1:])
+		}
+	}
+
+	fmt.Fprintf(env.stderr, "notify: unknown command %q\n\n", args[0])
+	usage(env.stderr)
+	return exitUsage
+}
+
+// usage prints the list of commands
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: notify <command> [flags] [text]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'notify <command> -h' for command flags.")
+}
+
+// newFlagSet creates a flag set for a command that reports errors to stderr
+func newFlagSet(env *environment, name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(env.stderr, "Usage: notify %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// loadManager builds a Manager from a config file, $NOTIFY_CONFIG or NOTIFY_* variables
+func loadManager(path string) (*notify.Manager, error) {
+	if path == "" {
+		path = os.Getenv("NOTIFY_CONFIG")
+	}
+
+	var manager *notify.Manager
+	var err error
+	if path != "" {
+		manager, err = notify.LoadConfig(path)
+	} else {
+		manager, err = notify.FromEnv("NOTIFY")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(manager.List()) == 0 {
+		return nil, fmt.Errorf("no providers configured (use --config, NOTIFY_CONFIG or NOTIFY_* variables)")
+	}
+	return manager, nil
+}
+
+// fail reports a usage or configuration error
+func fail(env *environment, err error) int {
+	fmt.Fprintf(env.stderr, "notify: %s\n", notify.Redact(err.Error()))
+	return exitUsage
+}
+
+// result is the delivery outcome for one provider
+type result struct {
+	Provider string `json:"provider"`
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+}
+
+// report is the machine-readable outcome of a command
+type report struct {
+	OK      bool     `json:"ok"`
+	Results []result `json:"results"`
+}
+
+// newResult converts a delivery error into a result, without the secrets
+// that provider errors may contain
+func newResult(provider string, err error) result {
+	r := result{Provider: provider, OK: err == nil}
+	if err != nil {
+		r.Error = notify.Redact(err.Error())
+	}
+	return r
+}
+
+// writeReport prints the results as JSON to w when asked to or when a
+// delivery failed, and returns the exit code
+func writeReport(w io.Writer, results []result, always bool) int {
+	sort.Slice(results, func(i, j int) bool { return results[i].Provider < results[j].Provider })
+
+	rep := report{OK: true, Results: results}
+	for _, r := range results {
+		if !r.OK {
+			rep.OK = false
+		}
+	}
+
+	if always || !rep.OK {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(rep)
+	}
+
+	if !rep.OK {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
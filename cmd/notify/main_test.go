@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/milano15662/notify"
+	"github.com/milano15662/notify/notifytest"
+)
+
+// sent records the messages of every cli-test provider
+var sent = notifytest.NewRecorder()
+
+func init() {
+	notify.RegisterProvider("cli-test", func(raw map[string]interface{}) (notify.Notifier, error) {
+		name, _ := raw["name"].(string)
+		if fail, _ := raw["fail"].(bool); fail {
+			return sent.Notifier(name, notifytest.FailAlways(errors.New(`Post "https://api.telegram.org/bot123456:AAHsecret/sendMessage": connection refused`))), nil
+		}
+		return sent.Notifier(name), nil
+	})
+}
+
+// sentTo returns every message provider received, including failed deliveries
+func sentTo(provider string) []*notify.Message {
+	var msgs []*notify.Message
+	for _, d := range sent.Deliveries() {
+		if d.Provider == provider {
+			msgs = append(msgs, d.Message)
+		}
+	}
+	return msgs
+}
+
+func lastSent(t *testing.T, provider string) *notify.Message {
+	t.Helper()
+	msgs := sentTo(provider)
+	if len(msgs) == 0 {
+		t.Fatalf("Expected a message for %s", provider)
+	}
+	return msgs[len(msgs)-1]
+}
+
+func testConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notify.yaml")
+	config := `
+providers:
+  ok:
+    type: cli-test
+  broken:
+    type: cli-test
+    fail: true
+routes:
+  all: [ok, broken]
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func runCLI(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &environment{
+		stdin:  strings.NewReader(stdin),
+		stdout: &stdout,
+		stderr: &stderr,
+	})
+	return code, stdout.String(), stderr.String()
+}
+
+func TestSend(t *testing.T) {
+	config := testConfig(t)
+
+	code, stdout, stderr := runCLI("", "send", "--config", config, "--provider", "ok",
+		"--title", "Backup", "--priority", "high", "--field", "Host=db-01", "--color", "danger", "Backup", "failed")
+	if code != exitOK {
+		t.Fatalf("Expected exit 0, got %d (%s)", code, stderr)
+	}
+
+	if stdout != "" {
+		t.Errorf("Expected no output on success, got %q", stdout)
+	}
+
+	msg := lastSent(t, "ok")
+	if msg.Text != "Backup failed" || msg.Title != "Backup" || msg.Priority != notify.PriorityHigh {
+		t.Errorf("Unexpected message: %+v", msg)
+	}
+
+	if len(msg.Attachments) != 1 || msg.Attachments[0].Fields[0].Value != "db-01" || msg.Attachments[0].Color != "danger" {
+		t.Errorf("Unexpected attachments: %+v", msg.Attachments)
+	}
+}
+
+func TestSendDeduplicatesTargets(t *testing.T) {
+	before := len(sentTo("ok"))
+
+	runCLI("", "send", "--config", testConfig(t), "--provider", "ok", "--route", "all", "once")
+
+	if got := len(sentTo("ok")) - before; got != 1 {
+		t.Errorf("Expected ok to receive the message once, got %d", got)
+	}
+}
+
+func TestSendFromStdin(t *testing.T) {
+	code, _, stderr := runCLI("from stdin\n", "send", "--config", testConfig(t), "--provider", "ok")
+	if code != exitOK {
+		t.Fatalf("Expected exit 0, got %d (%s)", code, stderr)
+	}
+
+	if msg := lastSent(t, "ok"); msg.Text != "from stdin" {
+		t.Errorf("Expected text 'from stdin', got %q", msg.Text)
+	}
+}
+
+func TestSendFailureReport(t *testing.T) {
+	code, stdout, _ := runCLI("", "send", "--config", testConfig(t), "--route", "all", "hello")
+	if code != exitFailure {
+		t.Fatalf("Expected exit 1, got %d", code)
+	}
+
+	var rep report
+	if err := json.Unmarshal([]byte(stdout), &rep); err != nil {
+		t.Fatalf("Expected JSON report, got %q: %v", stdout, err)
+	}
+
+	if rep.OK || len(rep.Results) != 2 {
+		t.Fatalf("Unexpected report: %+v", rep)
+	}
+
+	if rep.Results[0].Provider != "broken" || rep.Results[0].OK || rep.Results[0].Error == "" {
+		t.Errorf("Expected failure for 'broken', got %+v", rep.Results[0])
+	}
+	if strings.Contains(stdout, "AAHsecret") {
+		t.Errorf("Expected the report without the bot token, got %s", stdout)
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	code, stdout, _ := runCLI("", "broadcast", "--config", testConfig(t), "--json", "hello")
+	if code != exitFailure {
+		t.Fatalf("Expected exit 1, got %d", code)
+	}
+
+	var rep report
+	if err := json.Unmarshal([]byte(stdout), &rep); err != nil {
+		t.Fatalf("Expected JSON report: %v", err)
+	}
+
+	if len(rep.Results) != 2 {
+		t.Errorf("Expected 2 results, got %+v", rep.Results)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	config := testConfig(t)
+
+	tests := [][]string{
+		{},
+		{"frobnicate"},
+		{"send", "--config", config, "hello"},
+		{"send", "--config", config, "--provider", "ok", "--priority", "urgent", "hello"},
+		{"send", "--config", config, "--provider", "ok", "--field", "novalue", "hello"},
+	}
+
+	for _, args := range tests {
+		if code, _, _ := runCLI("", args...); code != exitUsage {
+			t.Errorf("Expected exit 2 for %v, got %d", args, code)
+		}
+	}
+}
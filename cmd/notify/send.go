@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/milano15662/notify"
+)
+
+// messageFlags holds the flags that describe a message
+type messageFlags struct {
+	config   string
+	timeout  time.Duration
+	jsonOut  bool
+	title    string
+	priority string
+	channel  string
+
+	attachTitle string
+	attachText  string
+	color       string
+	footer      string
+	image       string
+	fields      stringList
+}
+
+// register adds the message flags to fs
+func (f *messageFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.config, "config", "", "config file (default $NOTIFY_CONFIG, then NOTIFY_* variables)")
+	fs.DurationVar(&f.timeout, "timeout", 30*time.Second, "overall delivery timeout")
+	fs.BoolVar(&f.jsonOut, "json", false, "print the JSON report on success too")
+	fs.StringVar(&f.title, "title", "", "message title")
+	fs.StringVar(&f.priority, "priority", "", "message priority (high, normal, low)")
+	fs.StringVar(&f.channel, "channel", "", "target channel or chat (provider-specific)")
+	fs.StringVar(&f.attachTitle, "attach-title", "", "attachment title")
+	fs.StringVar(&f.attachText, "attach-text", "", "attachment text")
+	fs.StringVar(&f.color, "color", "", "attachment color (good, warning, danger or #hex)")
+	fs.StringVar(&f.footer, "footer", "", "attachment footer")
+	fs.StringVar(&f.image, "image", "", "attachment image URL")
+	fs.Var(&f.fields, "field", "attachment field as Title=Value (repeatable)")
+}
+
+// message builds the message described by the flags
+func (f *messageFlags) message(text string) (*notify.Message, error) {
+	switch f.priority {
+	case "", notify.PriorityHigh, notify.PriorityNormal, notify.PriorityLow:
+	default:
+		return nil, fmt.Errorf("invalid priority %q (want high, normal or low)", f.priority)
+	}
+
+	msg := &notify.Message{
+		Text:     text,
+		Title:    f.title,
+		Priority: f.priority,
+		Channel:  f.channel,
+	}
+
+	att := notify.Attachment{
+		Title:    f.attachTitle,
+		Text:     f.attachText,
+		Color:    f.color,
+		Footer:   f.footer,
+		ImageURL: f.image,
+	}
+	for _, field := range f.fields {
+		title, value, ok := strings.Cut(field, "=")
+		if !ok || title == "" {
+			return nil, fmt.Errorf("invalid field %q (want Title=Value)", field)
+		}
+		att.Fields = append(att.Fields, notify.Field{Title: title, Value: value, Short: len(value) <= 40})
+	}
+
+	if att.Title != "" || att.Text != "" || att.Color != "" || att.Footer != "" || att.ImageURL != "" || len(att.Fields) > 0 {
+		msg.Attachments = []notify.Attachment{att}
+	}
+
+	return msg, nil
+}
+
+// readText returns the message text from the arguments, or from stdin when
+// there are none or the only argument is "-"
+func readText(args []string, stdin io.Reader) (string, error) {
+	if len(args) > 0 && !(len(args) == 1 && args[0] == "-") {
+		return strings.Join(args, " "), nil
+	}
+
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read message from stdin: %w", err)
+	}
+
+	text := strings.TrimRight(string(data), "\r\n")
+	if text == "" {
+		return "", fmt.Errorf("message text is empty")
+	}
+	return text, nil
+}
+
+// runSend implements "notify send"
+func runSend(env *environment, args []string) int {
+	var mf messageFlags
+	var providers stringList
+	var route string
+
+	fs := newFlagSet(env, "send", "[text | -]")
+	mf.register(fs)
+	fs.Var(&providers, "provider", "provider instance to send to (repeatable)")
+	fs.StringVar(&route, "route", "", "route to send to")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if len(providers) == 0 && route == "" {
+		return fail(env, fmt.Errorf("send: --provider or --route is required"))
+	}
+
+	manager, msg, err := prepare(env, &mf, fs.Args())
+	if err != nil {
+		return fail(env, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mf.timeout)
+	defer cancel()
+
+	results, err := dispatch(ctx, manager, providers, route, msg)
+	if err != nil {
+		return fail(env, err)
+	}
+
+	return writeReport(env.stdout, results, mf.jsonOut)
+}
+
+// runBroadcast implements "notify broadcast"
+func runBroadcast(env *environment, args []string) int {
+	var mf messageFlags
+
+	fs := newFlagSet(env, "broadcast", "[text | -]")
+	mf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	manager, msg, err := prepare(env, &mf, fs.Args())
+	if err != nil {
+		return fail(env, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mf.timeout)
+	defer cancel()
+
+	results, _ := dispatch(ctx, manager, nil, "", msg)
+	return writeReport(env.stdout, results, mf.jsonOut)
+}
+
+// dispatch sends msg to the given providers and route, or to every provider
+// when neither is set, and returns one result per provider
+func dispatch(ctx context.Context, manager *notify.Manager, providers []string, route string, msg *notify.Message) ([]result, error) {
+	targets := append([]string(nil), providers...)
+	if route != "" {
+		routeProviders, exists := manager.Route(route)
+		if !exists {
+			return nil, fmt.Errorf("route %s not found", route)
+		}
+		targets = append(targets, routeProviders...)
+	}
+
+	// a provider named directly and through the route gets the message once
+	seen := make(map[string]bool, len(targets))
+	unique := targets[:0]
+	for _, provider := range targets {
+		if !seen[provider] {
+			seen[provider] = true
+			unique = append(unique, provider)
+		}
+	}
+	targets = unique
+
+	var results []result
+	if len(targets) == 0 {
+		for res := range manager.BroadcastAsyncWithOptions(ctx, msg) {
+			results = append(results, newResult(res.Provider, res.Error))
+		}
+		return results, nil
+	}
+
+	for _, provider := range targets {
+		results = append(results, newResult(provider, manager.SendWithOptions(ctx, provider, msg)))
+	}
+	return results, nil
+}
+
+// prepare loads the manager and builds the message for send and broadcast
+func prepare(env *environment, mf *messageFlags, args []string) (*notify.Manager, *notify.Message, error) {
+	text, err := readText(args, env.stdin)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	msg, err := mf.message(text)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manager, err := loadManager(mf.config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return manager, msg, nil
+}
+
+// runProviders implements "notify providers"
+func runProviders(env *environment, args []string) int {
+	var config string
+
+	fs := newFlagSet(env, "providers", "")
+	fs.StringVar(&config, "config", "", "config file (default $NOTIFY_CONFIG, then NOTIFY_* variables)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	manager, err := loadManager(config)
+	if err != nil {
+		return fail(env, err)
+	}
+
+	names := manager.List()
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(env.stdout, name)
+	}
+
+	for _, route := range manager.Routes() {
+		providers, _ := manager.Route(route)
+		fmt.Fprintf(env.stdout, "route %s: %s\n", route, strings.Join(providers, ", "))
+	}
+
+	return exitOK
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/milano15662/notify"
+	"github.com/milano15662/notify/metrics"
+	"github.com/milano15662/notify/server"
+)
+
+// runServe implements "notify serve"
+func runServe(env *environment, args []string) int {
+	var (
+		config  string
+		addr    string
+		token   string
+		maxBody int64
+		timeout time.Duration
+		amRoute string
+		gfRoute string
+		hooks   string
+		metric  bool
+		level   string
+	)
+
+	fs := newFlagSet(env, "serve", "")
+	fs.StringVar(&config, "config", "", "config file (default $NOTIFY_CONFIG, then NOTIFY_* variables)")
+	fs.StringVar(&addr, "addr", ":8080", "listen address")
+	fs.StringVar(&token, "token", os.Getenv("NOTIFY_SERVER_TOKEN"), "bearer token required from clients (default $NOTIFY_SERVER_TOKEN)")
+	fs.Int64Var(&maxBody, "max-body", server.DefaultMaxBodyBytes, "maximum request body size in bytes")
+	fs.DurationVar(&timeout, "timeout", 30*time.Second, "delivery timeout per request")
+	fs.StringVar(&amRoute, "alertmanager-route", "", "default route for Alertmanager webhooks")
+	fs.StringVar(&gfRoute, "grafana-route", "", "default route for Grafana webhooks")
+	fs.StringVar(&hooks, "webhooks", "", "YAML or JSON file with generic webhook mappings")
+	fs.BoolVar(&metric, "metrics", false, "expose Prometheus metrics at /metrics")
+	fs.StringVar(&level, "log-level", "info", "delivery log level on stderr: debug, info, warn, error or off")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	manager, err := loadManager(config)
+	if err != nil {
+		return fail(env, err)
+	}
+
+	if level != "off" {
+		var lvl slog.Level
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			fmt.Fprintf(env.stderr, "notify: invalid --log-level %q\n", level)
+			return exitUsage
+		}
+		logger := slog.New(slog.NewTextHandler(env.stderr, &slog.HandlerOptions{Level: lvl}))
+		manager.AddObserver(notify.NewLogObserver(logger, notify.LogOptions{}))
+	}
+
+	if token == "" {
+		fmt.Fprintln(env.stderr, "notify: warning: serving without authentication (set --token or NOTIFY_SERVER_TOKEN)")
+	}
+
+	var mappings map[string]server.Mapping
+	if hooks != "" {
+		if mappings, err = server.LoadMappings(hooks); err != nil {
+			return fail(env, err)
+		}
+	}
+
+	handler, err := server.New(manager, server.Options{
+		Token:             token,
+		MaxBodyBytes:      maxBody,
+		Timeout:           timeout,
+		AlertmanagerRoute: amRoute,
+		GrafanaRoute:      gfRoute,
+		Webhooks:          mappings,
+	})
+	if err != nil {
+		return fail(env, err)
+	}
+
+	if metric {
+		collector := metrics.NewCollector()
+		manager.AddObserver(collector)
+		handler.Handle("/metrics", collector)
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		fmt.Fprintf(env.stderr, "notify: listening on %s\n", addr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(env.stderr, "notify: %v\n", err)
+			return exitFailure
+		}
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			fmt.Fprintf(env.stderr, "notify: shutdown: %v\n", err)
+			return exitFailure
+		}
+	}
+
+	return exitOK
+}
@@ -0,0 +1,694 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes a set of providers, routes and delivery settings
+type Config struct {
+	// Defaults are applied to every delivery
+	Defaults Defaults
+
+	// Retry is the retry policy used for every delivery
+	Retry RetryPolicy
+
+	// Providers maps instance names to provider configurations
+	Providers map[string]ProviderConfig
+
+	// Routes maps route names to provider instance names
+	Routes map[string][]string
+}
+
+// ProviderConfig describes a single provider instance
+type ProviderConfig struct {
+	// Type is the provider kind (e.g., slack, telegram)
+	Type string
+
+	// Options holds the provider-specific settings
+	Options map[string]interface{}
+}
+
+// ConfigError represents an invalid configuration value
+type ConfigError struct {
+	// Key identifies the offending setting (e.g., providers.ops.token)
+	Key     string
+	Message string
+}
+
+func (e *ConfigError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("config: %s", e.Message)
+	}
+	return fmt.Sprintf("config: %s: %s", e.Key, e.Message)
+}
+
+// LoadConfig reads a YAML or JSON configuration file and builds a Manager from it
+func LoadConfig(path string) (*Manager, error) {
+	config, err := ReadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.NewManager()
+}
+
+// ReadConfig reads a YAML or JSON configuration file.
+// String values may reference environment variables as ${NAME}.
+func ReadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &ConfigError{Message: fmt.Sprintf("failed to read %s: %v", path, err)}
+	}
+
+	var raw map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &raw)
+	default:
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, &ConfigError{Message: fmt.Sprintf("failed to parse %s: %v", path, err)}
+	}
+
+	expanded, err := expandEnv(raw, "")
+	if err != nil {
+		return nil, err
+	}
+
+	raw, _ = expanded.(map[string]interface{})
+	return parseConfig(raw, joinKey)
+}
+
+// FromEnv builds a Manager from environment variables sharing a prefix.
+//
+// With prefix NOTIFY, PROVIDERS lists instance names and each instance reads
+// its options from NOTIFY_<NAME>_<OPTION> (the type defaults to the name):
+//
+//	NOTIFY_PROVIDERS=ops,oncall
+//	NOTIFY_OPS_TYPE=slack
+//	NOTIFY_OPS_TOKEN=xoxb-...
+//	NOTIFY_ONCALL_TYPE=telegram
+//	NOTIFY_ONCALL_BOT_TOKEN=123:ABC
+//	NOTIFY_ROUTE_CRITICAL=ops,oncall
+//	NOTIFY_RETRY_MAX_ATTEMPTS=3
+//	NOTIFY_DEFAULTS_TIMEOUT=10s
+//
+// When PROVIDERS is unset, every registered provider kind with at least one
+// variable (e.g., NOTIFY_SLACK_TOKEN) is configured. URLS adds providers
+// from whitespace-separated service URLs (see NewFromURL).
+func FromEnv(prefix string) (*Manager, error) {
+	config, err := ConfigFromEnv(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.NewManager()
+}
+
+// ConfigFromEnv reads a configuration from environment variables (see FromEnv)
+func ConfigFromEnv(prefix string) (*Config, error) {
+	prefix = strings.TrimSuffix(strings.ToUpper(prefix), "_")
+	if prefix != "" {
+		prefix += "_"
+	}
+
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, ok := strings.Cut(kv, "=")
+		if ok && strings.HasPrefix(key, prefix) {
+			env[strings.TrimPrefix(key, prefix)] = value
+		}
+	}
+
+	var names []string
+	if list, ok := env["PROVIDERS"]; ok {
+		names = splitList(list)
+	} else {
+		for _, kind := range ProviderKinds() {
+			if hasPrefixedKey(env, envName(kind)+"_") {
+				names = append(names, kind)
+			}
+		}
+	}
+
+	// Match longer instance names first so NOTIFY_OPS_SLACK_TOKEN belongs
+	// to "ops-slack" rather than to "ops"
+	sorted := append([]string(nil), names...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	providers := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		providers[name] = make(map[string]interface{})
+	}
+
+	for _, rawURL := range strings.Fields(env["URLS"]) {
+		name := urlInstanceName(rawURL)
+		for i := 2; providers[name] != nil; i++ {
+			name = fmt.Sprintf("%s-%d", urlInstanceName(rawURL), i)
+		}
+		providers[name] = map[string]interface{}{"url": rawURL}
+	}
+
+	raw := map[string]interface{}{"providers": providers}
+	sections := map[string]map[string]interface{}{
+		"RETRY_":    make(map[string]interface{}),
+		"DEFAULTS_": make(map[string]interface{}),
+		"ROUTE_":    make(map[string]interface{}),
+	}
+
+	for key, value := range env {
+		if key == "PROVIDERS" || key == "URLS" {
+			continue
+		}
+
+		matched := false
+		for section, values := range sections {
+			if strings.HasPrefix(key, section) {
+				values[strings.ToLower(strings.TrimPrefix(key, section))] = value
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		for _, name := range sorted {
+			if option, ok := strings.CutPrefix(key, envName(name)+"_"); ok {
+				providers[name].(map[string]interface{})[strings.ToLower(option)] = value
+				break
+			}
+		}
+	}
+
+	raw["retry"] = sections["RETRY_"]
+	raw["defaults"] = sections["DEFAULTS_"]
+	raw["routes"] = sections["ROUTE_"]
+
+	return parseConfig(raw, func(path ...string) string {
+		return prefix + envKey(path)
+	})
+}
+
+// NewManager creates a Manager with all configured providers and routes registered
+func (c *Config) NewManager() (*Manager, error) {
+	manager := NewManager()
+	manager.SetDefaults(c.Defaults)
+	manager.SetRetryPolicy(c.Retry)
+
+	names := make([]string, 0, len(c.Providers))
+	for name := range c.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		notifier, err := buildProvider(name, c.Providers[name])
+		if err != nil {
+			return nil, prefixConfigError(err, "providers", name)
+		}
+
+		if err := manager.Register(notifier); err != nil {
+			return nil, &ConfigError{Key: joinKey("providers", name), Message: err.Error()}
+		}
+	}
+
+	for route, providers := range c.Routes {
+		if err := manager.SetRoute(route, providers...); err != nil {
+			return nil, &ConfigError{Key: joinKey("routes", route), Message: err.Error()}
+		}
+	}
+
+	return manager, nil
+}
+
+// buildProvider creates a notifier for a provider configuration
+func buildProvider(name string, pc ProviderConfig) (Notifier, error) {
+	opts := newOptions(pc.Options)
+
+	if rawURL := opts.String("url"); rawURL != "" {
+		if err := opts.Finish(); err != nil {
+			return nil, err
+		}
+
+		notifier, err := newFromURLNamed(rawURL, name)
+		if err != nil {
+			return nil, &ConfigError{Key: "url", Message: err.Error()}
+		}
+		return notifier, nil
+	}
+
+	raw := make(map[string]interface{}, len(pc.Options)+1)
+	for key, value := range pc.Options {
+		raw[key] = value
+	}
+	raw["name"] = name
+
+	return NewProvider(pc.Type, raw)
+}
+
+// parseConfig converts a decoded configuration tree into a Config.
+// key formats the path of a setting for error messages.
+func parseConfig(raw map[string]interface{}, key func(path ...string) string) (*Config, error) {
+	config := &Config{
+		Providers: make(map[string]ProviderConfig),
+		Routes:    make(map[string][]string),
+	}
+
+	root := newOptions(raw)
+	defaults := root.Section("defaults")
+	retry := root.Section("retry")
+	providers := root.Section("providers")
+	routes := root.Section("routes")
+	if err := root.Finish(); err != nil {
+		return nil, renameConfigError(err, key)
+	}
+
+	config.Defaults = Defaults{
+		Priority: defaults.String("priority"),
+		Timeout:  defaults.Duration("timeout"),
+	}
+	if err := defaults.Finish(); err != nil {
+		return nil, renameConfigError(prefixConfigError(err, "defaults"), key)
+	}
+
+	switch config.Defaults.Priority {
+	case "", PriorityHigh, PriorityNormal, PriorityLow:
+	default:
+		return nil, &ConfigError{
+			Key:     key("defaults", "priority"),
+			Message: fmt.Sprintf("unknown priority %q", config.Defaults.Priority),
+		}
+	}
+
+	config.Retry = RetryPolicy{
+		MaxAttempts:    retry.Int("max_attempts"),
+		InitialBackoff: retry.Duration("initial_backoff"),
+		MaxBackoff:     retry.Duration("max_backoff"),
+		Multiplier:     retry.Float("multiplier"),
+	}
+	if err := retry.Finish(); err != nil {
+		return nil, renameConfigError(prefixConfigError(err, "retry"), key)
+	}
+
+	for _, name := range providers.Keys() {
+		opts := providers.Section(name)
+		kind := opts.String("type")
+		if opts.err != nil {
+			return nil, renameConfigError(prefixConfigError(opts.err, "providers", name), key)
+		}
+		if kind == "" {
+			kind = name
+		}
+		config.Providers[name] = ProviderConfig{
+			Type:    kind,
+			Options: opts.Rest(),
+		}
+	}
+	if err := providers.Finish(); err != nil {
+		return nil, renameConfigError(prefixConfigError(err, "providers"), key)
+	}
+
+	for _, name := range routes.Keys() {
+		targets := routes.Strings(name)
+		if len(targets) == 0 {
+			return nil, &ConfigError{Key: key("routes", name), Message: "at least one provider is required"}
+		}
+		for _, target := range targets {
+			if _, ok := config.Providers[target]; !ok {
+				return nil, &ConfigError{Key: key("routes", name), Message: fmt.Sprintf("unknown provider %q", target)}
+			}
+		}
+		config.Routes[name] = targets
+	}
+	if err := routes.Finish(); err != nil {
+		return nil, renameConfigError(prefixConfigError(err, "routes"), key)
+	}
+
+	// Validate provider options up front so errors point at the config key
+	for name, pc := range config.Providers {
+		if _, err := buildProvider(name, pc); err != nil {
+			return nil, renameConfigError(prefixConfigError(err, "providers", name), key)
+		}
+	}
+
+	return config, nil
+}
+
+// options reads typed values from a decoded map and reports the first error
+type options struct {
+	values map[string]interface{}
+	used   map[string]bool
+	err    error
+}
+
+func newOptions(values map[string]interface{}) *options {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &options{values: values, used: make(map[string]bool)}
+}
+
+// fail records the first error for key
+func (o *options) fail(key, format string, args ...interface{}) {
+	if o.err == nil {
+		o.err = &ConfigError{Key: key, Message: fmt.Sprintf(format, args...)}
+	}
+}
+
+// lookup marks key as used and returns its value
+func (o *options) lookup(key string) (interface{}, bool) {
+	o.used[key] = true
+	value, ok := o.values[key]
+	return value, ok && value != nil
+}
+
+// String returns a string option
+func (o *options) String(key string) string {
+	value, ok := o.lookup(key)
+	if !ok {
+		return ""
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case int, int64, float64, bool:
+		return fmt.Sprint(v)
+	}
+
+	o.fail(key, "expected a string")
+	return ""
+}
+
+// Required returns a string option that must be set
+func (o *options) Required(key string) string {
+	value := o.String(key)
+	if value == "" {
+		o.fail(key, "is required")
+	}
+	return value
+}
+
+// Int returns an integer option
+func (o *options) Int(key string) int {
+	value, ok := o.lookup(key)
+	if !ok {
+		return 0
+	}
+
+	switch v := value.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		if v == float64(int(v)) {
+			return int(v)
+		}
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n
+		}
+	}
+
+	o.fail(key, "expected an integer, got %v", value)
+	return 0
+}
+
+// Float returns a floating point option
+func (o *options) Float(key string) float64 {
+	value, ok := o.lookup(key)
+	if !ok {
+		return 0
+	}
+
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f
+		}
+	}
+
+	o.fail(key, "expected a number, got %v", value)
+	return 0
+}
+
+// Bool returns a boolean option
+func (o *options) Bool(key string) bool {
+	value, ok := o.lookup(key)
+	if !ok {
+		return false
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+			return b
+		}
+	}
+
+	o.fail(key, "expected a boolean, got %v", value)
+	return false
+}
+
+// Duration returns a duration option such as "10s" (plain numbers are seconds)
+func (o *options) Duration(key string) time.Duration {
+	value, ok := o.lookup(key)
+	if !ok {
+		return 0
+	}
+
+	switch v := value.(type) {
+	case int:
+		return time.Duration(v) * time.Second
+	case float64:
+		return time.Duration(v * float64(time.Second))
+	case string:
+		if d, err := time.ParseDuration(strings.TrimSpace(v)); err == nil {
+			return d
+		}
+	}
+
+	o.fail(key, "expected a duration such as 10s, got %v", value)
+	return 0
+}
+
+// Strings returns a list option given as a sequence or a comma-separated string
+func (o *options) Strings(key string) []string {
+	value, ok := o.lookup(key)
+	if !ok {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		return splitList(v)
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			switch s := item.(type) {
+			case string:
+				list = append(list, s)
+			case int, int64, float64:
+				list = append(list, fmt.Sprint(s))
+			default:
+				o.fail(key, "expected a list of strings")
+				return nil
+			}
+		}
+		return list
+	}
+
+	o.fail(key, "expected a list of strings")
+	return nil
+}
+
+// Section returns a nested mapping as options
+func (o *options) Section(key string) *options {
+	value, ok := o.lookup(key)
+	if !ok {
+		return newOptions(nil)
+	}
+
+	values, isMap := value.(map[string]interface{})
+	if !isMap {
+		o.fail(key, "expected a mapping")
+		return newOptions(nil)
+	}
+	return newOptions(values)
+}
+
+// Keys returns the sorted keys of the mapping
+func (o *options) Keys() []string {
+	keys := make([]string, 0, len(o.values))
+	for key := range o.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Rest returns the values that haven't been read yet
+func (o *options) Rest() map[string]interface{} {
+	rest := make(map[string]interface{})
+	for key, value := range o.values {
+		if !o.used[key] {
+			rest[key] = value
+		}
+	}
+	return rest
+}
+
+// Finish returns the first error, or an error for the first unknown key
+func (o *options) Finish() error {
+	if o.err != nil {
+		return o.err
+	}
+
+	for _, key := range o.Keys() {
+		if !o.used[key] {
+			return &ConfigError{Key: key, Message: "unknown setting"}
+		}
+	}
+	return nil
+}
+
+// prefixConfigError prepends path to the key of a ConfigError
+func prefixConfigError(err error, path ...string) error {
+	ce, ok := err.(*ConfigError)
+	if !ok {
+		return &ConfigError{Key: joinKey(path...), Message: err.Error()}
+	}
+
+	if ce.Key == "" {
+		return &ConfigError{Key: joinKey(path...), Message: ce.Message}
+	}
+	return &ConfigError{Key: joinKey(append(path, ce.Key)...), Message: ce.Message}
+}
+
+// renameConfigError rewrites a dotted ConfigError key with the given formatter
+func renameConfigError(err error, key func(path ...string) string) error {
+	ce, ok := err.(*ConfigError)
+	if !ok || ce.Key == "" {
+		return err
+	}
+	return &ConfigError{Key: key(strings.Split(ce.Key, ".")...), Message: ce.Message}
+}
+
+// joinKey formats a setting path as a dotted key
+func joinKey(path ...string) string {
+	return strings.Join(path, ".")
+}
+
+// envKey formats a setting path as the environment variable read by ConfigFromEnv
+func envKey(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+
+	switch path[0] {
+	case "providers":
+		path = path[1:]
+	case "routes":
+		path = append([]string{"route"}, path[1:]...)
+	}
+
+	parts := make([]string, len(path))
+	for i, part := range path {
+		parts[i] = envName(part)
+	}
+	return strings.Join(parts, "_")
+}
+
+// envName converts a name to its environment variable form
+func envName(name string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+// hasPrefixedKey reports whether any key in env starts with prefix
+func hasPrefixedKey(env map[string]string, prefix string) bool {
+	for key := range env {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces ${NAME} references in every string of a decoded tree
+func expandEnv(value interface{}, key string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		var missing string
+		expanded := envPattern.ReplaceAllStringFunc(v, func(ref string) string {
+			name := envPattern.FindStringSubmatch(ref)[1]
+			value, ok := os.LookupEnv(name)
+			if !ok && missing == "" {
+				missing = name
+			}
+			return value
+		})
+		if missing != "" {
+			return nil, &ConfigError{Key: key, Message: fmt.Sprintf("environment variable %s is not set", missing)}
+		}
+		return expanded, nil
+	case map[string]interface{}:
+		for k, item := range v {
+			expanded, err := expandEnv(item, joinChildKey(key, k))
+			if err != nil {
+				return nil, err
+			}
+			v[k] = expanded
+		}
+	case []interface{}:
+		for i, item := range v {
+			expanded, err := expandEnv(item, fmt.Sprintf("%s[%d]", key, i))
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+	}
+	return value, nil
+}
+
+// joinChildKey appends a child key to a (possibly empty) parent key
+func joinChildKey(parent, child string) string {
+	if parent == "" {
+		return child
+	}
+	return parent + "." + child
+}
@@ -0,0 +1,176 @@
+package notify
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	t.Setenv("TEST_SLACK_TOKEN", "xoxb-test")
+
+	path := writeConfig(t, "notify.yaml", `
+defaults:
+  priority: normal
+  timeout: 5s
+retry:
+  max_attempts: 3
+  initial_backoff: 100ms
+providers:
+  ops:
+    type: slack
+    token: ${TEST_SLACK_TOKEN}
+    channel: "#ops"
+  telegram:
+    bot_token: "123:ABC"
+    chat_id: "42"
+routes:
+  critical: [ops, telegram]
+`)
+
+	manager, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if len(manager.List()) != 2 {
+		t.Errorf("Expected 2 notifiers, got %d", len(manager.List()))
+	}
+
+	if _, exists := manager.Get("ops"); !exists {
+		t.Error("Expected notifier 'ops' to be registered")
+	}
+
+	if routes := manager.Routes(); len(routes) != 1 || routes[0] != "critical" {
+		t.Errorf("Expected route 'critical', got %v", routes)
+	}
+
+	if manager.retry.MaxAttempts != 3 || manager.retry.InitialBackoff != 100*time.Millisecond {
+		t.Errorf("Unexpected retry policy: %+v", manager.retry)
+	}
+
+	if manager.defaults.Timeout != 5*time.Second {
+		t.Errorf("Expected timeout 5s, got %v", manager.defaults.Timeout)
+	}
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	path := writeConfig(t, "notify.json", `{
+  "providers": {
+    "alerts": {"type": "telegram", "bot_token": "123:ABC", "chat_id": 42}
+  }
+}`)
+
+	manager, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	notifier, exists := manager.Get("alerts")
+	if !exists {
+		t.Fatal("Expected notifier 'alerts' to be registered")
+	}
+
+	if notifier.(*TelegramNotifier).chatID != "42" {
+		t.Errorf("Expected chat ID '42', got '%s'", notifier.(*TelegramNotifier).chatID)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		key     string
+	}{
+		{
+			name:    "missing token",
+			content: "providers:\n  tg:\n    type: telegram\n    chat_id: '1'\n",
+			key:     "providers.tg.bot_token",
+		},
+		{
+			name:    "unknown option",
+			content: "providers:\n  slack:\n    token: x\n    chanel: '#ops'\n",
+			key:     "providers.slack.chanel",
+		},
+		{
+			name:    "unknown type",
+			content: "providers:\n  ops:\n    type: pager\n",
+			key:     "providers.ops.type",
+		},
+		{
+			name:    "bad duration",
+			content: "retry:\n  max_backoff: soon\n",
+			key:     "retry.max_backoff",
+		},
+		{
+			name:    "unknown route target",
+			content: "providers:\n  slack:\n    token: x\nroutes:\n  critical: [slack, pager]\n",
+			key:     "routes.critical",
+		},
+		{
+			name:    "missing env",
+			content: "providers:\n  slack:\n    token: ${NOTIFY_TEST_UNSET_VARIABLE}\n",
+			key:     "providers.slack.token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, "notify.yaml", tt.content))
+
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) {
+				t.Fatalf("Expected ConfigError, got %v", err)
+			}
+
+			if configErr.Key != tt.key {
+				t.Errorf("Expected key '%s', got '%s' (%v)", tt.key, configErr.Key, err)
+			}
+		})
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("NOTIFYTEST_PROVIDERS", "ops-slack,oncall")
+	t.Setenv("NOTIFYTEST_OPS_SLACK_TYPE", "slack")
+	t.Setenv("NOTIFYTEST_OPS_SLACK_TOKEN", "xoxb-test")
+	t.Setenv("NOTIFYTEST_ONCALL_TYPE", "telegram")
+	t.Setenv("NOTIFYTEST_ONCALL_BOT_TOKEN", "123:ABC")
+	t.Setenv("NOTIFYTEST_ONCALL_CHAT_ID", "42")
+	t.Setenv("NOTIFYTEST_ROUTE_CRITICAL", "ops-slack,oncall")
+	t.Setenv("NOTIFYTEST_RETRY_MAX_ATTEMPTS", "2")
+
+	manager, err := FromEnv("NOTIFYTEST")
+	if err != nil {
+		t.Fatalf("Failed to load from env: %v", err)
+	}
+
+	if len(manager.List()) != 2 {
+		t.Errorf("Expected 2 notifiers, got %d", len(manager.List()))
+	}
+
+	if manager.retry.MaxAttempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", manager.retry.MaxAttempts)
+	}
+}
+
+func TestFromEnvErrorNamesVariable(t *testing.T) {
+	t.Setenv("NOTIFYTEST_TELEGRAM_BOT_TOKEN", "123:ABC")
+
+	_, err := FromEnv("NOTIFYTEST")
+	if err == nil || !strings.Contains(err.Error(), "NOTIFYTEST_TELEGRAM_CHAT_ID") {
+		t.Errorf("Expected error naming NOTIFYTEST_TELEGRAM_CHAT_ID, got %v", err)
+	}
+}
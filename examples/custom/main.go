@@ -18,6 +18,17 @@ func NewCustomNotifier(name string) *CustomNotifier {
 	return &CustomNotifier{name: name}
 }
 
+// init registers the provider so config files and service URLs can create it
+func init() {
+	notify.RegisterProvider("console", func(raw map[string]interface{}) (notify.Notifier, error) {
+		name, _ := raw["name"].(string)
+		if name == "" {
+			name = "console"
+		}
+		return NewCustomNotifier(name), nil
+	})
+}
+
 // Name returns the name of the provider
 func (c *CustomNotifier) Name() string {
 	return c.name
@@ -38,6 +49,10 @@ func (c *CustomNotifier) SendWithOptions(ctx context.Context, msg *notify.Messag
 		fmt.Printf("  Title: %s\n", msg.Title)
 	}
 	fmt.Printf("  Text: %s\n", msg.Text)
+	// Without buttons, show link actions as plain links
+	if links := notify.ActionsText(msg.Actions); links != "" {
+		fmt.Printf("  Links:\n%s\n", links)
+	}
 	if msg.Priority != "" {
 		fmt.Printf("  Priority: %s\n", msg.Priority)
 	}
@@ -87,6 +102,14 @@ func main() {
 	manager.Register(emailNotifier)
 	manager.Register(smsNotifier)
 
+	// Providers registered with notify.RegisterProvider can also be created
+	// from service URLs and config files
+	pagerNotifier, err := notify.NewFromURL("console://?name=pager")
+	if err != nil {
+		log.Fatalf("Failed to create notifier from URL: %v", err)
+	}
+	manager.Register(pagerNotifier)
+
 	fmt.Printf("Registered notifiers: %v\n", manager.List())
 
 	// Broadcast to all notifiers
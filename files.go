@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+)
+
+// FileSender is implemented by notifiers that can send files
+type FileSender interface {
+	// SendFiles sends files as a single message where the provider
+	// supports it. Readers are consumed, so a failed send can't be retried
+	// with the same files.
+	SendFiles(ctx context.Context, files []File, opts FileOptions) error
+}
+
+// File is a file to send
+type File struct {
+	// Name is the filename (defaults to "file")
+	Name string
+
+	// Reader supplies the content
+	Reader io.Reader
+
+	// Size is the content length in bytes (optional). When unknown, it is
+	// taken from readers like *os.File and *bytes.Reader, or the content is
+	// read into memory if the provider needs it up front.
+	Size int64
+
+	// Title is shown instead of the filename where supported (optional)
+	Title string
+}
+
+// FileOptions are the options of sending files
+type FileOptions struct {
+	// Channel is the channel or chat to send to (defaults to the provider's)
+	Channel string
+
+	// Comment is a message sent with the files
+	Comment string
+
+	// ThreadID is the thread or message to reply in: a Slack thread
+	// timestamp or a Telegram message ID
+	ThreadID string
+}
+
+// name returns the filename or "file"
+func (f File) name() string {
+	if f.Name == "" {
+		return "file"
+	}
+	return f.Name
+}
+
+// sized returns the content and its size, reading it into memory when the
+// size is unknown
+func (f File) sized() (io.Reader, int64, error) {
+	if f.Size > 0 {
+		return f.Reader, f.Size, nil
+	}
+
+	switch r := f.Reader.(type) {
+	case interface{ Len() int }:
+		return f.Reader, int64(r.Len()), nil
+	case *os.File:
+		info, err := r.Stat()
+		if err != nil {
+			return nil, 0, err
+		}
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if info.Mode().IsRegular() && err == nil {
+			return f.Reader, info.Size() - offset, nil
+		}
+	}
+
+	data, err := io.ReadAll(f.Reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSized(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(path, []byte("0123456789"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opened, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer opened.Close()
+	opened.Seek(4, io.SeekStart)
+
+	tests := []struct {
+		name string
+		file File
+		size int64
+		data string
+	}{
+		{"explicit", File{Reader: strings.NewReader("abc"), Size: 3}, 3, "abc"},
+		{"len", File{Reader: bytes.NewBufferString("abcd")}, 4, "abcd"},
+		{"os.File", File{Reader: opened}, 6, "456789"},
+		{"buffered", File{Reader: io.MultiReader(strings.NewReader("ab"), strings.NewReader("c"))}, 3, "abc"},
+	}
+
+	for _, tt := range tests {
+		r, size, err := tt.file.sized()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		data, _ := io.ReadAll(r)
+		if size != tt.size || string(data) != tt.data {
+			t.Errorf("%s: got %d bytes %q, want %d bytes %q", tt.name, size, data, tt.size, tt.data)
+		}
+	}
+}
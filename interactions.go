@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"context"
+	"sync"
+)
+
+// Interaction is a click on a callback action of a sent message
+type Interaction struct {
+	// Provider is the name of the notifier that sent the message
+	Provider string
+
+	// User is the user who clicked
+	User User
+
+	// ActionID and Value are those of the clicked action
+	ActionID string
+	Value    string
+
+	// MessageRef identifies the message with the action
+	MessageRef MessageRef
+
+	responder interactionResponder
+}
+
+// User is a provider user
+type User struct {
+	// ID is the provider's user ID
+	ID string
+
+	// Name is the username or display name
+	Name string
+}
+
+// MessageRef identifies a sent message: a Slack channel ID and message
+// timestamp, or a Telegram chat ID and message ID
+type MessageRef struct {
+	Channel string
+	ID      string
+}
+
+// interactionResponder replies to and updates messages of a provider
+type interactionResponder interface {
+	reply(ctx context.Context, ref MessageRef, msg *Message) error
+	update(ctx context.Context, ref MessageRef, msg *Message) error
+}
+
+// Reply sends msg as a reply to the message with the action: in its thread
+// on Slack, or as a reply on Telegram
+func (in *Interaction) Reply(ctx context.Context, msg *Message) error {
+	if in.responder == nil {
+		return &NotificationError{
+			Provider: in.Provider,
+			Message:  "interaction can't be replied to",
+		}
+	}
+	return in.responder.reply(ctx, in.MessageRef, msg)
+}
+
+// Update replaces the message with the action by msg. Sending msg without
+// actions removes the buttons.
+func (in *Interaction) Update(ctx context.Context, msg *Message) error {
+	if in.responder == nil {
+		return &NotificationError{
+			Provider: in.Provider,
+			Message:  "interaction can't be updated",
+		}
+	}
+	return in.responder.update(ctx, in.MessageRef, msg)
+}
+
+// InteractionHandler handles an interaction
+type InteractionHandler func(ctx context.Context, in *Interaction) error
+
+// Interactions dispatches interactions to handlers by action ID. It is safe
+// for concurrent use.
+type Interactions struct {
+	mu       sync.RWMutex
+	handlers map[string]InteractionHandler
+	fallback InteractionHandler
+}
+
+// NewInteractions creates an empty interaction dispatcher
+func NewInteractions() *Interactions {
+	return &Interactions{handlers: make(map[string]InteractionHandler)}
+}
+
+// Handle registers the handler of an action ID, replacing any previous one
+func (r *Interactions) Handle(actionID string, handler InteractionHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[actionID] = handler
+}
+
+// HandleDefault registers the handler of actions without their own handler
+func (r *Interactions) HandleDefault(handler InteractionHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = handler
+}
+
+// Dispatch calls the handler of an interaction. Interactions without a
+// handler are ignored.
+func (r *Interactions) Dispatch(ctx context.Context, in *Interaction) error {
+	r.mu.RLock()
+	handler, exists := r.handlers[in.ActionID]
+	if !exists {
+		handler = r.fallback
+	}
+	r.mu.RUnlock()
+
+	if handler == nil {
+		return nil
+	}
+	return handler(ctx, in)
+}
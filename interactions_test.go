@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestInteractionsDispatch(t *testing.T) {
+	interactions := NewInteractions()
+
+	var handled []string
+	interactions.Handle("ack", func(ctx context.Context, in *Interaction) error {
+		handled = append(handled, "ack:"+in.Value)
+		return nil
+	})
+	interactions.Handle("fail", func(ctx context.Context, in *Interaction) error {
+		return errors.New("boom")
+	})
+
+	ctx := context.Background()
+	if err := interactions.Dispatch(ctx, &Interaction{ActionID: "ack", Value: "7"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := interactions.Dispatch(ctx, &Interaction{ActionID: "unknown"}); err != nil {
+		t.Errorf("Expected unhandled interactions to be ignored, got %v", err)
+	}
+	if err := interactions.Dispatch(ctx, &Interaction{ActionID: "fail"}); err == nil {
+		t.Error("Expected the handler error")
+	}
+
+	interactions.HandleDefault(func(ctx context.Context, in *Interaction) error {
+		handled = append(handled, "default:"+in.ActionID)
+		return nil
+	})
+	interactions.Dispatch(ctx, &Interaction{ActionID: "unknown"})
+
+	if len(handled) != 2 || handled[0] != "ack:7" || handled[1] != "default:unknown" {
+		t.Errorf("Unexpected dispatches %v", handled)
+	}
+}
+
+func TestInteractionWithoutResponder(t *testing.T) {
+	in := &Interaction{Provider: "custom", ActionID: "ack"}
+	if err := in.Reply(context.Background(), &Message{Text: "ok"}); err == nil {
+		t.Error("Expected an error replying without a provider")
+	}
+	if err := in.Update(context.Background(), &Message{Text: "ok"}); err == nil {
+		t.Error("Expected an error updating without a provider")
+	}
+}
@@ -3,22 +3,53 @@ package notify
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Manager manages multiple notification providers
 type Manager struct {
-	notifiers map[string]Notifier
-	mu        sync.RWMutex
+	notifiers  map[string]Notifier
+	wrapped    map[string]Notifier
+	middleware []Middleware
+	overrides  map[string][]Middleware
+	routes     map[string][]string
+	retry      RetryPolicy
+	defaults   Defaults
+	observers  []Observer
+	queued     atomic.Int64
+	queueMu    sync.Mutex
+	mu         sync.RWMutex
 }
 
 // NewManager creates a new notification manager
 func NewManager() *Manager {
 	return &Manager{
 		notifiers: make(map[string]Notifier),
+		wrapped:   make(map[string]Notifier),
+		overrides: make(map[string][]Middleware),
+		routes:    make(map[string][]string),
 	}
 }
 
+// SetRetryPolicy sets the retry policy used for every delivery
+func (m *Manager) SetRetryPolicy(policy RetryPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.retry = policy
+}
+
+// SetDefaults sets the defaults applied to every delivery
+func (m *Manager) SetDefaults(defaults Defaults) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.defaults = defaults
+}
+
 // Register adds a notifier to the manager
 func (m *Manager) Register(notifier Notifier) error {
 	if notifier == nil {
@@ -34,6 +65,7 @@ func (m *Manager) Register(notifier Notifier) error {
 	}
 
 	m.notifiers[name] = notifier
+	m.wrapped[name] = m.wrap(name, notifier)
 	return nil
 }
 
@@ -43,6 +75,7 @@ func (m *Manager) Unregister(name string) {
 	defer m.mu.Unlock()
 
 	delete(m.notifiers, name)
+	delete(m.wrapped, name)
 }
 
 // Get retrieves a notifier by name
@@ -66,34 +99,137 @@ func (m *Manager) List() []string {
 	return names
 }
 
+// Use adds middleware applied to every provider, registered now or later.
+// Middleware added first is the outermost. Sends through the Manager go
+// through the middleware; Get returns the notifier as registered.
+func (m *Manager) Use(middleware ...Middleware) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.middleware = append(m.middleware, middleware...)
+	for name, notifier := range m.notifiers {
+		m.wrapped[name] = m.wrap(name, notifier)
+	}
+}
+
+// UseFor adds middleware for a single provider. It runs inside the
+// middleware added with Use, so it sees messages after them and can
+// override their changes.
+func (m *Manager) UseFor(provider string, middleware ...Middleware) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.overrides[provider] = append(m.overrides[provider], middleware...)
+	if notifier, exists := m.notifiers[provider]; exists {
+		m.wrapped[provider] = m.wrap(provider, notifier)
+	}
+}
+
+// AddObserver registers an observer for delivery events
+func (m *Manager) AddObserver(observer Observer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.observers = append(m.observers, observer)
+}
+
+// SetRoute maps a route name to a list of registered providers
+func (m *Manager) SetRoute(route string, providers ...string) error {
+	if route == "" {
+		return fmt.Errorf("route name cannot be empty")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, name := range providers {
+		if _, exists := m.notifiers[name]; !exists {
+			return fmt.Errorf("route %s: notifier %s not found", route, name)
+		}
+	}
+
+	m.routes[route] = append([]string(nil), providers...)
+	return nil
+}
+
+// Routes returns all configured route names
+func (m *Manager) Routes() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.routes))
+	for name := range m.routes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Route returns the providers of a route
+func (m *Manager) Route(route string) ([]string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	providers, exists := m.routes[route]
+	return append([]string(nil), providers...), exists
+}
+
 // Send sends a message to a specific notifier
 func (m *Manager) Send(ctx context.Context, provider, message string) error {
-	notifier, exists := m.Get(provider)
+	notifier, exists := m.lookup(provider)
 	if !exists {
 		return fmt.Errorf("notifier %s not found", provider)
 	}
 
-	return notifier.Send(ctx, message)
+	return m.deliver(ctx, provider, &Message{Text: message}, func(ctx context.Context) error {
+		return notifier.Send(ctx, message)
+	})
 }
 
 // SendWithOptions sends a message with options to a specific notifier
 func (m *Manager) SendWithOptions(ctx context.Context, provider string, msg *Message) error {
-	notifier, exists := m.Get(provider)
+	notifier, exists := m.lookup(provider)
 	if !exists {
 		return fmt.Errorf("notifier %s not found", provider)
 	}
 
-	return notifier.SendWithOptions(ctx, msg)
+	msg = m.applyDefaults(msg)
+	return m.deliver(ctx, provider, msg, func(ctx context.Context) error {
+		return notifier.SendWithOptions(ctx, msg)
+	})
+}
+
+// SendRoute sends a message with options to every provider of a route
+func (m *Manager) SendRoute(ctx context.Context, route string, msg *Message) []error {
+	m.mu.RLock()
+	providers, exists := m.routes[route]
+	m.mu.RUnlock()
+
+	if !exists {
+		return []error{fmt.Errorf("route %s not found", route)}
+	}
+
+	var errors []error
+	for _, name := range providers {
+		if err := m.SendWithOptions(ctx, name, msg); err != nil {
+			errors = append(errors, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return errors
 }
 
 // Broadcast sends a message to all registered notifiers
 func (m *Manager) Broadcast(ctx context.Context, message string) []error {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	msg := &Message{Text: message}
 
 	var errors []error
-	for name, notifier := range m.notifiers {
-		if err := notifier.Send(ctx, message); err != nil {
+	for name, notifier := range m.snapshot() {
+		notifier := notifier
+		err := m.deliver(ctx, name, msg, func(ctx context.Context) error {
+			return notifier.Send(ctx, message)
+		})
+		if err != nil {
 			errors = append(errors, fmt.Errorf("%s: %w", name, err))
 		}
 	}
@@ -103,12 +239,15 @@ func (m *Manager) Broadcast(ctx context.Context, message string) []error {
 
 // BroadcastWithOptions sends a message with options to all registered notifiers
 func (m *Manager) BroadcastWithOptions(ctx context.Context, msg *Message) []error {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	msg = m.applyDefaults(msg)
 
 	var errors []error
-	for name, notifier := range m.notifiers {
-		if err := notifier.SendWithOptions(ctx, msg); err != nil {
+	for name, notifier := range m.snapshot() {
+		notifier := notifier
+		err := m.deliver(ctx, name, msg, func(ctx context.Context) error {
+			return notifier.SendWithOptions(ctx, msg)
+		})
+		if err != nil {
 			errors = append(errors, fmt.Errorf("%s: %w", name, err))
 		}
 	}
@@ -118,21 +257,21 @@ func (m *Manager) BroadcastWithOptions(ctx context.Context, msg *Message) []erro
 
 // BroadcastAsync sends a message to all registered notifiers asynchronously
 func (m *Manager) BroadcastAsync(ctx context.Context, message string) <-chan NotificationResult {
-	m.mu.RLock()
-	notifiers := make(map[string]Notifier, len(m.notifiers))
-	for name, notifier := range m.notifiers {
-		notifiers[name] = notifier
-	}
-	m.mu.RUnlock()
+	notifiers := m.snapshot()
+	msg := &Message{Text: message}
 
 	resultChan := make(chan NotificationResult, len(notifiers))
 
 	var wg sync.WaitGroup
 	for name, notifier := range notifiers {
 		wg.Add(1)
+		m.adjustQueue(1)
 		go func(n string, nt Notifier) {
 			defer wg.Done()
-			err := nt.Send(ctx, message)
+			defer m.adjustQueue(-1)
+			err := m.deliver(ctx, n, msg, func(ctx context.Context) error {
+				return nt.Send(ctx, message)
+			})
 			resultChan <- NotificationResult{
 				Provider: n,
 				Success:  err == nil,
@@ -151,21 +290,21 @@ func (m *Manager) BroadcastAsync(ctx context.Context, message string) <-chan Not
 
 // BroadcastAsyncWithOptions sends a message with options to all registered notifiers asynchronously
 func (m *Manager) BroadcastAsyncWithOptions(ctx context.Context, msg *Message) <-chan NotificationResult {
-	m.mu.RLock()
-	notifiers := make(map[string]Notifier, len(m.notifiers))
-	for name, notifier := range m.notifiers {
-		notifiers[name] = notifier
-	}
-	m.mu.RUnlock()
+	notifiers := m.snapshot()
+	msg = m.applyDefaults(msg)
 
 	resultChan := make(chan NotificationResult, len(notifiers))
 
 	var wg sync.WaitGroup
 	for name, notifier := range notifiers {
 		wg.Add(1)
+		m.adjustQueue(1)
 		go func(n string, nt Notifier) {
 			defer wg.Done()
-			err := nt.SendWithOptions(ctx, msg)
+			defer m.adjustQueue(-1)
+			err := m.deliver(ctx, n, msg, func(ctx context.Context) error {
+				return nt.SendWithOptions(ctx, msg)
+			})
 			resultChan <- NotificationResult{
 				Provider: n,
 				Success:  err == nil,
@@ -188,3 +327,141 @@ type NotificationResult struct {
 	Success  bool
 	Error    error
 }
+
+// snapshot returns a copy of the registered notifiers wrapped in their middleware
+func (m *Manager) snapshot() map[string]Notifier {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	notifiers := make(map[string]Notifier, len(m.wrapped))
+	for name, notifier := range m.wrapped {
+		notifiers[name] = notifier
+	}
+	return notifiers
+}
+
+// lookup returns a registered notifier wrapped in its middleware
+func (m *Manager) lookup(name string) (Notifier, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	notifier, exists := m.wrapped[name]
+	return notifier, exists
+}
+
+// wrap applies the global and provider middleware to a notifier.
+// The caller must hold m.mu.
+func (m *Manager) wrap(name string, notifier Notifier) Notifier {
+	if len(m.middleware) == 0 && len(m.overrides[name]) == 0 {
+		return notifier
+	}
+
+	middleware := append(append([]Middleware(nil), m.middleware...), m.overrides[name]...)
+	return Chain(middleware...)(notifier)
+}
+
+// applyDefaults returns msg with the manager defaults filled in, and the
+// plain text of rich messages without Text
+func (m *Manager) applyDefaults(msg *Message) *Message {
+	m.mu.RLock()
+	priority := m.defaults.Priority
+	m.mu.RUnlock()
+
+	if msg == nil {
+		return msg
+	}
+
+	needsPriority := msg.Priority == "" && priority != ""
+	needsText := msg.Text == "" && msg.Rich != nil
+	if !needsPriority && !needsText {
+		return msg
+	}
+
+	withDefaults := *msg
+	if needsPriority {
+		withDefaults.Priority = priority
+	}
+	if needsText {
+		withDefaults.Text = msg.Rich.Plain()
+	}
+	return &withDefaults
+}
+
+// deliver runs send for the named provider, retrying retryable failures
+// according to the retry policy and reporting every attempt to the observers
+func (m *Manager) deliver(ctx context.Context, provider string, msg *Message, send func(context.Context) error) error {
+	m.mu.RLock()
+	policy := m.retry
+	timeout := m.defaults.Timeout
+	observers := m.observers
+	m.mu.RUnlock()
+
+	attempts := policy.attempts()
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err := attemptWithTimeout(ctx, timeout, send)
+		final := err == nil || attempt >= attempts || ctx.Err() != nil || !Retryable(err)
+
+		for _, o := range observers {
+			o.OnAttempt(ctx, AttemptEvent{
+				Provider: provider,
+				Message:  msg,
+				Attempt:  attempt,
+				Duration: time.Since(start),
+				Err:      err,
+				Final:    final,
+			})
+		}
+
+		if final {
+			return err
+		}
+
+		delay := policy.backoff(attempt)
+		for _, o := range observers {
+			o.OnRetry(ctx, RetryEvent{
+				Provider: provider,
+				Message:  msg,
+				Attempt:  attempt,
+				Delay:    delay,
+				Err:      err,
+			})
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// adjustQueue changes the number of pending asynchronous deliveries. Changes
+// are reported under queueMu so observers see depths in order and the last
+// depth they see is the current one.
+func (m *Manager) adjustQueue(delta int64) {
+	m.mu.RLock()
+	observers := m.observers
+	m.mu.RUnlock()
+
+	m.queueMu.Lock()
+	defer m.queueMu.Unlock()
+
+	depth := m.queued.Add(delta)
+	for _, o := range observers {
+		o.OnQueueDepth(int(depth))
+	}
+}
+
+// attemptWithTimeout runs a single delivery attempt bounded by timeout
+func attemptWithTimeout(ctx context.Context, timeout time.Duration, send func(context.Context) error) error {
+	if timeout <= 0 {
+		return send(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return send(ctx)
+}
@@ -2,7 +2,13 @@ package notify
 
 import (
 	"context"
+	"fmt"
+	"runtime"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/milano15662/notify/richtext"
 )
 
 func TestNewManager(t *testing.T) {
@@ -157,6 +163,47 @@ func TestManagerBroadcastAsync(t *testing.T) {
 	}
 }
 
+// depthObserver records the reported queue depths
+type depthObserver struct {
+	mu     sync.Mutex
+	depths []int
+}
+
+func (o *depthObserver) OnAttempt(ctx context.Context, event AttemptEvent) {}
+func (o *depthObserver) OnRetry(ctx context.Context, event RetryEvent)     {}
+
+func (o *depthObserver) OnQueueDepth(depth int) {
+	runtime.Gosched()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.depths = append(o.depths, depth)
+}
+
+func TestManagerQueueDepthReportsInOrder(t *testing.T) {
+	manager := NewManager()
+	for i := 0; i < 20; i++ {
+		manager.Register(NewMockNotifier(fmt.Sprintf("test%d", i)))
+	}
+	observer := &depthObserver{}
+	manager.AddObserver(observer)
+
+	for i := 0; i < 10; i++ {
+		for range manager.BroadcastAsync(context.Background(), "Async message") {
+		}
+	}
+
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	if len(observer.depths) != 400 || observer.depths[len(observer.depths)-1] != 0 {
+		t.Fatalf("Expected 400 reports ending at 0, got %d ending at %d", len(observer.depths), observer.depths[len(observer.depths)-1])
+	}
+	for i := 1; i < len(observer.depths); i++ {
+		if diff := observer.depths[i] - observer.depths[i-1]; diff != 1 && diff != -1 {
+			t.Fatalf("Expected depths in order, got %d after %d", observer.depths[i], observer.depths[i-1])
+		}
+	}
+}
+
 func TestManagerSendWithOptions(t *testing.T) {
 	manager := NewManager()
 	notifier := NewMockNotifier("test")
@@ -178,3 +225,132 @@ func TestManagerSendWithOptions(t *testing.T) {
 		t.Error("Expected SendWithOptions to be called")
 	}
 }
+
+func TestManagerSendRoute(t *testing.T) {
+	manager := NewManager()
+	notifier1 := NewMockNotifier("test1")
+	notifier2 := NewMockNotifier("test2")
+	notifier3 := NewMockNotifier("test3")
+
+	manager.Register(notifier1)
+	manager.Register(notifier2)
+	manager.Register(notifier3)
+
+	if err := manager.SetRoute("critical", "test1", "test2"); err != nil {
+		t.Fatalf("Failed to set route: %v", err)
+	}
+
+	if err := manager.SetRoute("broken", "missing"); err == nil {
+		t.Error("Expected error for route with unknown provider")
+	}
+
+	errors := manager.SendRoute(context.Background(), "critical", &Message{Text: "Routed"})
+	if len(errors) != 0 {
+		t.Errorf("Expected no errors, got %v", errors)
+	}
+
+	if !notifier1.sendCalled || !notifier2.sendCalled || notifier3.sendCalled {
+		t.Error("Expected only route providers to be called")
+	}
+
+	if errors := manager.SendRoute(context.Background(), "unknown", &Message{Text: "x"}); len(errors) != 1 {
+		t.Errorf("Expected 1 error for unknown route, got %d", len(errors))
+	}
+}
+
+// flakyNotifier fails a fixed number of times before succeeding
+type flakyNotifier struct {
+	*MockNotifier
+	failures int
+	calls    int
+	err      error
+}
+
+func (f *flakyNotifier) SendWithOptions(ctx context.Context, msg *Message) error {
+	f.calls++
+	if f.calls <= f.failures {
+		if f.err != nil {
+			return f.err
+		}
+		return &NotificationError{Provider: f.name, Message: "temporary failure", Err: context.DeadlineExceeded}
+	}
+	return f.MockNotifier.SendWithOptions(ctx, msg)
+}
+
+func TestManagerRetry(t *testing.T) {
+	manager := NewManager()
+	notifier := &flakyNotifier{MockNotifier: NewMockNotifier("flaky"), failures: 2}
+	manager.Register(notifier)
+	manager.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	err := manager.SendWithOptions(context.Background(), "flaky", &Message{Text: "Retry me"})
+	if err != nil {
+		t.Fatalf("Expected success after retries, got %v", err)
+	}
+
+	if notifier.calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", notifier.calls)
+	}
+}
+
+func TestManagerDoesNotRetryPermanentErrors(t *testing.T) {
+	manager := NewManager()
+	notifier := &flakyNotifier{MockNotifier: NewMockNotifier("flaky"), failures: 2,
+		err: &NotificationError{Provider: "flaky", Message: "message text is required"}}
+	manager.Register(notifier)
+	manager.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	if err := manager.SendWithOptions(context.Background(), "flaky", &Message{}); err == nil {
+		t.Fatal("Expected the permanent error")
+	}
+	if notifier.calls != 1 {
+		t.Errorf("Expected a single attempt, got %d", notifier.calls)
+	}
+}
+
+func TestManagerDefaultPriority(t *testing.T) {
+	manager := NewManager()
+	notifier := &priorityNotifier{MockNotifier: NewMockNotifier("test")}
+	manager.Register(notifier)
+	manager.SetDefaults(Defaults{Priority: PriorityLow})
+
+	msg := &Message{Text: "Quiet"}
+	manager.SendWithOptions(context.Background(), "test", msg)
+
+	if notifier.priority != PriorityLow {
+		t.Errorf("Expected priority '%s', got '%s'", PriorityLow, notifier.priority)
+	}
+
+	if msg.Priority != "" {
+		t.Error("Expected caller's message to be left unchanged")
+	}
+}
+
+func TestManagerRichTextFallback(t *testing.T) {
+	manager := NewManager()
+	notifier := NewMockNotifier("test")
+	manager.Register(notifier)
+
+	msg := &Message{Rich: richtext.New().Bold("Disk").Text(" full on ").Code("db-1").Document()}
+	if err := manager.SendWithOptions(context.Background(), "test", msg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if notifier.lastMessage != "Disk full on db-1" {
+		t.Errorf("Expected the plain text of the rich text, got %q", notifier.lastMessage)
+	}
+	if msg.Text != "" {
+		t.Error("Expected caller's message to be left unchanged")
+	}
+}
+
+// priorityNotifier records the priority of the last message
+type priorityNotifier struct {
+	*MockNotifier
+	priority string
+}
+
+func (p *priorityNotifier) SendWithOptions(ctx context.Context, msg *Message) error {
+	p.priority = msg.Priority
+	return p.MockNotifier.SendWithOptions(ctx, msg)
+}
@@ -0,0 +1,265 @@
+// Package metrics instruments notification delivery and exposes the results
+// in the Prometheus text exposition format.
+//
+//	collector := metrics.NewCollector()
+//	manager.AddObserver(collector)
+//	http.Handle("/metrics", collector)
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/milano15662/notify"
+)
+
+// Delivery outcomes used as the outcome label
+const (
+	OutcomeSuccess    = "success"
+	OutcomeFailure    = "failure"
+	OutcomeTimeout    = "timeout"
+	OutcomeCanceled   = "canceled"
+	OutcomeSuppressed = "suppressed"
+)
+
+// DefaultBuckets are the latency histogram buckets in seconds
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// contentType is the Prometheus text exposition format content type
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Collector is a notify.Observer that records delivery metrics. It is also
+// an http.Handler serving them in the Prometheus text exposition format.
+//
+// Exposed metrics:
+//
+//	notify_deliveries_total{provider,outcome}        counter
+//	notify_attempts_total{provider,outcome}          counter
+//	notify_attempt_duration_seconds{provider}        histogram
+//	notify_retries_total{provider}                   counter
+//	notify_async_queue_depth                         gauge
+type Collector struct {
+	buckets []float64
+
+	mu         sync.Mutex
+	deliveries map[labels]uint64
+	attempts   map[labels]uint64
+	durations  map[string]*histogram
+	retries    map[string]uint64
+	queueDepth int
+}
+
+// labels identifies a provider and outcome pair
+type labels struct {
+	provider string
+	outcome  string
+}
+
+// histogram holds cumulative bucket counts
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewCollector creates a collector using the given latency buckets in
+// seconds, or DefaultBuckets when none are given. Non-finite and duplicate
+// bounds are dropped, since the +Inf bucket is always exposed.
+func NewCollector(buckets ...float64) *Collector {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	sorted := make([]float64, 0, len(buckets))
+	for _, bound := range buckets {
+		if !math.IsInf(bound, 0) && !math.IsNaN(bound) {
+			sorted = append(sorted, bound)
+		}
+	}
+	sort.Float64s(sorted)
+	sorted = slices.Compact(sorted)
+
+	return &Collector{
+		buckets:    sorted,
+		deliveries: make(map[labels]uint64),
+		attempts:   make(map[labels]uint64),
+		durations:  make(map[string]*histogram),
+		retries:    make(map[string]uint64),
+	}
+}
+
+// OnAttempt implements notify.Observer
+func (c *Collector) OnAttempt(ctx context.Context, event notify.AttemptEvent) {
+	key := labels{provider: event.Provider, outcome: Outcome(event.Err)}
+	seconds := event.Duration.Seconds()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.attempts[key]++
+	if event.Final {
+		c.deliveries[key]++
+	}
+
+	h, exists := c.durations[event.Provider]
+	if !exists {
+		h = &histogram{counts: make([]uint64, len(c.buckets))}
+		c.durations[event.Provider] = h
+	}
+	for i, bound := range c.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// OnRetry implements notify.Observer
+func (c *Collector) OnRetry(ctx context.Context, event notify.RetryEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.retries[event.Provider]++
+}
+
+// OnQueueDepth implements notify.Observer
+func (c *Collector) OnQueueDepth(depth int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.queueDepth = depth
+}
+
+// Outcome classifies a delivery error as an outcome label
+func Outcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeSuccess
+	case errors.Is(err, notify.ErrSuppressed):
+		return OutcomeSuppressed
+	case errors.Is(err, context.DeadlineExceeded):
+		return OutcomeTimeout
+	case errors.Is(err, context.Canceled):
+		return OutcomeCanceled
+	default:
+		return OutcomeFailure
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if r.Method == http.MethodHead {
+		return
+	}
+	c.WriteTo(w)
+}
+
+// WriteTo writes all metrics to w in the Prometheus text exposition format
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	c.mu.Lock()
+	writeCounters(&b, "notify_deliveries_total", "Notifications delivered, by final outcome.", c.deliveries)
+	writeCounters(&b, "notify_attempts_total", "Delivery attempts, including retries.", c.attempts)
+	c.writeDurations(&b)
+
+	b.WriteString("# HELP notify_retries_total Delivery attempts that were retried.\n")
+	b.WriteString("# TYPE notify_retries_total counter\n")
+	for _, provider := range sortedKeys(c.retries) {
+		fmt.Fprintf(&b, "notify_retries_total{provider=%s} %d\n", quote(provider), c.retries[provider])
+	}
+
+	b.WriteString("# HELP notify_async_queue_depth Asynchronous deliveries in flight.\n")
+	b.WriteString("# TYPE notify_async_queue_depth gauge\n")
+	fmt.Fprintf(&b, "notify_async_queue_depth %d\n", c.queueDepth)
+	c.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeCounters writes a counter family labelled by provider and outcome
+func writeCounters(b *strings.Builder, name, help string, values map[labels]uint64) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s counter\n", name)
+
+	keys := make([]labels, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].provider != keys[j].provider {
+			return keys[i].provider < keys[j].provider
+		}
+		return keys[i].outcome < keys[j].outcome
+	})
+
+	for _, key := range keys {
+		fmt.Fprintf(b, "%s{provider=%s,outcome=%s} %d\n", name, quote(key.provider), quote(key.outcome), values[key])
+	}
+}
+
+// writeDurations writes the attempt latency histograms
+func (c *Collector) writeDurations(b *strings.Builder) {
+	const name = "notify_attempt_duration_seconds"
+	fmt.Fprintf(b, "# HELP %s Duration of delivery attempts.\n", name)
+	fmt.Fprintf(b, "# TYPE %s histogram\n", name)
+
+	providers := make([]string, 0, len(c.durations))
+	for provider := range c.durations {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	for _, provider := range providers {
+		h := c.durations[provider]
+		p := quote(provider)
+		for i, bound := range c.buckets {
+			fmt.Fprintf(b, "%s_bucket{provider=%s,le=\"%s\"} %d\n", name, p, formatFloat(bound), h.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{provider=%s,le=\"+Inf\"} %d\n", name, p, h.count)
+		fmt.Fprintf(b, "%s_sum{provider=%s} %s\n", name, p, formatFloat(h.sum))
+		fmt.Fprintf(b, "%s_count{provider=%s} %d\n", name, p, h.count)
+	}
+}
+
+// quote formats a label value, escaping backslashes, quotes and newlines
+func quote(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return `"` + value + `"`
+}
+
+// formatFloat formats a sample value
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
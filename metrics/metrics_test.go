@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milano15662/notify"
+	"github.com/milano15662/notify/notifytest"
+)
+
+func scrape(t *testing.T, c *Collector) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	return rec.Body.String()
+}
+
+func TestCollectorManager(t *testing.T) {
+	collector := NewCollector()
+
+	manager := notify.NewManager()
+	manager.Register(notifytest.New("slack", notifytest.Fail(errors.New("temporary failure"))))
+	manager.Register(notifytest.New("telegram", notifytest.FailAlways(errors.New("temporary failure"))))
+	manager.SetRetryPolicy(notify.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+	manager.AddObserver(collector)
+
+	manager.Send(context.Background(), "slack", "hello")
+	manager.Send(context.Background(), "telegram", "hello")
+
+	body := scrape(t, collector)
+	want := []string{
+		`notify_deliveries_total{provider="slack",outcome="success"} 1`,
+		`notify_deliveries_total{provider="telegram",outcome="failure"} 1`,
+		`notify_attempts_total{provider="slack",outcome="failure"} 1`,
+		`notify_attempts_total{provider="slack",outcome="success"} 1`,
+		`notify_attempts_total{provider="telegram",outcome="failure"} 2`,
+		`notify_attempt_duration_seconds_bucket{provider="slack",le="+Inf"} 2`,
+		`notify_attempt_duration_seconds_count{provider="telegram"} 2`,
+		`notify_retries_total{provider="slack"} 1`,
+		`notify_retries_total{provider="telegram"} 1`,
+		`notify_async_queue_depth 0`,
+		"# TYPE notify_attempt_duration_seconds histogram",
+	}
+	for _, line := range want {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected %q in:\n%s", line, body)
+		}
+	}
+}
+
+func TestCollectorHistogramBuckets(t *testing.T) {
+	collector := NewCollector(1, 0.1)
+	collector.OnAttempt(context.Background(), notify.AttemptEvent{Provider: "p", Duration: 62500 * time.Microsecond, Final: true})
+	collector.OnAttempt(context.Background(), notify.AttemptEvent{Provider: "p", Duration: 500 * time.Millisecond, Final: true})
+	collector.OnAttempt(context.Background(), notify.AttemptEvent{Provider: "p", Duration: 2 * time.Second, Final: true})
+
+	body := scrape(t, collector)
+	for _, line := range []string{
+		`notify_attempt_duration_seconds_bucket{provider="p",le="0.1"} 1`,
+		`notify_attempt_duration_seconds_bucket{provider="p",le="1"} 2`,
+		`notify_attempt_duration_seconds_bucket{provider="p",le="+Inf"} 3`,
+		`notify_attempt_duration_seconds_sum{provider="p"} 2.5625`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected %q in:\n%s", line, body)
+		}
+	}
+}
+
+func TestCollectorIgnoresInfiniteBuckets(t *testing.T) {
+	collector := NewCollector(math.Inf(1), 1, math.NaN(), 1, math.Inf(-1))
+	collector.OnAttempt(context.Background(), notify.AttemptEvent{Provider: "p", Duration: 2 * time.Second, Final: true})
+
+	body := scrape(t, collector)
+	if n := strings.Count(body, `le="+Inf"`); n != 1 {
+		t.Errorf("Expected one +Inf bucket, got %d in:\n%s", n, body)
+	}
+
+	if n := strings.Count(body, `le="1"`); n != 1 {
+		t.Errorf("Expected one le=\"1\" bucket, got %d in:\n%s", n, body)
+	}
+
+	if strings.Contains(body, "NaN") || strings.Contains(body, "-Inf") {
+		t.Errorf("Expected no NaN or -Inf buckets in:\n%s", body)
+	}
+}
+
+func TestCollectorQueueDepth(t *testing.T) {
+	collector := NewCollector()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	manager := notify.NewManager()
+	manager.Register(notifytest.New("blocking", notifytest.Latency(time.Hour)))
+	manager.AddObserver(collector)
+
+	results := manager.BroadcastAsync(ctx, "hello")
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(scrape(t, collector), "notify_async_queue_depth 1\n") {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected queue depth 1 while delivery is blocked")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	for range results {
+	}
+
+	if body := scrape(t, collector); !strings.Contains(body, "notify_async_queue_depth 0\n") {
+		t.Errorf("Expected queue depth 0 after delivery, got:\n%s", body)
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	tests := map[error]string{
+		nil:                      OutcomeSuccess,
+		errors.New("boom"):       OutcomeFailure,
+		context.DeadlineExceeded: OutcomeTimeout,
+		&notify.NotificationError{Err: context.Canceled}:  OutcomeCanceled,
+		fmt.Errorf("duplicate: %w", notify.ErrSuppressed): OutcomeSuppressed,
+	}
+	for err, want := range tests {
+		if got := Outcome(err); got != want {
+			t.Errorf("Outcome(%v) = %q, want %q", err, got, want)
+		}
+	}
+}
+
+func TestQuoteEscapes(t *testing.T) {
+	if got := quote("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("Unexpected escaping: %s", got)
+	}
+}
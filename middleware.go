@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"reflect"
+)
+
+// Middleware wraps a Notifier to add behavior around its sends, such as
+// logging, deduplication or message enrichment
+type Middleware func(Notifier) Notifier
+
+// Chain composes middleware into one. The first middleware is the outermost,
+// so it sees each message first.
+func Chain(middleware ...Middleware) Middleware {
+	return func(notifier Notifier) Notifier {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			notifier = middleware[i](notifier)
+		}
+		return notifier
+	}
+}
+
+// SendFunc sends a message
+type SendFunc func(ctx context.Context, msg *Message) error
+
+// InterceptFunc handles a message on its way to a notifier. It may inspect
+// or modify msg, call next to continue, or return without calling it.
+type InterceptFunc func(ctx context.Context, msg *Message, next SendFunc) error
+
+// Intercept creates middleware from an InterceptFunc. Plain text sends are
+// passed to fn as a Message. Each call receives its own copy of the message,
+// so fn may modify it freely.
+//
+//	stamp := notify.Intercept(func(ctx context.Context, msg *notify.Message, next notify.SendFunc) error {
+//		msg.Title = "[prod] " + msg.Title
+//		return next(ctx, msg)
+//	})
+//	manager.Use(stamp)
+func Intercept(fn InterceptFunc) Middleware {
+	return func(notifier Notifier) Notifier {
+		return &interceptor{next: notifier, fn: fn}
+	}
+}
+
+// interceptor is the Notifier created by Intercept
+type interceptor struct {
+	next Notifier
+	fn   InterceptFunc
+}
+
+// Name returns the wrapped notifier's name
+func (i *interceptor) Name() string {
+	return i.next.Name()
+}
+
+// Unwrap returns the wrapped notifier
+func (i *interceptor) Unwrap() Notifier {
+	return i.next
+}
+
+// Send sends a simple text message through the interceptor
+func (i *interceptor) Send(ctx context.Context, message string) error {
+	return i.fn(ctx, &Message{Text: message}, i.next.SendWithOptions)
+}
+
+// SendWithOptions sends a message with options through the interceptor
+func (i *interceptor) SendWithOptions(ctx context.Context, msg *Message) error {
+	return i.fn(ctx, msg.Clone(), i.next.SendWithOptions)
+}
+
+// Unwrap returns the notifier wrapped by notifier, or nil when notifier
+// has no Unwrap() Notifier method
+func Unwrap(notifier Notifier) Notifier {
+	wrapper, ok := notifier.(interface{ Unwrap() Notifier })
+	if !ok {
+		return nil
+	}
+	return wrapper.Unwrap()
+}
+
+// As finds the first notifier in the chain of notifier and the notifiers it
+// wraps that is assignable to the value pointed to by target, and if so, sets
+// target to it and returns true. Like errors.As, it panics if target is not
+// a non-nil pointer. Use it to detect optional interfaces such as FileSender,
+// or provider types, through middleware:
+//
+//	var sender notify.FileSender
+//	if notify.As(notifier, &sender) {
+//		err = sender.SendFiles(ctx, files, notify.FileOptions{})
+//	}
+func As(notifier Notifier, target interface{}) bool {
+	value := reflect.ValueOf(target)
+	if target == nil || value.Kind() != reflect.Ptr || value.IsNil() {
+		panic("notify: target must be a non-nil pointer")
+	}
+
+	targetType := value.Type().Elem()
+	for ; notifier != nil; notifier = Unwrap(notifier) {
+		if reflect.TypeOf(notifier).AssignableTo(targetType) {
+			value.Elem().Set(reflect.ValueOf(notifier))
+			return true
+		}
+	}
+	return false
+}
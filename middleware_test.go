@@ -0,0 +1,139 @@
+package notify_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/milano15662/notify"
+	"github.com/milano15662/notify/notifytest"
+)
+
+// tag returns middleware that appends label to message titles
+func tag(label string) notify.Middleware {
+	return notify.Intercept(func(ctx context.Context, msg *notify.Message, next notify.SendFunc) error {
+		msg.Title += label
+		return next(ctx, msg)
+	})
+}
+
+func TestChainOrder(t *testing.T) {
+	notifier := notifytest.New("test")
+	wrapped := notify.Chain(tag("a"), tag("b"), tag("c"))(notifier)
+
+	if wrapped.Name() != "test" {
+		t.Errorf("Expected wrapped name test, got %s", wrapped.Name())
+	}
+
+	wrapped.SendWithOptions(context.Background(), &notify.Message{Text: "hi"})
+
+	sent := received(notifier)
+	if len(sent) != 1 || sent[0].Title != "abc" {
+		t.Errorf("Expected middleware to run in order, got %+v", sent)
+	}
+}
+
+func TestInterceptCopiesMessage(t *testing.T) {
+	notifier := notifytest.New("test")
+	enrich := notify.Intercept(func(ctx context.Context, msg *notify.Message, next notify.SendFunc) error {
+		msg.Metadata["env"] = "prod"
+		msg.Attachments[0].Fields = append(msg.Attachments[0].Fields, notify.Field{Title: "Env", Value: "prod"})
+		return next(ctx, msg)
+	})
+
+	original := &notify.Message{
+		Text:        "hi",
+		Metadata:    map[string]interface{}{},
+		Attachments: []notify.Attachment{{Title: "details"}},
+	}
+	enrich(notifier).SendWithOptions(context.Background(), original)
+
+	if len(original.Metadata) != 0 || len(original.Attachments[0].Fields) != 0 {
+		t.Errorf("Expected the caller's message to be unchanged, got %+v", original)
+	}
+
+	sent := received(notifier)[0]
+	if sent.Metadata["env"] != "prod" || len(sent.Attachments[0].Fields) != 1 {
+		t.Errorf("Expected the notifier to receive the modified message, got %+v", sent)
+	}
+}
+
+func TestManagerUse(t *testing.T) {
+	manager := notify.NewManager()
+	early := notifytest.New("early")
+	manager.Register(early)
+
+	manager.Use(tag("[global]"))
+	manager.UseFor("late", tag("[late]"))
+
+	late := notifytest.New("late")
+	manager.Register(late)
+
+	manager.Send(context.Background(), "early", "hello")
+	manager.BroadcastWithOptions(context.Background(), &notify.Message{Text: "all"})
+
+	if sent := received(early); len(sent) != 2 || sent[0].Title != "[global]" || sent[0].Text != "hello" {
+		t.Errorf("Expected global middleware for early, got %+v", sent)
+	}
+	if sent := received(late); len(sent) != 1 || sent[0].Title != "[global][late]" {
+		t.Errorf("Expected global then provider middleware for late, got %+v", sent)
+	}
+
+	if registered, _ := manager.Get("early"); registered != early {
+		t.Error("Expected Get to return the notifier as registered")
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	manager := notify.NewManager()
+	notifier := notifytest.New("test")
+	manager.Register(notifier)
+
+	manager.Use(notify.Intercept(func(ctx context.Context, msg *notify.Message, next notify.SendFunc) error {
+		if strings.Contains(msg.Text, "noise") {
+			return notify.ErrSuppressed
+		}
+		return next(ctx, msg)
+	}))
+
+	if err := manager.Send(context.Background(), "test", "noise"); err != notify.ErrSuppressed {
+		t.Errorf("Expected notify.ErrSuppressed, got %v", err)
+	}
+	if err := manager.Send(context.Background(), "test", "signal"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if sent := received(notifier); len(sent) != 1 || sent[0].Text != "signal" {
+		t.Errorf("Expected only the signal to be sent, got %+v", sent)
+	}
+}
+
+func TestAsThroughMiddleware(t *testing.T) {
+	slack, err := notify.NewSlackNotifier(notify.SlackConfig{Token: "xoxb-test", DefaultChannel: "C0123456"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped := notify.Chain(tag("a"), tag("b"))(slack)
+
+	if notify.Unwrap(notify.Unwrap(wrapped)) != notify.Notifier(slack) {
+		t.Error("Expected notify.Unwrap to return the wrapped notifiers")
+	}
+	if notify.Unwrap(slack) != nil {
+		t.Error("Expected nil for a notifier that wraps nothing")
+	}
+
+	var sender notify.FileSender
+	if !notify.As(wrapped, &sender) || sender != notify.FileSender(slack) {
+		t.Errorf("Expected to find the notify.FileSender through middleware, got %v", sender)
+	}
+
+	var found *notify.SlackNotifier
+	if !notify.As(wrapped, &found) || found != slack {
+		t.Errorf("Expected to find the notify.SlackNotifier through middleware, got %v", found)
+	}
+
+	var telegram *notify.TelegramNotifier
+	if notify.As(wrapped, &telegram) {
+		t.Error("Expected no notify.TelegramNotifier in the chain")
+	}
+}
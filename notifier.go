@@ -2,7 +2,10 @@ package notify
 
 import (
 	"context"
+	"errors"
 	"fmt"
+
+	"github.com/milano15662/notify/richtext"
 )
 
 // Notifier defines the interface that all notification providers must implement
@@ -20,40 +23,79 @@ type Notifier interface {
 // Message represents a notification message with options
 type Message struct {
 	// Text is the main message content
-	Text string
+	Text string `json:"text"`
 
 	// Title is an optional title for the message
-	Title string
+	Title string `json:"title,omitempty"`
 
 	// Priority defines the message priority (high, normal, low)
-	Priority string
+	Priority string `json:"priority,omitempty"`
 
 	// Channel defines the target channel/chat (provider-specific)
-	Channel string
+	Channel string `json:"channel,omitempty"`
 
 	// Attachments for rich messages (provider-specific)
-	Attachments []Attachment
+	Attachments []Attachment `json:"attachments,omitempty"`
 
 	// Metadata for additional provider-specific data
-	Metadata map[string]interface{}
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Actions are buttons shown below the message: Slack Block Kit buttons
+	// or a Telegram inline keyboard
+	Actions []Action `json:"actions,omitempty"`
+
+	// Rich is formatted content that providers render in their own markup
+	// instead of Text. Text remains the plain fallback for providers without
+	// rich text support; the Manager fills it in from Rich when empty.
+	Rich *richtext.Document `json:"-"`
+}
+
+// hasText reports whether the message has any text content
+func (m *Message) hasText() bool {
+	return m.Text != "" || !m.Rich.Empty()
+}
+
+// Clone returns a deep copy of the message. Rich documents are immutable
+// and shared.
+func (m *Message) Clone() *Message {
+	if m == nil {
+		return nil
+	}
+
+	clone := *m
+	if m.Attachments != nil {
+		clone.Attachments = make([]Attachment, len(m.Attachments))
+		for i, att := range m.Attachments {
+			att.Fields = append([]Field(nil), att.Fields...)
+			clone.Attachments[i] = att
+		}
+	}
+	clone.Actions = append([]Action(nil), m.Actions...)
+	if m.Metadata != nil {
+		clone.Metadata = make(map[string]interface{}, len(m.Metadata))
+		for key, value := range m.Metadata {
+			clone.Metadata[key] = value
+		}
+	}
+	return &clone
 }
 
 // Attachment represents a message attachment
 type Attachment struct {
-	Title      string
-	Text       string
-	ImageURL   string
-	Color      string
-	Fields     []Field
-	Footer     string
-	FooterIcon string
+	Title      string  `json:"title,omitempty"`
+	Text       string  `json:"text,omitempty"`
+	ImageURL   string  `json:"image_url,omitempty"`
+	Color      string  `json:"color,omitempty"`
+	Fields     []Field `json:"fields,omitempty"`
+	Footer     string  `json:"footer,omitempty"`
+	FooterIcon string  `json:"footer_icon,omitempty"`
 }
 
 // Field represents a key-value field in an attachment
 type Field struct {
-	Title string
-	Value string
-	Short bool
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short,omitempty"`
 }
 
 // Priority constants
@@ -63,6 +105,24 @@ const (
 	PriorityLow    = "low"
 )
 
+// ErrSuppressed is returned, possibly wrapped, by notifiers and middleware that
+// intentionally drop a message (e.g., deduplication or rate limiting).
+// Suppressed deliveries are not retried.
+var ErrSuppressed = errors.New("notification suppressed")
+
+// ErrPartialDelivery is returned, wrapped, when only part of a message was
+// delivered, e.g. its text but not its images. Such deliveries are not
+// retried, since a retry would send the delivered part again.
+var ErrPartialDelivery = errors.New("message partially delivered")
+
+// partialDelivery marks err as a failure after part of a message was delivered
+func partialDelivery(err error) error {
+	if errors.Is(err, ErrPartialDelivery) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrPartialDelivery, err)
+}
+
 // NotificationError represents an error that occurred during notification
 type NotificationError struct {
 	Provider string
@@ -80,3 +140,10 @@ func (e *NotificationError) Error() string {
 func (e *NotificationError) Unwrap() error {
 	return e.Err
 }
+
+// Temporary reports whether the failure may go away when retried. Errors
+// without a cause, such as invalid messages and configuration errors, are
+// permanent.
+func (e *NotificationError) Temporary() bool {
+	return e.Err != nil && Retryable(e.Err)
+}
@@ -0,0 +1,152 @@
+package notifytest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milano15662/notify"
+)
+
+// Matcher reports whether a message matches
+type Matcher func(msg *notify.Message) bool
+
+// Any matches every message
+func Any() Matcher {
+	return func(msg *notify.Message) bool { return true }
+}
+
+// Text matches messages with exactly the given text
+func Text(text string) Matcher {
+	return func(msg *notify.Message) bool { return msg.Text == text }
+}
+
+// TextContains matches messages whose text contains substr
+func TextContains(substr string) Matcher {
+	return func(msg *notify.Message) bool { return strings.Contains(msg.Text, substr) }
+}
+
+// Title matches messages with the given title
+func Title(title string) Matcher {
+	return func(msg *notify.Message) bool { return msg.Title == title }
+}
+
+// Priority matches messages with the given priority
+func Priority(priority string) Matcher {
+	return func(msg *notify.Message) bool { return msg.Priority == priority }
+}
+
+// Channel matches messages sent to the given channel
+func Channel(channel string) Matcher {
+	return func(msg *notify.Message) bool { return msg.Channel == channel }
+}
+
+// HasField matches messages with an attachment field of the given title and value
+func HasField(title, value string) Matcher {
+	return func(msg *notify.Message) bool {
+		for _, att := range msg.Attachments {
+			for _, field := range att.Fields {
+				if field.Title == title && field.Value == value {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// All matches messages matched by every matcher
+func All(matchers ...Matcher) Matcher {
+	return func(msg *notify.Message) bool {
+		for _, match := range matchers {
+			if !match(msg) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Count returns the number of messages successfully sent to a provider that match
+func (r *Recorder) Count(provider string, match Matcher) int {
+	count := 0
+	for _, msg := range r.Messages(provider) {
+		if match(msg) {
+			count++
+		}
+	}
+	return count
+}
+
+// AssertSent fails the test unless a matching message was successfully sent
+// to provider
+func (r *Recorder) AssertSent(t testing.TB, provider string, match Matcher) {
+	t.Helper()
+	if r.Count(provider, match) == 0 {
+		t.Errorf("notifytest: no matching message sent to %s; sent:\n%s", provider, r.describe(provider))
+	}
+}
+
+// AssertNotSent fails the test if a matching message was successfully sent
+// to provider
+func (r *Recorder) AssertNotSent(t testing.TB, provider string, match Matcher) {
+	t.Helper()
+	if r.Count(provider, match) > 0 {
+		t.Errorf("notifytest: unexpected matching message sent to %s; sent:\n%s", provider, r.describe(provider))
+	}
+}
+
+// AssertCount fails the test unless exactly n matching messages were
+// successfully sent to provider
+func (r *Recorder) AssertCount(t testing.TB, provider string, match Matcher, n int) {
+	t.Helper()
+	if got := r.Count(provider, match); got != n {
+		t.Errorf("notifytest: %d matching messages sent to %s, want %d; sent:\n%s", got, provider, n, r.describe(provider))
+	}
+}
+
+// WaitSent waits up to timeout for a matching message to be sent to provider,
+// for code that sends asynchronously, and fails the test if none arrives
+func (r *Recorder) WaitSent(t testing.TB, provider string, match Matcher, timeout time.Duration) {
+	t.Helper()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		r.mu.Lock()
+		changed := r.changed
+		r.mu.Unlock()
+
+		if r.Count(provider, match) > 0 {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			t.Errorf("notifytest: no matching message sent to %s within %v; sent:\n%s", provider, timeout, r.describe(provider))
+			return
+		}
+	}
+}
+
+// describe lists the deliveries to provider for failure messages
+func (r *Recorder) describe(provider string) string {
+	var b strings.Builder
+	for _, d := range r.Deliveries() {
+		if d.Provider != provider {
+			continue
+		}
+		fmt.Fprintf(&b, "  - title=%q text=%q priority=%q channel=%q", d.Message.Title, d.Message.Text, d.Message.Priority, d.Message.Channel)
+		if d.Err != nil {
+			fmt.Fprintf(&b, " (failed: %v)", d.Err)
+		}
+		b.WriteString("\n")
+	}
+	if b.Len() == 0 {
+		return "  (nothing)\n"
+	}
+	return b.String()
+}
@@ -0,0 +1,334 @@
+package notifytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/milano15662/notify"
+)
+
+// Harness is a notifier under conformance test together with hooks into the
+// backend it delivers to. Optional hooks left nil skip the checks that need them.
+type Harness struct {
+	// Notifier is the notifier under test (required)
+	Notifier notify.Notifier
+
+	// Delivered returns what the backend received so far, in order
+	Delivered func() []Delivered
+
+	// DefaultChannel is where messages without a Channel are delivered
+	DefaultChannel string
+
+	// OtherChannel is a channel other than the default that the backend accepts
+	OtherChannel string
+
+	// SetFailing makes the backend reject requests until called with false
+	SetFailing func(failing bool)
+
+	// Hold makes the backend stall requests until release is called
+	Hold func() (release func())
+}
+
+// Delivered is a message as received by a notifier's backend
+type Delivered struct {
+	// Channel is the channel or chat the message was delivered to
+	Channel string
+
+	// Text is the delivered text, which must contain the Message text
+	Text string
+}
+
+// Factory creates a fresh Harness for each conformance check
+type Factory func(t *testing.T) *Harness
+
+// RunConformance runs a standard battery of behavioural tests against a
+// Notifier implementation, checking that it behaves like the built-in
+// providers:
+//
+//   - it has a stable, non-empty name
+//   - it rejects empty text with a *notify.NotificationError, sending nothing
+//   - it delivers Send and SendWithOptions messages, to the default channel
+//     unless a Channel is set
+//   - it accepts every priority and doesn't modify the caller's Message
+//   - it honours context cancellation and deadlines
+//   - it reports backend failures as *notify.NotificationError
+//   - it is safe for concurrent use
+//
+// Run it with -race to catch data races.
+func RunConformance(t *testing.T, factory Factory) {
+	t.Helper()
+
+	checks := []struct {
+		name  string
+		check func(t *testing.T, h *Harness)
+	}{
+		{"Name", checkName},
+		{"EmptyText", checkEmptyText},
+		{"Send", checkSend},
+		{"SendWithOptions", checkSendWithOptions},
+		{"DefaultChannel", checkDefaultChannel},
+		{"ExplicitChannel", checkExplicitChannel},
+		{"Priorities", checkPriorities},
+		{"MessageUnchanged", checkMessageUnchanged},
+		{"CanceledContext", checkCanceledContext},
+		{"Deadline", checkDeadline},
+		{"BackendFailure", checkBackendFailure},
+		{"Concurrent", checkConcurrent},
+	}
+
+	for _, c := range checks {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			h := factory(t)
+			if h == nil || h.Notifier == nil {
+				t.Fatal("notifytest: factory returned no notifier")
+			}
+			c.check(t, h)
+		})
+	}
+}
+
+// conformanceTimeout bounds every send made by the checks
+const conformanceTimeout = 10 * time.Second
+
+func sendContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), conformanceTimeout)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+// delivered returns the backend deliveries, or skips the test without a hook
+func delivered(t *testing.T, h *Harness) []Delivered {
+	t.Helper()
+	if h.Delivered == nil {
+		t.Skip("Harness.Delivered not set")
+	}
+	return h.Delivered()
+}
+
+// requireNotificationError checks that err is a *notify.NotificationError
+func requireNotificationError(t *testing.T, err error) {
+	t.Helper()
+	var notificationErr *notify.NotificationError
+	if !errors.As(err, &notificationErr) {
+		t.Errorf("expected a *notify.NotificationError, got %T: %v", err, err)
+	} else if notificationErr.Provider == "" {
+		t.Errorf("expected NotificationError.Provider to be set: %v", err)
+	}
+}
+
+func checkName(t *testing.T, h *Harness) {
+	name := h.Notifier.Name()
+	if name == "" {
+		t.Fatal("Name returned an empty string")
+	}
+	if again := h.Notifier.Name(); again != name {
+		t.Errorf("Name is not stable: %q then %q", name, again)
+	}
+}
+
+func checkEmptyText(t *testing.T, h *Harness) {
+	for _, msg := range []*notify.Message{{}, {Title: "title only"}} {
+		err := h.Notifier.SendWithOptions(sendContext(t), msg)
+		if err == nil {
+			t.Fatalf("SendWithOptions(%+v) succeeded, want an error", msg)
+		}
+		requireNotificationError(t, err)
+	}
+
+	if err := h.Notifier.Send(sendContext(t), ""); err == nil {
+		t.Error(`Send("") succeeded, want an error`)
+	}
+
+	if h.Delivered != nil {
+		if got := h.Delivered(); len(got) != 0 {
+			t.Errorf("empty messages reached the backend: %+v", got)
+		}
+	}
+}
+
+func checkSend(t *testing.T, h *Harness) {
+	if err := h.Notifier.Send(sendContext(t), "conformance send"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	got := delivered(t, h)
+	if len(got) != 1 || !strings.Contains(got[0].Text, "conformance send") {
+		t.Errorf("expected one delivery containing the text, got %+v", got)
+	}
+}
+
+func checkSendWithOptions(t *testing.T, h *Harness) {
+	msg := &notify.Message{
+		Title:    "Conformance",
+		Text:     "conformance options",
+		Priority: notify.PriorityHigh,
+		Attachments: []notify.Attachment{{
+			Title:  "Details",
+			Text:   "attachment text",
+			Color:  "good",
+			Fields: []notify.Field{{Title: "Key", Value: "Value", Short: true}},
+		}},
+		Metadata: map[string]interface{}{"conformance": true},
+	}
+	if err := h.Notifier.SendWithOptions(sendContext(t), msg); err != nil {
+		t.Fatalf("SendWithOptions failed: %v", err)
+	}
+
+	got := delivered(t, h)
+	if len(got) != 1 || !strings.Contains(got[0].Text, "conformance options") {
+		t.Errorf("expected one delivery containing the text, got %+v", got)
+	}
+}
+
+func checkDefaultChannel(t *testing.T, h *Harness) {
+	if h.DefaultChannel == "" {
+		t.Skip("Harness.DefaultChannel not set")
+	}
+
+	h.Notifier.Send(sendContext(t), "to the default channel")
+	h.Notifier.SendWithOptions(sendContext(t), &notify.Message{Text: "also to the default channel"})
+
+	got := delivered(t, h)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 deliveries, got %+v", got)
+	}
+	for _, d := range got {
+		if d.Channel != h.DefaultChannel {
+			t.Errorf("expected delivery to %q, got %q", h.DefaultChannel, d.Channel)
+		}
+	}
+}
+
+func checkExplicitChannel(t *testing.T, h *Harness) {
+	if h.OtherChannel == "" {
+		t.Skip("Harness.OtherChannel not set")
+	}
+
+	err := h.Notifier.SendWithOptions(sendContext(t), &notify.Message{Text: "elsewhere", Channel: h.OtherChannel})
+	if err != nil {
+		t.Fatalf("SendWithOptions failed: %v", err)
+	}
+
+	got := delivered(t, h)
+	if len(got) != 1 || got[0].Channel != h.OtherChannel {
+		t.Errorf("expected one delivery to %q, got %+v", h.OtherChannel, got)
+	}
+}
+
+func checkPriorities(t *testing.T, h *Harness) {
+	for _, priority := range []string{notify.PriorityHigh, notify.PriorityNormal, notify.PriorityLow, ""} {
+		err := h.Notifier.SendWithOptions(sendContext(t), &notify.Message{Text: "priority " + priority, Priority: priority})
+		if err != nil {
+			t.Errorf("SendWithOptions with priority %q failed: %v", priority, err)
+		}
+	}
+}
+
+func checkMessageUnchanged(t *testing.T, h *Harness) {
+	msg := &notify.Message{
+		Title:       "Unchanged",
+		Text:        "do not modify me",
+		Attachments: []notify.Attachment{{Title: "A", Fields: []notify.Field{{Title: "K", Value: "V"}}}},
+		Metadata:    map[string]interface{}{"key": "value"},
+	}
+	before := msg.Clone()
+
+	if err := h.Notifier.SendWithOptions(sendContext(t), msg); err != nil {
+		t.Fatalf("SendWithOptions failed: %v", err)
+	}
+	if !reflect.DeepEqual(msg, before) {
+		t.Errorf("SendWithOptions modified the message:\nbefore %+v\nafter  %+v", before, msg)
+	}
+}
+
+func checkCanceledContext(t *testing.T, h *Harness) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := h.Notifier.SendWithOptions(ctx, &notify.Message{Text: "canceled"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected an error wrapping context.Canceled, got %v", err)
+	}
+
+	if h.Delivered != nil {
+		if got := h.Delivered(); len(got) != 0 {
+			t.Errorf("a canceled send reached the backend: %+v", got)
+		}
+	}
+}
+
+func checkDeadline(t *testing.T, h *Harness) {
+	if h.Hold == nil {
+		t.Skip("Harness.Hold not set")
+	}
+
+	release := h.Hold()
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- h.Notifier.SendWithOptions(ctx, &notify.Message{Text: "stalled"})
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected an error wrapping context.DeadlineExceeded, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("send did not return after its context deadline")
+	}
+}
+
+func checkBackendFailure(t *testing.T, h *Harness) {
+	if h.SetFailing == nil {
+		t.Skip("Harness.SetFailing not set")
+	}
+
+	h.SetFailing(true)
+	err := h.Notifier.Send(sendContext(t), "failing backend")
+	h.SetFailing(false)
+
+	if err == nil {
+		t.Fatal("Send succeeded against a failing backend")
+	}
+	requireNotificationError(t, err)
+}
+
+func checkConcurrent(t *testing.T, h *Harness) {
+	const senders = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, senders)
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := h.Notifier.SendWithOptions(sendContext(t), &notify.Message{Text: fmt.Sprintf("concurrent %d", i)}); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("concurrent send failed: %v", err)
+	}
+
+	if h.Delivered != nil {
+		if got := h.Delivered(); len(got) != senders {
+			t.Errorf("expected %d deliveries, got %d", senders, len(got))
+		}
+	}
+}
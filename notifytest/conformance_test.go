@@ -0,0 +1,98 @@
+package notifytest_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/milano15662/notify"
+	"github.com/milano15662/notify/notifytest"
+)
+
+// inboxNotifier is a minimal well-behaved notifier delivering to an in-memory inbox
+type inboxNotifier struct {
+	mu        sync.Mutex
+	delivered []notifytest.Delivered
+	failing   bool
+	held      chan struct{}
+}
+
+func (n *inboxNotifier) Name() string { return "inbox" }
+
+func (n *inboxNotifier) Send(ctx context.Context, message string) error {
+	return n.SendWithOptions(ctx, &notify.Message{Text: message})
+}
+
+func (n *inboxNotifier) SendWithOptions(ctx context.Context, msg *notify.Message) error {
+	if msg.Text == "" {
+		return &notify.NotificationError{Provider: "inbox", Message: "message text is required"}
+	}
+
+	n.mu.Lock()
+	held, failing := n.held, n.failing
+	n.mu.Unlock()
+
+	if held != nil {
+		select {
+		case <-held:
+		case <-ctx.Done():
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return &notify.NotificationError{Provider: "inbox", Message: "send canceled", Err: err}
+	}
+	if failing {
+		return &notify.NotificationError{Provider: "inbox", Message: "inbox unavailable"}
+	}
+
+	channel := msg.Channel
+	if channel == "" {
+		channel = "inbox"
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.delivered = append(n.delivered, notifytest.Delivered{Channel: channel, Text: msg.Text})
+	return nil
+}
+
+func TestRunConformance(t *testing.T) {
+	notifytest.RunConformance(t, func(t *testing.T) *notifytest.Harness {
+		n := &inboxNotifier{}
+		return &notifytest.Harness{
+			Notifier: n,
+			Delivered: func() []notifytest.Delivered {
+				n.mu.Lock()
+				defer n.mu.Unlock()
+				return append([]notifytest.Delivered(nil), n.delivered...)
+			},
+			DefaultChannel: "inbox",
+			OtherChannel:   "elsewhere",
+			SetFailing: func(failing bool) {
+				n.mu.Lock()
+				defer n.mu.Unlock()
+				n.failing = failing
+			},
+			Hold: func() func() {
+				held := make(chan struct{})
+				n.mu.Lock()
+				n.held = held
+				n.mu.Unlock()
+				return func() {
+					n.mu.Lock()
+					defer n.mu.Unlock()
+					if n.held == held {
+						n.held = nil
+						close(held)
+					}
+				}
+			},
+		}
+	})
+}
+
+func TestRunConformanceMinimalHarness(t *testing.T) {
+	notifytest.RunConformance(t, func(t *testing.T) *notifytest.Harness {
+		return &notifytest.Harness{Notifier: &inboxNotifier{}}
+	})
+}
@@ -0,0 +1,228 @@
+// Package notifytest provides fake notifiers and assertions for testing code
+// that sends notifications.
+//
+//	rec := notifytest.NewRecorder()
+//	manager := notify.NewManager()
+//	manager.Register(rec.Notifier("slack"))
+//	manager.Register(rec.Notifier("telegram", notifytest.Fail(errors.New("rate limited"), nil)))
+//
+//	deploy(ctx, manager)
+//
+//	rec.AssertSent(t, "slack", notifytest.TextContains("deployed"))
+package notifytest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/milano15662/notify"
+)
+
+// ErrScripted is the error returned by FailAlways when given a nil error
+var ErrScripted = errors.New("notifytest: scripted failure")
+
+// Delivery is a send recorded by a Recorder
+type Delivery struct {
+	// Provider is the name of the notifier
+	Provider string
+
+	// Message is a copy of the message sent (plain text sends are recorded
+	// as a Message with only Text set)
+	Message *notify.Message
+
+	// Err is the error returned to the caller, nil on success
+	Err error
+
+	// Time is when the send started
+	Time time.Time
+}
+
+// UploadedFile is a file uploaded to a fake API server
+type UploadedFile struct {
+	Filename string
+	Data     []byte
+}
+
+// Recorder records the sends of its notifiers. It is safe for concurrent use.
+type Recorder struct {
+	mu         sync.Mutex
+	deliveries []Delivery
+	changed    chan struct{}
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{changed: make(chan struct{})}
+}
+
+// Notifier creates a fake notifier that records into the recorder
+func (r *Recorder) Notifier(name string, opts ...Option) *Notifier {
+	n := &Notifier{name: name, recorder: r}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
+
+// Deliveries returns all recorded sends, successful or not, in order
+func (r *Recorder) Deliveries() []Delivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Delivery(nil), r.deliveries...)
+}
+
+// Messages returns the messages successfully sent to a provider, in order
+func (r *Recorder) Messages(provider string) []*notify.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var msgs []*notify.Message
+	for _, d := range r.deliveries {
+		if d.Provider == provider && d.Err == nil {
+			msgs = append(msgs, d.Message)
+		}
+	}
+	return msgs
+}
+
+// Last returns the last message successfully sent to a provider
+func (r *Recorder) Last(provider string) (*notify.Message, bool) {
+	msgs := r.Messages(provider)
+	if len(msgs) == 0 {
+		return nil, false
+	}
+	return msgs[len(msgs)-1], true
+}
+
+// Reset discards all recorded sends
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deliveries = nil
+}
+
+// record appends a delivery and wakes up waiters
+func (r *Recorder) record(d Delivery) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deliveries = append(r.deliveries, d)
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// Option configures a fake Notifier
+type Option func(*Notifier)
+
+// Fail scripts the results of successive sends: the first send returns
+// errs[0], the second errs[1] and so on, where nil means success. Sends
+// after the script is exhausted succeed.
+func Fail(errs ...error) Option {
+	return func(n *Notifier) {
+		n.script = append([]error(nil), errs...)
+	}
+}
+
+// FailAlways makes every send return err (ErrScripted when err is nil)
+func FailAlways(err error) Option {
+	if err == nil {
+		err = ErrScripted
+	}
+	return func(n *Notifier) {
+		n.always = err
+	}
+}
+
+// Latency delays every send by d, or until the send's context is done
+func Latency(d time.Duration) Option {
+	return func(n *Notifier) {
+		n.latency = d
+	}
+}
+
+// Notifier is a fake notify.Notifier that records its sends
+type Notifier struct {
+	name     string
+	recorder *Recorder
+	latency  time.Duration
+
+	mu     sync.Mutex
+	script []error
+	always error
+	calls  int
+}
+
+// New creates a fake notifier with its own recorder
+func New(name string, opts ...Option) *Notifier {
+	return NewRecorder().Notifier(name, opts...)
+}
+
+// Recorder returns the recorder the notifier records into
+func (n *Notifier) Recorder() *Recorder {
+	return n.recorder
+}
+
+// Calls returns the number of sends, including failed ones
+func (n *Notifier) Calls() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.calls
+}
+
+// Name returns the notifier name
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Send records a simple text message
+func (n *Notifier) Send(ctx context.Context, message string) error {
+	return n.send(ctx, &notify.Message{Text: message})
+}
+
+// SendWithOptions records a message with options. A nil message is counted
+// as a call and rejected like the real providers do, without being recorded.
+func (n *Notifier) SendWithOptions(ctx context.Context, msg *notify.Message) error {
+	if msg == nil {
+		n.mu.Lock()
+		n.calls++
+		n.mu.Unlock()
+
+		return &notify.NotificationError{
+			Provider: n.name,
+			Message:  "message text is required",
+		}
+	}
+	return n.send(ctx, msg.Clone())
+}
+
+// send waits for the configured latency, then records msg with its scripted result
+func (n *Notifier) send(ctx context.Context, msg *notify.Message) error {
+	start := time.Now()
+
+	n.mu.Lock()
+	n.calls++
+	err := n.always
+	if err == nil && len(n.script) > 0 {
+		err = n.script[0]
+		n.script = n.script[1:]
+	}
+	n.mu.Unlock()
+
+	if n.latency > 0 {
+		timer := time.NewTimer(n.latency)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			err = ctx.Err()
+		}
+	}
+
+	n.recorder.record(Delivery{Provider: n.name, Message: msg, Err: err, Time: start})
+	return err
+}
@@ -0,0 +1,177 @@
+package notifytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/milano15662/notify"
+)
+
+// fakeT captures assertion failures
+type fakeT struct {
+	testing.TB
+	failures []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+func TestRecorderWithManager(t *testing.T) {
+	rec := NewRecorder()
+	manager := notify.NewManager()
+	manager.Register(rec.Notifier("slack"))
+	manager.Register(rec.Notifier("telegram"))
+
+	manager.SendWithOptions(context.Background(), "slack", &notify.Message{
+		Title:       "Deploy",
+		Text:        "v1.2.3 deployed",
+		Priority:    notify.PriorityHigh,
+		Attachments: []notify.Attachment{{Fields: []notify.Field{{Title: "Env", Value: "prod"}}}},
+	})
+	manager.Send(context.Background(), "telegram", "plain")
+
+	rec.AssertSent(t, "slack", All(Title("Deploy"), TextContains("deployed"), Priority(notify.PriorityHigh), HasField("Env", "prod")))
+	rec.AssertSent(t, "telegram", Text("plain"))
+	rec.AssertNotSent(t, "telegram", TextContains("deployed"))
+	rec.AssertCount(t, "slack", Any(), 1)
+
+	if len(rec.Deliveries()) != 2 {
+		t.Errorf("Expected 2 deliveries, got %d", len(rec.Deliveries()))
+	}
+
+	rec.Reset()
+	if _, ok := rec.Last("slack"); ok {
+		t.Error("Expected no messages after Reset")
+	}
+}
+
+func TestAssertionFailures(t *testing.T) {
+	n := New("slack")
+	n.Send(context.Background(), "hello")
+
+	ft := &fakeT{}
+	n.Recorder().AssertSent(ft, "slack", Text("goodbye"))
+	n.Recorder().AssertNotSent(ft, "slack", Text("hello"))
+	n.Recorder().AssertCount(ft, "slack", Any(), 2)
+
+	if len(ft.failures) != 3 {
+		t.Fatalf("Expected 3 failures, got %d: %v", len(ft.failures), ft.failures)
+	}
+	if !strings.Contains(ft.failures[0], `text="hello"`) {
+		t.Errorf("Expected the failure to list sent messages, got %q", ft.failures[0])
+	}
+}
+
+func TestScriptedFailures(t *testing.T) {
+	rateLimited := errors.New("rate limited")
+	n := New("telegram", Fail(rateLimited, nil, rateLimited))
+
+	var got []error
+	for i := 0; i < 4; i++ {
+		got = append(got, n.Send(context.Background(), "hi"))
+	}
+
+	want := []error{rateLimited, nil, rateLimited, nil}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Send %d: expected %v, got %v", i+1, want[i], got[i])
+		}
+	}
+
+	if n.Calls() != 4 {
+		t.Errorf("Expected 4 calls, got %d", n.Calls())
+	}
+	if len(n.Recorder().Messages("telegram")) != 2 {
+		t.Errorf("Expected only successful sends in Messages")
+	}
+}
+
+func TestScriptedFailuresWithRetry(t *testing.T) {
+	n := New("flaky", Fail(errors.New("1"), errors.New("2")))
+
+	manager := notify.NewManager()
+	manager.Register(n)
+	manager.SetRetryPolicy(notify.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	if err := manager.Send(context.Background(), "flaky", "hi"); err != nil {
+		t.Fatalf("Expected success on the third attempt, got %v", err)
+	}
+	if n.Calls() != 3 {
+		t.Errorf("Expected 3 calls, got %d", n.Calls())
+	}
+}
+
+func TestFailAlways(t *testing.T) {
+	n := New("down", FailAlways(nil))
+	if err := n.Send(context.Background(), "hi"); !errors.Is(err, ErrScripted) {
+		t.Errorf("Expected ErrScripted, got %v", err)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	n := New("slow", Latency(time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := n.Send(ctx, "hi"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+
+	fast := New("fast", Latency(5*time.Millisecond))
+	start := time.Now()
+	fast.Send(context.Background(), "hi")
+	if time.Since(start) < 5*time.Millisecond {
+		t.Error("Expected the send to be delayed")
+	}
+}
+
+func TestConcurrentSendsAndWait(t *testing.T) {
+	rec := NewRecorder()
+	n := rec.Notifier("async")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			n.SendWithOptions(context.Background(), &notify.Message{Text: fmt.Sprintf("message %d", i)})
+		}(i)
+	}
+
+	rec.WaitSent(t, "async", Text("message 49"), time.Second)
+	wg.Wait()
+	rec.AssertCount(t, "async", TextContains("message"), 50)
+}
+
+func TestRecordsCopies(t *testing.T) {
+	n := New("copy")
+	msg := &notify.Message{Text: "original"}
+	n.SendWithOptions(context.Background(), msg)
+	msg.Text = "changed"
+
+	if last, _ := n.Recorder().Last("copy"); last.Text != "original" {
+		t.Errorf("Expected the recorded message to be a copy, got %q", last.Text)
+	}
+}
+
+func TestNilMessage(t *testing.T) {
+	n := New("nil")
+
+	var notifErr *notify.NotificationError
+	if err := n.SendWithOptions(context.Background(), nil); !errors.As(err, &notifErr) || notifErr.Provider != "nil" {
+		t.Errorf("Expected a NotificationError, got %v", err)
+	}
+
+	if n.Calls() != 1 || len(n.Recorder().Deliveries()) != 0 {
+		t.Errorf("Expected the call to be counted but not recorded, got %d calls and %d deliveries", n.Calls(), len(n.Recorder().Deliveries()))
+	}
+}
@@ -0,0 +1,644 @@
+package notifytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Slack Web API limits enforced by SlackServer
+const (
+	SlackMaxTextLength    = 40000
+	SlackMaxBlocks        = 50
+	SlackMaxHeaderLength  = 150
+	SlackMaxSectionLength = 3000
+	SlackMaxActions       = 25
+	SlackMaxButtonLabel   = 75
+	SlackMaxButtonValue   = 2000
+	SlackMaxButtonURL     = 3000
+)
+
+// SlackCall is a request received by a SlackServer
+type SlackCall struct {
+	// Method is the Web API method (e.g., chat.postMessage), "upload" for
+	// data posted to an upload URL or "webhook" for incoming webhooks
+	Method string
+
+	// Token is the token from the form or the Authorization header
+	Token string
+
+	// Params holds the request parameters. Non-string JSON values are kept
+	// as JSON text.
+	Params map[string]string
+
+	// Files holds multipart file uploads by field name
+	Files map[string]UploadedFile
+
+	// Status is the HTTP status of the response
+	Status int
+
+	// Error is the Slack error code of a failed call (e.g., channel_not_found)
+	Error string
+}
+
+// Param returns a parameter
+func (c SlackCall) Param(name string) string {
+	return c.Params[name]
+}
+
+// Decode decodes a JSON parameter such as blocks or attachments into v
+func (c SlackCall) Decode(name string, v interface{}) error {
+	return json.Unmarshal([]byte(c.Params[name]), v)
+}
+
+// SlackUpload is a file shared through a SlackServer
+type SlackUpload struct {
+	ID       string
+	Filename string
+	Title    string
+	Channels []string
+	Data     []byte
+
+	// Comment and ThreadTS are the text and thread of the message sharing
+	// the file
+	Comment  string
+	ThreadTS string
+}
+
+// SlackServer is a fake Slack Web API and incoming webhook endpoint for
+// hermetic tests. It emulates auth.test, chat.postMessage, chat.update,
+// files.upload and the files.getUploadURLExternal/files.completeUploadExternal
+// upload flow, returns realistic errors and can simulate rate limiting and
+// outages.
+//
+//	srv := notifytest.NewSlackServer()
+//	defer srv.Close()
+//
+//	slack, _ := notify.NewSlackNotifier(notify.SlackConfig{
+//		Token: "xoxb-test", DefaultChannel: "#alerts", APIURL: srv.APIURL(),
+//	})
+type SlackServer struct {
+	*httptest.Server
+
+	// Token, when set, is the only token accepted
+	Token string
+
+	// Channels, when set, lists the only channel names or IDs accepted
+	Channels []string
+
+	mu         sync.Mutex
+	calls      []SlackCall
+	uploads    []SlackUpload
+	pending    map[string]*SlackUpload
+	rateLimit  int
+	retryAfter int
+	outage     int
+	down       bool
+	held       chan struct{}
+	sequence   int
+}
+
+// NewSlackServer starts a fake Slack server. Close it when done.
+func NewSlackServer() *SlackServer {
+	s := &SlackServer{pending: make(map[string]*SlackUpload)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// APIURL returns the Web API base URL for SlackConfig.APIURL
+func (s *SlackServer) APIURL() string {
+	return s.URL + "/api/"
+}
+
+// WebhookURL returns an incoming webhook URL for SlackConfig.WebhookURL
+func (s *SlackServer) WebhookURL() string {
+	return s.URL + "/services/T00000000/B00000000/XXXXXXXXXXXXXXXXXXXXXXXX"
+}
+
+// Calls returns all recorded calls in order
+func (s *SlackServer) Calls() []SlackCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]SlackCall(nil), s.calls...)
+}
+
+// CallsTo returns the successful calls of a method
+func (s *SlackServer) CallsTo(method string) []SlackCall {
+	var calls []SlackCall
+	for _, call := range s.Calls() {
+		if call.Method == method && call.Error == "" {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Uploads returns the files shared with files.upload or the external upload flow
+func (s *SlackServer) Uploads() []SlackUpload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]SlackUpload(nil), s.uploads...)
+}
+
+// Reset discards recorded calls, uploads and pending failures
+func (s *SlackServer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls, s.uploads = nil, nil
+	s.pending = make(map[string]*SlackUpload)
+	s.rateLimit, s.outage, s.down = 0, 0, false
+}
+
+// RateLimit answers the next n requests with 429 and a Retry-After header
+func (s *SlackServer) RateLimit(n int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rateLimit = n
+	s.retryAfter = int(retryAfter.Round(time.Second) / time.Second)
+}
+
+// Outage answers the next n requests with 503 Service Unavailable
+func (s *SlackServer) Outage(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.outage = n
+}
+
+// SetDown makes the server answer every request with 503 Service
+// Unavailable until called with false
+func (s *SlackServer) SetDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.down = down
+}
+
+// Hold stalls incoming requests until release is called or the client gives
+// up, for testing timeouts and cancellation
+func (s *SlackServer) Hold() (release func()) {
+	held := make(chan struct{})
+
+	s.mu.Lock()
+	s.held = held
+	s.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			if s.held == held {
+				s.held = nil
+			}
+			s.mu.Unlock()
+			close(held)
+		})
+	}
+}
+
+// wait blocks while requests are held, reporting whether the client is still there
+func (s *SlackServer) wait(r *http.Request) bool {
+	s.mu.Lock()
+	held := s.held
+	s.mu.Unlock()
+
+	if held == nil {
+		return true
+	}
+	select {
+	case <-held:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// slackFailure is a failed response
+type slackFailure struct {
+	status int
+	code   string
+}
+
+func (s *SlackServer) handle(w http.ResponseWriter, r *http.Request) {
+	if !s.wait(r) {
+		return
+	}
+
+	var method string
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/api/"):
+		method = strings.TrimPrefix(r.URL.Path, "/api/")
+	case strings.HasPrefix(r.URL.Path, "/upload/"):
+		method = "upload"
+	case strings.HasPrefix(r.URL.Path, "/services/"):
+		method = "webhook"
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	call := SlackCall{Method: method, Params: map[string]string{}, Files: map[string]UploadedFile{}}
+	failure := parseSlackParams(r, &call)
+
+	s.mu.Lock()
+	var result map[string]interface{}
+	switch {
+	case failure != nil:
+	case s.down || s.outage > 0:
+		if s.outage > 0 {
+			s.outage--
+		}
+		failure = &slackFailure{status: http.StatusServiceUnavailable}
+	case s.rateLimit > 0:
+		s.rateLimit--
+		failure = &slackFailure{status: http.StatusTooManyRequests, code: "ratelimited"}
+	case method == "upload":
+		failure = s.upload(strings.TrimPrefix(r.URL.Path, "/upload/"), &call)
+	case method == "webhook":
+		failure = s.webhook(&call)
+	default:
+		failure = s.authorize(&call)
+		if failure == nil {
+			result, failure = s.call(&call)
+		}
+	}
+
+	call.Status = http.StatusOK
+	if failure != nil {
+		if failure.status != 0 {
+			call.Status = failure.status
+		}
+		call.Error = failure.code
+		if call.Error == "" {
+			call.Error = http.StatusText(call.Status)
+		}
+	}
+	s.calls = append(s.calls, call)
+	retryAfter := s.retryAfter
+	s.mu.Unlock()
+
+	s.respond(w, method, failure, result, retryAfter)
+}
+
+// parseSlackParams reads query, form, multipart and JSON parameters
+func parseSlackParams(r *http.Request, call *SlackCall) *slackFailure {
+	call.Token = r.FormValue("token")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		call.Token = strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {
+			return &slackFailure{status: http.StatusBadRequest, code: "invalid_payload"}
+		}
+		for key, value := range body {
+			if text, ok := value.(string); ok {
+				call.Params[key] = text
+				continue
+			}
+			data, _ := json.Marshal(value)
+			call.Params[key] = string(data)
+		}
+	}
+
+	for key, values := range r.Form {
+		if key != "token" {
+			call.Params[key] = values[0]
+		}
+	}
+
+	if r.MultipartForm != nil {
+		for field, headers := range r.MultipartForm.File {
+			file, err := headers[0].Open()
+			if err != nil {
+				return &slackFailure{code: "invalid_form_data"}
+			}
+			data, err := io.ReadAll(file)
+			file.Close()
+			if err != nil {
+				return &slackFailure{code: "invalid_form_data"}
+			}
+			call.Files[field] = UploadedFile{Filename: headers[0].Filename, Data: data}
+		}
+	}
+	return nil
+}
+
+// authorize checks the token of a Web API call
+func (s *SlackServer) authorize(call *SlackCall) *slackFailure {
+	switch {
+	case call.Token == "":
+		return &slackFailure{code: "not_authed"}
+	case s.Token != "" && call.Token != s.Token:
+		return &slackFailure{code: "invalid_auth"}
+	}
+	return nil
+}
+
+// call validates and answers a Web API call
+func (s *SlackServer) call(call *SlackCall) (map[string]interface{}, *slackFailure) {
+	switch call.Method {
+	case "auth.test":
+		return map[string]interface{}{
+			"url": s.URL + "/", "team": "notifytest", "user": "notifytest_bot",
+			"team_id": "T00000000", "user_id": "U00000000", "bot_id": "B00000000",
+		}, nil
+	case "chat.postMessage":
+		if failure := s.validateMessage(call); failure != nil {
+			return nil, failure
+		}
+		return map[string]interface{}{
+			"channel": call.Param("channel"),
+			"ts":      s.timestamp(),
+			"message": map[string]interface{}{"text": call.Param("text")},
+		}, nil
+	case "chat.update":
+		if call.Param("ts") == "" {
+			return nil, &slackFailure{code: "message_not_found"}
+		}
+		if failure := s.validateMessage(call); failure != nil {
+			return nil, failure
+		}
+		return map[string]interface{}{
+			"channel": call.Param("channel"),
+			"ts":      call.Param("ts"),
+			"text":    call.Param("text"),
+		}, nil
+	case "files.upload":
+		channels := splitChannels(call.Param("channels"))
+		for _, channel := range channels {
+			if !s.knownChannel(channel) {
+				return nil, &slackFailure{code: "channel_not_found"}
+			}
+		}
+		file, uploaded := call.Files["file"]
+		if !uploaded && call.Param("content") == "" {
+			return nil, &slackFailure{code: "no_file_data"}
+		}
+		if !uploaded {
+			file = UploadedFile{Filename: call.Param("filename"), Data: []byte(call.Param("content"))}
+		}
+		upload := s.share(file, call, channels)
+		return map[string]interface{}{
+			"file": map[string]interface{}{"id": upload.ID, "name": upload.Filename, "title": upload.Title},
+		}, nil
+	case "files.getUploadURLExternal":
+		length, err := strconv.Atoi(call.Param("length"))
+		if call.Param("filename") == "" || err != nil || length <= 0 {
+			return nil, &slackFailure{code: "invalid_arguments"}
+		}
+		s.sequence++
+		id := fmt.Sprintf("F%08d", s.sequence)
+		s.pending[id] = &SlackUpload{ID: id, Filename: call.Param("filename")}
+		return map[string]interface{}{"upload_url": s.URL + "/upload/" + id, "file_id": id}, nil
+	case "files.completeUploadExternal":
+		return s.completeUpload(call)
+	}
+
+	return nil, &slackFailure{code: "unknown_method"}
+}
+
+// validateMessage checks a chat.postMessage call
+func (s *SlackServer) validateMessage(call *SlackCall) *slackFailure {
+	channel := call.Param("channel")
+	if channel == "" || !s.knownChannel(channel) {
+		return &slackFailure{code: "channel_not_found"}
+	}
+
+	text, blocks, attachments := call.Param("text"), call.Param("blocks"), call.Param("attachments")
+	if text == "" && blocks == "" && attachments == "" {
+		return &slackFailure{code: "no_text"}
+	}
+	if utf8.RuneCountInString(text) > SlackMaxTextLength {
+		return &slackFailure{code: "msg_too_long"}
+	}
+
+	if blocks != "" {
+		var parsed []struct {
+			Type string `json:"type"`
+			Text *struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"text"`
+			Elements []struct {
+				Type string `json:"type"`
+				Text struct {
+					Type string `json:"type"`
+					Text string `json:"text"`
+				} `json:"text"`
+				URL   string `json:"url"`
+				Value string `json:"value"`
+			} `json:"elements"`
+		}
+		if err := json.Unmarshal([]byte(blocks), &parsed); err != nil || len(parsed) > SlackMaxBlocks {
+			return &slackFailure{code: "invalid_blocks"}
+		}
+		for _, block := range parsed {
+			if block.Type == "" {
+				return &slackFailure{code: "invalid_blocks"}
+			}
+			if block.Type == "actions" && (len(block.Elements) == 0 || len(block.Elements) > SlackMaxActions) {
+				return &slackFailure{code: "invalid_blocks"}
+			}
+			for _, element := range block.Elements {
+				if element.Type == "button" && (element.Text.Type != "plain_text" || element.Text.Text == "" ||
+					utf8.RuneCountInString(element.Text.Text) > SlackMaxButtonLabel ||
+					len(element.Value) > SlackMaxButtonValue || len(element.URL) > SlackMaxButtonURL) {
+					return &slackFailure{code: "invalid_blocks"}
+				}
+			}
+			if block.Text == nil {
+				continue
+			}
+			length := utf8.RuneCountInString(block.Text.Text)
+			if block.Type == "header" && (block.Text.Type != "plain_text" || length > SlackMaxHeaderLength) {
+				return &slackFailure{code: "invalid_blocks"}
+			}
+			if block.Type == "section" && length > SlackMaxSectionLength {
+				return &slackFailure{code: "invalid_blocks"}
+			}
+		}
+	}
+
+	if attachments != "" {
+		var parsed []map[string]interface{}
+		if err := json.Unmarshal([]byte(attachments), &parsed); err != nil {
+			return &slackFailure{code: "invalid_attachments"}
+		}
+	}
+	return nil
+}
+
+// upload stores data posted to an upload URL
+func (s *SlackServer) upload(id string, call *SlackCall) *slackFailure {
+	pending, exists := s.pending[id]
+	if !exists {
+		return &slackFailure{status: http.StatusNotFound}
+	}
+
+	if file, uploaded := call.Files["file"]; uploaded {
+		pending.Data = file.Data
+	} else {
+		pending.Data = []byte(call.Param("content"))
+	}
+	return nil
+}
+
+// completeUpload shares files uploaded to upload URLs
+func (s *SlackServer) completeUpload(call *SlackCall) (map[string]interface{}, *slackFailure) {
+	var files []struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+	}
+	if err := call.Decode("files", &files); err != nil || len(files) == 0 {
+		return nil, &slackFailure{code: "invalid_arguments"}
+	}
+
+	// like Slack, only accept channel IDs
+	channel := call.Param("channel_id")
+	if channel != "" && (!channelID(channel) || !s.knownChannel(channel)) {
+		return nil, &slackFailure{code: "channel_not_found"}
+	}
+
+	var summaries []map[string]interface{}
+	for _, file := range files {
+		pending, exists := s.pending[file.ID]
+		if !exists || pending.Data == nil {
+			return nil, &slackFailure{code: "file_not_found"}
+		}
+		delete(s.pending, file.ID)
+
+		pending.Title = file.Title
+		pending.Comment = call.Param("initial_comment")
+		pending.ThreadTS = call.Param("thread_ts")
+		if channel != "" {
+			pending.Channels = []string{channel}
+		}
+		s.uploads = append(s.uploads, *pending)
+		summaries = append(summaries, map[string]interface{}{"id": file.ID, "title": file.Title})
+	}
+	return map[string]interface{}{"files": summaries}, nil
+}
+
+// share records a file uploaded with files.upload
+func (s *SlackServer) share(file UploadedFile, call *SlackCall, channels []string) SlackUpload {
+	s.sequence++
+	upload := SlackUpload{
+		ID:       fmt.Sprintf("F%08d", s.sequence),
+		Filename: file.Filename,
+		Title:    call.Param("title"),
+		Channels: channels,
+		Data:     file.Data,
+		Comment:  call.Param("initial_comment"),
+		ThreadTS: call.Param("thread_ts"),
+	}
+	s.uploads = append(s.uploads, upload)
+	return upload
+}
+
+// webhook validates an incoming webhook payload
+func (s *SlackServer) webhook(call *SlackCall) *slackFailure {
+	if call.Param("text") == "" && call.Param("blocks") == "" && call.Param("attachments") == "" {
+		return &slackFailure{status: http.StatusBadRequest, code: "no_text"}
+	}
+	if channel := call.Param("channel"); channel != "" && !s.knownChannel(channel) {
+		return &slackFailure{status: http.StatusNotFound, code: "channel_not_found"}
+	}
+	return nil
+}
+
+// knownChannel reports whether channel is accepted
+func (s *SlackServer) knownChannel(channel string) bool {
+	if len(s.Channels) == 0 {
+		return true
+	}
+	channel = strings.TrimPrefix(channel, "#")
+	for _, known := range s.Channels {
+		if strings.TrimPrefix(known, "#") == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// channelID reports whether channel looks like a conversation ID (C…, G…, D…)
+func channelID(channel string) bool {
+	if len(channel) < 2 || !strings.ContainsRune("CGD", rune(channel[0])) {
+		return false
+	}
+	for _, r := range channel[1:] {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// timestamp returns a unique message timestamp
+func (s *SlackServer) timestamp() string {
+	s.sequence++
+	return fmt.Sprintf("%d.%06d", time.Now().Unix(), s.sequence)
+}
+
+// respond writes a Web API, upload or webhook response
+func (s *SlackServer) respond(w http.ResponseWriter, method string, failure *slackFailure, result map[string]interface{}, retryAfter int) {
+	status := http.StatusOK
+	if failure != nil && failure.status != 0 {
+		status = failure.status
+	}
+	if status == http.StatusTooManyRequests {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+
+	// Webhooks and upload URLs answer with plain text, as does the Web API
+	// when it is unavailable
+	if method == "webhook" || method == "upload" || status >= http.StatusInternalServerError {
+		text := "ok"
+		if failure != nil {
+			if text = failure.code; text == "" {
+				text = http.StatusText(status)
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		io.WriteString(w, text)
+		return
+	}
+
+	body := map[string]interface{}{"ok": failure == nil}
+	for key, value := range result {
+		body[key] = value
+	}
+	if failure != nil {
+		body["error"] = failure.code
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// splitChannels splits a comma-separated channel list
+func splitChannels(value string) []string {
+	var channels []string
+	for _, channel := range strings.Split(value, ",") {
+		if channel = strings.TrimSpace(channel); channel != "" {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
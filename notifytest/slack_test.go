@@ -0,0 +1,140 @@
+package notifytest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestSlackServerAuth(t *testing.T) {
+	srv := NewSlackServer()
+	srv.Token = "xoxb-good"
+	defer srv.Close()
+
+	if _, err := slack.New("xoxb-bad", slack.OptionAPIURL(srv.APIURL())).AuthTest(); err == nil || err.Error() != "invalid_auth" {
+		t.Errorf("Expected invalid_auth, got %v", err)
+	}
+
+	resp, err := http.PostForm(srv.APIURL()+"auth.test", url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if calls := srv.Calls(); calls[len(calls)-1].Error != "not_authed" {
+		t.Errorf("Expected not_authed, got %+v", calls[len(calls)-1])
+	}
+
+	info, err := slack.New("xoxb-good", slack.OptionAPIURL(srv.APIURL())).AuthTest()
+	if err != nil || info.UserID == "" {
+		t.Errorf("Expected auth.test to succeed, got %+v %v", info, err)
+	}
+}
+
+func TestSlackServerMessageValidation(t *testing.T) {
+	srv := NewSlackServer()
+	defer srv.Close()
+	client := slack.New("xoxb-test", slack.OptionAPIURL(srv.APIURL()))
+
+	tests := []struct {
+		name    string
+		channel string
+		options []slack.MsgOption
+		code    string
+	}{
+		{"no text", "#alerts", nil, "no_text"},
+		{"too long", "#alerts", []slack.MsgOption{slack.MsgOptionText(strings.Repeat("a", SlackMaxTextLength+1), false)}, "msg_too_long"},
+		{"long header", "#alerts", []slack.MsgOption{slack.MsgOptionBlocks(slack.NewHeaderBlock(
+			slack.NewTextBlockObject("plain_text", strings.Repeat("h", SlackMaxHeaderLength+1), false, false)))}, "invalid_blocks"},
+		{"mrkdwn header", "#alerts", []slack.MsgOption{slack.MsgOptionBlocks(slack.NewHeaderBlock(
+			slack.NewTextBlockObject("mrkdwn", "*title*", false, false)))}, "invalid_blocks"},
+		{"empty actions", "#alerts", []slack.MsgOption{slack.MsgOptionBlocks(slack.NewActionBlock(""))}, "invalid_blocks"},
+		{"long button label", "#alerts", []slack.MsgOption{slack.MsgOptionBlocks(slack.NewActionBlock("", slack.NewButtonBlockElement("ack", "",
+			slack.NewTextBlockObject("plain_text", strings.Repeat("b", SlackMaxButtonLabel+1), false, false))))}, "invalid_blocks"},
+		{"no channel", "", []slack.MsgOption{slack.MsgOptionText("hi", false)}, "channel_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := client.PostMessage(tt.channel, tt.options...)
+			if err == nil || err.Error() != tt.code {
+				t.Errorf("Expected %s, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestSlackServerUploadV2(t *testing.T) {
+	srv := NewSlackServer()
+	defer srv.Close()
+	client := slack.New("xoxb-test", slack.OptionAPIURL(srv.APIURL()))
+
+	data := []byte("col1,col2\n1,2\n")
+	file, err := client.UploadFileV2Context(context.Background(), slack.UploadFileV2Parameters{
+		Reader:   bytes.NewReader(data),
+		Filename: "report.csv",
+		FileSize: len(data),
+		Title:    "Report",
+		Channel:  "C123",
+	})
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	uploads := srv.Uploads()
+	if len(uploads) != 1 || uploads[0].ID != file.ID {
+		t.Fatalf("Expected the upload to be recorded, got %+v", uploads)
+	}
+	if upload := uploads[0]; upload.Filename != "report.csv" || upload.Title != "Report" || !bytes.Equal(upload.Data, data) || upload.Channels[0] != "C123" {
+		t.Errorf("Unexpected upload: %+v", upload)
+	}
+}
+
+func TestSlackServerWebhook(t *testing.T) {
+	srv := NewSlackServer()
+	srv.Channels = []string{"alerts"}
+	defer srv.Close()
+
+	if err := slack.PostWebhook(srv.WebhookURL(), &slack.WebhookMessage{Text: "hi", Channel: "#alerts"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := slack.PostWebhook(srv.WebhookURL(), &slack.WebhookMessage{Text: "hi", Channel: "#nope"}); err == nil {
+		t.Error("Expected an error for an unknown channel")
+	}
+	if err := slack.PostWebhook(srv.WebhookURL(), &slack.WebhookMessage{}); err == nil {
+		t.Error("Expected an error for an empty message")
+	}
+
+	if calls := srv.Calls(); len(calls) != 3 || calls[1].Error != "channel_not_found" || calls[2].Error != "no_text" {
+		t.Errorf("Unexpected calls: %+v", calls)
+	}
+}
+
+func TestSlackServerUpdate(t *testing.T) {
+	srv := NewSlackServer()
+	defer srv.Close()
+	client := slack.New("xoxb-test", slack.OptionAPIURL(srv.APIURL()))
+
+	_, ts, err := client.PostMessage("#alerts", slack.MsgOptionText("DB down", false))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, _, _, err := client.UpdateMessage("#alerts", ts, slack.MsgOptionText("DB down (acknowledged)", false)); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, _, _, err := client.UpdateMessage("#alerts", "", slack.MsgOptionText("x", false)); err == nil || err.Error() != "message_not_found" {
+		t.Errorf("Expected message_not_found, got %v", err)
+	}
+	if _, _, _, err := client.UpdateMessage("#alerts", ts); err == nil || err.Error() != "no_text" {
+		t.Errorf("Expected no_text, got %v", err)
+	}
+
+	calls := srv.CallsTo("chat.update")
+	if len(calls) != 1 || calls[0].Param("ts") != ts || calls[0].Param("text") != "DB down (acknowledged)" {
+		t.Errorf("Unexpected calls: %+v", calls)
+	}
+}
@@ -0,0 +1,604 @@
+package notifytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Telegram Bot API limits enforced by TelegramServer
+const (
+	TelegramMaxMessageLength = 4096
+	TelegramMaxCaptionLength = 1024
+	TelegramMaxMediaGroup    = 10
+	TelegramMaxCallbackData  = 64
+)
+
+// TelegramCall is a Bot API request received by a TelegramServer
+type TelegramCall struct {
+	// Token is the bot token from the request path
+	Token string
+
+	// Method is the Bot API method, e.g. sendMessage
+	Method string
+
+	// Params holds the request parameters. JSON values are kept as decoded;
+	// form and multipart values are strings.
+	Params map[string]interface{}
+
+	// Files holds multipart file uploads by field name
+	Files map[string]UploadedFile
+
+	// Status is the HTTP status of the response
+	Status int
+
+	// Error is the response description of a failed call
+	Error string
+}
+
+// Param returns a parameter formatted as a string
+func (c TelegramCall) Param(name string) string {
+	switch v := c.Params[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// TelegramServer is a fake Telegram Bot API for hermetic tests. It validates
+// requests like the real API (chat IDs, text and caption lengths, parse
+// modes), records every call and can simulate rate limiting and outages.
+//
+//	srv := notifytest.NewTelegramServer()
+//	defer srv.Close()
+//
+//	telegram, _ := notify.NewTelegramNotifier(notify.TelegramConfig{
+//		BotToken: "123:ABC", ChatID: "42", APIURL: srv.URL,
+//	})
+type TelegramServer struct {
+	*httptest.Server
+
+	// Token, when set, is the only bot token accepted
+	Token string
+
+	// Chats, when set, lists the only chat IDs accepted
+	Chats []string
+
+	mu        sync.Mutex
+	calls     []TelegramCall
+	rateLimit int
+	retry     int
+	outage    int
+	down      bool
+	held      chan struct{}
+	messageID int
+	updates   []map[string]interface{}
+	updateID  int
+	queryID   int
+	newUpdate chan struct{}
+}
+
+// NewTelegramServer starts a fake Bot API server. Close it when done.
+func NewTelegramServer() *TelegramServer {
+	s := &TelegramServer{newUpdate: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Calls returns all recorded calls in order
+func (s *TelegramServer) Calls() []TelegramCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]TelegramCall(nil), s.calls...)
+}
+
+// CallsTo returns the successful calls of a method
+func (s *TelegramServer) CallsTo(method string) []TelegramCall {
+	var calls []TelegramCall
+	for _, call := range s.Calls() {
+		if call.Method == method && call.Status == http.StatusOK {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset discards recorded calls, queued updates and pending failures
+func (s *TelegramServer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls, s.updates = nil, nil
+	s.rateLimit, s.outage, s.down = 0, 0, false
+}
+
+// AddUpdate queues an update for getUpdates, setting its update_id, and
+// returns the update ID
+func (s *TelegramServer) AddUpdate(update map[string]interface{}) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateID++
+	update["update_id"] = s.updateID
+	s.updates = append(s.updates, update)
+
+	close(s.newUpdate)
+	s.newUpdate = make(chan struct{})
+	return s.updateID
+}
+
+// CallbackQuery returns the update of a user clicking a callback button with
+// data below a message, for AddUpdate or a webhook request
+func (s *TelegramServer) CallbackQuery(chatID string, messageID int, userID int64, data string) map[string]interface{} {
+	s.mu.Lock()
+	s.queryID++
+	id := strconv.Itoa(s.queryID)
+	s.mu.Unlock()
+
+	chat, err := strconv.ParseInt(chatID, 10, 64)
+	var chatValue interface{} = chat
+	if err != nil {
+		chatValue = chatID
+	}
+	return map[string]interface{}{
+		"callback_query": map[string]interface{}{
+			"id":   id,
+			"from": map[string]interface{}{"id": userID, "is_bot": false, "first_name": "Test", "username": "user" + strconv.FormatInt(userID, 10)},
+			"message": map[string]interface{}{
+				"message_id": messageID,
+				"date":       time.Now().Unix(),
+				"chat":       map[string]interface{}{"id": chatValue},
+			},
+			"chat_instance": "1",
+			"data":          data,
+		},
+	}
+}
+
+// awaitUpdates long-polls getUpdates: it waits up to the timeout parameter
+// for an update from the offset, or until the client gives up
+func (s *TelegramServer) awaitUpdates(r *http.Request, call *TelegramCall) {
+	offset, _ := strconv.Atoi(call.Param("offset"))
+	timeout, _ := strconv.Atoi(call.Param("timeout"))
+	deadline := time.After(time.Duration(timeout) * time.Second)
+
+	for {
+		s.mu.Lock()
+		ready := false
+		for _, update := range s.updates {
+			ready = ready || update["update_id"].(int) >= offset
+		}
+		newUpdate := s.newUpdate
+		s.mu.Unlock()
+
+		if ready {
+			return
+		}
+		select {
+		case <-newUpdate:
+		case <-deadline:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// RateLimit answers the next n requests with 429 Too Many Requests and a
+// retry_after of the given duration
+func (s *TelegramServer) RateLimit(n int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rateLimit = n
+	s.retry = int(retryAfter.Round(time.Second) / time.Second)
+}
+
+// Outage answers the next n requests with 502 Bad Gateway
+func (s *TelegramServer) Outage(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.outage = n
+}
+
+// SetDown makes the server answer every request with 502 Bad Gateway until
+// called with false
+func (s *TelegramServer) SetDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.down = down
+}
+
+// Hold stalls incoming requests until release is called or the client gives
+// up, for testing timeouts and cancellation
+func (s *TelegramServer) Hold() (release func()) {
+	held := make(chan struct{})
+
+	s.mu.Lock()
+	s.held = held
+	s.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			if s.held == held {
+				s.held = nil
+			}
+			s.mu.Unlock()
+			close(held)
+		})
+	}
+}
+
+// wait blocks while requests are held, reporting whether the client is still there
+func (s *TelegramServer) wait(r *http.Request) bool {
+	s.mu.Lock()
+	held := s.held
+	s.mu.Unlock()
+
+	if held == nil {
+		return true
+	}
+	select {
+	case <-held:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// telegramError is a failed Bot API response
+type telegramError struct {
+	status      int
+	description string
+	retryAfter  int
+}
+
+func badRequest(format string, args ...interface{}) *telegramError {
+	return &telegramError{status: http.StatusBadRequest, description: "Bad Request: " + fmt.Sprintf(format, args...)}
+}
+
+func (s *TelegramServer) handle(w http.ResponseWriter, r *http.Request) {
+	if !s.wait(r) {
+		return
+	}
+
+	token, method, ok := parseBotPath(r.URL.Path)
+	if !ok {
+		writeTelegram(w, &telegramError{status: http.StatusNotFound, description: "Not Found"}, nil)
+		return
+	}
+
+	call := TelegramCall{Token: token, Method: method, Params: map[string]interface{}{}, Files: map[string]UploadedFile{}}
+	apiErr := parseTelegramParams(r, &call)
+	if apiErr == nil && method == "getUpdates" {
+		s.awaitUpdates(r, &call)
+	}
+
+	s.mu.Lock()
+	switch {
+	case apiErr != nil:
+	case s.down || s.outage > 0:
+		if s.outage > 0 {
+			s.outage--
+		}
+		apiErr = &telegramError{status: http.StatusBadGateway, description: "Bad Gateway"}
+	case s.rateLimit > 0:
+		s.rateLimit--
+		apiErr = &telegramError{
+			status:      http.StatusTooManyRequests,
+			description: fmt.Sprintf("Too Many Requests: retry after %d", s.retry),
+			retryAfter:  s.retry,
+		}
+	case s.Token != "" && token != s.Token:
+		apiErr = &telegramError{status: http.StatusUnauthorized, description: "Unauthorized"}
+	default:
+		apiErr = s.validate(&call)
+	}
+
+	var result interface{}
+	if apiErr == nil {
+		result = s.result(&call)
+		call.Status = http.StatusOK
+	} else {
+		call.Status = apiErr.status
+		call.Error = apiErr.description
+	}
+	s.calls = append(s.calls, call)
+	s.mu.Unlock()
+
+	writeTelegram(w, apiErr, result)
+}
+
+// parseBotPath splits /bot<token>/<method>
+func parseBotPath(path string) (token, method string, ok bool) {
+	rest, found := strings.CutPrefix(path, "/bot")
+	if !found {
+		return "", "", false
+	}
+	token, method, found = strings.Cut(rest, "/")
+	return token, method, found && token != "" && method != ""
+}
+
+// parseTelegramParams reads JSON, form or multipart parameters
+func parseTelegramParams(r *http.Request, call *TelegramCall) *telegramError {
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&call.Params); err != nil && err != io.EOF {
+			return badRequest("can't parse JSON object")
+		}
+	case "multipart/form-data":
+		reader := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return badRequest("can't parse multipart request")
+			}
+			data, err := io.ReadAll(part)
+			if err != nil {
+				return badRequest("can't parse multipart request")
+			}
+			if part.FileName() != "" {
+				call.Files[part.FormName()] = UploadedFile{Filename: part.FileName(), Data: data}
+				continue
+			}
+			call.Params[part.FormName()] = string(data)
+		}
+	default:
+		if err := r.ParseForm(); err != nil {
+			return badRequest("can't parse request")
+		}
+		for key, values := range r.Form {
+			call.Params[key] = values[0]
+		}
+	}
+	return nil
+}
+
+// validate checks a call like the Bot API does
+func (s *TelegramServer) validate(call *TelegramCall) *telegramError {
+	switch call.Method {
+	case "getMe", "getUpdates":
+		return nil
+	case "answerCallbackQuery":
+		if call.Param("callback_query_id") == "" {
+			return badRequest("query is too old and response timeout expired or query ID is invalid")
+		}
+		return nil
+	case "sendMessage", "editMessageText":
+		if err := s.validateChat(call); err != nil {
+			return err
+		}
+		if call.Method == "editMessageText" && call.Param("message_id") == "" {
+			return badRequest("message to edit not found")
+		}
+		text := call.Param("text")
+		if strings.TrimSpace(text) == "" {
+			return badRequest("message text is empty")
+		}
+		if utf8.RuneCountInString(text) > TelegramMaxMessageLength {
+			return badRequest("message is too long")
+		}
+		if err := validateKeyboard(call); err != nil {
+			return err
+		}
+		return validateEntities(call.Param("parse_mode"), text)
+	case "sendPhoto", "sendDocument", "sendVideo", "sendAudio", "sendAnimation":
+		if err := s.validateChat(call); err != nil {
+			return err
+		}
+		field := strings.ToLower(strings.TrimPrefix(call.Method, "send"))
+		if _, uploaded := call.Files[field]; !uploaded && call.Param(field) == "" {
+			return badRequest("there is no %s in the request", field)
+		}
+		if err := validateKeyboard(call); err != nil {
+			return err
+		}
+		return validateCaption(call.Param("parse_mode"), call.Param("caption"))
+	case "sendMediaGroup":
+		if err := s.validateChat(call); err != nil {
+			return err
+		}
+		return validateMediaGroup(call)
+	}
+
+	return &telegramError{status: http.StatusNotFound, description: "Not Found: method not found"}
+}
+
+// validateChat checks the chat_id parameter
+func (s *TelegramServer) validateChat(call *TelegramCall) *telegramError {
+	chat := call.Param("chat_id")
+	if chat == "" {
+		return badRequest("chat_id is empty")
+	}
+	if len(s.Chats) == 0 {
+		return nil
+	}
+	for _, allowed := range s.Chats {
+		if chat == allowed {
+			return nil
+		}
+	}
+	return badRequest("chat not found")
+}
+
+// validateCaption checks a media caption
+func validateCaption(parseMode, caption string) *telegramError {
+	if utf8.RuneCountInString(caption) > TelegramMaxCaptionLength {
+		return badRequest("message caption is too long")
+	}
+	return validateEntities(parseMode, caption)
+}
+
+// validateKeyboard checks the inline keyboard in reply_markup
+func validateKeyboard(call *TelegramCall) *telegramError {
+	if _, exists := call.Params["reply_markup"]; !exists {
+		return nil
+	}
+
+	var markup struct {
+		InlineKeyboard [][]struct {
+			Text         string  `json:"text"`
+			URL          string  `json:"url"`
+			CallbackData *string `json:"callback_data"`
+		} `json:"inline_keyboard"`
+	}
+	if err := json.Unmarshal([]byte(call.Param("reply_markup")), &markup); err != nil {
+		return badRequest("can't parse reply keyboard markup JSON object")
+	}
+
+	for _, row := range markup.InlineKeyboard {
+		for _, button := range row {
+			switch {
+			case button.Text == "":
+				return badRequest("text buttons are unallowed in the inline keyboard")
+			case button.CallbackData != nil && (*button.CallbackData == "" || len(*button.CallbackData) > TelegramMaxCallbackData):
+				return badRequest("BUTTON_DATA_INVALID")
+			case (button.URL == "") == (button.CallbackData == nil):
+				return badRequest("can't parse inline keyboard button: Text buttons are unallowed in the inline keyboard")
+			}
+		}
+	}
+	return nil
+}
+
+// validateMediaGroup checks the media parameter of sendMediaGroup
+func validateMediaGroup(call *TelegramCall) *telegramError {
+	var media []struct {
+		Type      string `json:"type"`
+		Media     string `json:"media"`
+		Caption   string `json:"caption"`
+		ParseMode string `json:"parse_mode"`
+	}
+
+	raw, isString := call.Params["media"].(string)
+	if !isString {
+		data, _ := json.Marshal(call.Params["media"])
+		raw = string(data)
+	}
+	if err := json.Unmarshal([]byte(raw), &media); err != nil {
+		return badRequest("can't parse media JSON object")
+	}
+	if len(media) < 2 || len(media) > TelegramMaxMediaGroup {
+		return badRequest("wrong number of media items, must be between 2 and %d", TelegramMaxMediaGroup)
+	}
+
+	for _, item := range media {
+		if item.Media == "" {
+			return badRequest("media not found")
+		}
+		if name, ok := strings.CutPrefix(item.Media, "attach://"); ok {
+			if _, uploaded := call.Files[name]; !uploaded {
+				return badRequest("file attach://%s not found", name)
+			}
+		}
+		if err := validateCaption(item.ParseMode, item.Caption); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// result builds the result of a successful call
+func (s *TelegramServer) result(call *TelegramCall) interface{} {
+	if call.Method == "getMe" {
+		return map[string]interface{}{"id": 1, "is_bot": true, "first_name": "Test", "username": "notifytest_bot"}
+	}
+	if call.Method == "answerCallbackQuery" {
+		return true
+	}
+	if call.Method == "getUpdates" {
+		// updates before the offset are confirmed and dropped
+		offset, _ := strconv.Atoi(call.Param("offset"))
+		var pending []map[string]interface{}
+		for _, update := range s.updates {
+			if update["update_id"].(int) >= offset {
+				pending = append(pending, update)
+			}
+		}
+		s.updates = pending
+		return append([]map[string]interface{}{}, pending...)
+	}
+
+	if call.Method == "sendMediaGroup" {
+		var media []json.RawMessage
+		json.Unmarshal([]byte(call.Param("media")), &media)
+
+		messages := make([]interface{}, len(media))
+		for i := range media {
+			s.messageID++
+			messages[i] = map[string]interface{}{
+				"message_id": s.messageID,
+				"date":       time.Now().Unix(),
+				"chat":       map[string]interface{}{"id": call.Params["chat_id"]},
+			}
+		}
+		return messages
+	}
+
+	messageID := call.Params["message_id"]
+	if call.Method != "editMessageText" {
+		s.messageID++
+		messageID = s.messageID
+	}
+	message := map[string]interface{}{
+		"message_id": messageID,
+		"date":       time.Now().Unix(),
+		"chat":       map[string]interface{}{"id": call.Params["chat_id"]},
+	}
+	if text := call.Param("text"); text != "" {
+		message["text"] = text
+	}
+	if caption := call.Param("caption"); caption != "" {
+		message["caption"] = caption
+	}
+
+	return message
+}
+
+// writeTelegram writes a Bot API response
+func writeTelegram(w http.ResponseWriter, apiErr *telegramError, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if apiErr == nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+		return
+	}
+
+	body := map[string]interface{}{
+		"ok":          false,
+		"error_code":  apiErr.status,
+		"description": apiErr.description,
+	}
+	if apiErr.retryAfter > 0 {
+		body["parameters"] = map[string]interface{}{"retry_after": apiErr.retryAfter}
+		w.Header().Set("Retry-After", strconv.Itoa(apiErr.retryAfter))
+	}
+
+	w.WriteHeader(apiErr.status)
+	json.NewEncoder(w).Encode(body)
+}
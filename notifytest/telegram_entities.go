@@ -0,0 +1,234 @@
+package notifytest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateEntities checks formatted text like the Bot API's entity parser
+func validateEntities(parseMode, text string) *telegramError {
+	var err error
+	switch strings.ToLower(parseMode) {
+	case "":
+		return nil
+	case "markdown":
+		err = checkMarkdown(text)
+	case "markdownv2":
+		err = checkMarkdownV2(text)
+	case "html":
+		err = checkHTML(text)
+	default:
+		return badRequest("unsupported parse_mode")
+	}
+
+	if err != nil {
+		return badRequest("can't parse entities: %v", err)
+	}
+	return nil
+}
+
+// checkMarkdown validates legacy Markdown: *bold*, _italic_, `code`,
+// ```pre``` and [text](url), with \ escaping the entity characters
+func checkMarkdown(text string) error {
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '\\':
+			if i+1 < len(text) && strings.IndexByte("_*`[", text[i+1]) >= 0 {
+				i++
+			}
+		case '`':
+			delim := "`"
+			if strings.HasPrefix(text[i:], "```") {
+				delim = "```"
+			}
+			end := strings.Index(text[i+len(delim):], delim)
+			if end < 0 {
+				return endNotFound(i)
+			}
+			i += len(delim) + end + len(delim) - 1
+		case '*', '_':
+			end := strings.IndexByte(text[i+1:], c)
+			if end < 0 {
+				return endNotFound(i)
+			}
+			i += end + 1
+		case '[':
+			end := strings.IndexByte(text[i+1:], ']')
+			if end < 0 {
+				return endNotFound(i)
+			}
+			i += end + 1
+			if strings.HasPrefix(text[i+1:], "(") {
+				close := strings.IndexByte(text[i+1:], ')')
+				if close < 0 {
+					return endNotFound(i + 1)
+				}
+				i += close + 1
+			}
+		}
+	}
+	return nil
+}
+
+// markdownV2Reserved are the characters that must be escaped in MarkdownV2
+// when they don't start or end an entity
+const markdownV2Reserved = "_*[]()~`>#+-=|{}.!"
+
+// markdownV2Entities names the MarkdownV2 entity markers
+var markdownV2Entities = map[string]string{
+	"*":  "Bold",
+	"_":  "Italic",
+	"__": "Underline",
+	"~":  "Strikethrough",
+	"||": "Spoiler",
+	"[":  "TextUrl",
+}
+
+// checkMarkdownV2 validates MarkdownV2 entities and escaping
+func checkMarkdownV2(text string) error {
+	type open struct {
+		marker string
+		offset int
+	}
+	var stack []open
+
+	toggle := func(marker string, offset int) error {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].marker != marker {
+				continue
+			}
+			if i != len(stack)-1 {
+				top := stack[len(stack)-1]
+				return fmt.Errorf("Can't find end of %s entity at byte offset %d", markdownV2Entities[top.marker], top.offset)
+			}
+			stack = stack[:i]
+			return nil
+		}
+		stack = append(stack, open{marker: marker, offset: offset})
+		return nil
+	}
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\\':
+			i++
+		case strings.HasPrefix(text[i:], "```"):
+			end := strings.Index(text[i+3:], "```")
+			if end < 0 {
+				return endNotFound(i)
+			}
+			i += 3 + end + 2
+		case c == '`':
+			end := strings.IndexByte(text[i+1:], '`')
+			if end < 0 {
+				return endNotFound(i)
+			}
+			i += end + 1
+		case strings.HasPrefix(text[i:], "__"), strings.HasPrefix(text[i:], "||"):
+			if err := toggle(text[i:i+2], i); err != nil {
+				return err
+			}
+			i++
+		case c == '*' || c == '_' || c == '~':
+			if err := toggle(string(c), i); err != nil {
+				return err
+			}
+		case c == '[':
+			stack = append(stack, open{marker: "[", offset: i})
+		case c == ']' && len(stack) > 0 && stack[len(stack)-1].marker == "[":
+			stack = stack[:len(stack)-1]
+			if !strings.HasPrefix(text[i+1:], "(") {
+				continue
+			}
+			end := linkEnd(text[i+2:])
+			if end < 0 {
+				return endNotFound(i + 1)
+			}
+			i += end + 2
+		case c == '>' && (i == 0 || text[i-1] == '\n'):
+			// block quotation
+		case strings.IndexByte(markdownV2Reserved, c) >= 0:
+			return fmt.Errorf("Character '%c' is reserved and must be escaped with the preceding '\\'", c)
+		}
+	}
+
+	if len(stack) > 0 {
+		return fmt.Errorf("Can't find end of %s entity at byte offset %d", markdownV2Entities[stack[0].marker], stack[0].offset)
+	}
+	return nil
+}
+
+// htmlTags are the tags supported by the HTML parse mode
+var htmlTags = map[string]bool{
+	"b": true, "strong": true, "i": true, "em": true, "u": true, "ins": true,
+	"s": true, "strike": true, "del": true, "span": true, "tg-spoiler": true,
+	"a": true, "code": true, "pre": true, "blockquote": true, "tg-emoji": true,
+}
+
+// checkHTML validates HTML tags and their nesting
+func checkHTML(text string) error {
+	var stack []string
+	for i := 0; i < len(text); i++ {
+		if text[i] != '<' {
+			continue
+		}
+
+		end := strings.IndexByte(text[i:], '>')
+		if end < 0 {
+			return fmt.Errorf("Unclosed start tag at byte offset %d", i)
+		}
+		tag := text[i+1 : i+end]
+
+		if name, closing := strings.CutPrefix(tag, "/"); closing {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if len(stack) == 0 || stack[len(stack)-1] != name {
+				return fmt.Errorf("Unmatched end tag at byte offset %d, expected \"</%s>\", found \"</%s>\"", i, last(stack), name)
+			}
+			stack = stack[:len(stack)-1]
+		} else {
+			name := tag
+			if space := strings.IndexAny(tag, " \t\n"); space >= 0 {
+				name = tag[:space]
+			}
+			name = strings.ToLower(name)
+			if !htmlTags[name] {
+				return fmt.Errorf("Unsupported start tag \"%s\" at byte offset %d", name, i)
+			}
+			stack = append(stack, name)
+		}
+		i += end
+	}
+
+	if len(stack) > 0 {
+		return fmt.Errorf("Can't find end tag corresponding to start tag \"%s\"", stack[len(stack)-1])
+	}
+	return nil
+}
+
+// last returns the last element of s or ""
+func last(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[len(s)-1]
+}
+
+// linkEnd returns the index of the ')' ending a link URL, where ')' and '\'
+// are escaped, or -1
+func linkEnd(url string) int {
+	for i := 0; i < len(url); i++ {
+		switch url[i] {
+		case '\\':
+			i++
+		case ')':
+			return i
+		}
+	}
+	return -1
+}
+
+// endNotFound reports an unterminated entity
+func endNotFound(offset int) error {
+	return fmt.Errorf("Can't find end of the entity starting at byte offset %d", offset)
+}
@@ -0,0 +1,216 @@
+package notifytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func postJSON(t *testing.T, srv *TelegramServer, method string, params map[string]interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	body, _ := json.Marshal(params)
+	resp, err := http.Post(srv.URL+"/bot123:ABC/"+method, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result
+}
+
+func TestTelegramServerSendMessage(t *testing.T) {
+	srv := NewTelegramServer()
+	defer srv.Close()
+
+	status, result := postJSON(t, srv, "sendMessage", map[string]interface{}{"chat_id": "42", "text": "hello", "parse_mode": "HTML"})
+	if status != http.StatusOK || result["ok"] != true {
+		t.Fatalf("Expected success, got %d %v", status, result)
+	}
+
+	calls := srv.CallsTo("sendMessage")
+	if len(calls) != 1 || calls[0].Token != "123:ABC" || calls[0].Param("text") != "hello" || calls[0].Param("chat_id") != "42" {
+		t.Errorf("Unexpected calls: %+v", calls)
+	}
+}
+
+func TestTelegramServerValidation(t *testing.T) {
+	srv := NewTelegramServer()
+	srv.Chats = []string{"42"}
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		method      string
+		params      map[string]interface{}
+		description string
+	}{
+		{"missing chat", "sendMessage", map[string]interface{}{"text": "x"}, "Bad Request: chat_id is empty"},
+		{"unknown chat", "sendMessage", map[string]interface{}{"chat_id": "7", "text": "x"}, "Bad Request: chat not found"},
+		{"empty text", "sendMessage", map[string]interface{}{"chat_id": "42", "text": " "}, "Bad Request: message text is empty"},
+		{"too long", "sendMessage", map[string]interface{}{"chat_id": "42", "text": strings.Repeat("é", 4097)}, "Bad Request: message is too long"},
+		{"bad parse mode", "sendMessage", map[string]interface{}{"chat_id": "42", "text": "x", "parse_mode": "BBCode"}, "Bad Request: unsupported parse_mode"},
+		{"caption too long", "sendPhoto", map[string]interface{}{"chat_id": "42", "photo": "https://x/y.png", "caption": strings.Repeat("a", 1025)}, "Bad Request: message caption is too long"},
+		{"missing photo", "sendPhoto", map[string]interface{}{"chat_id": "42"}, "Bad Request: there is no photo in the request"},
+		{"small media group", "sendMediaGroup", map[string]interface{}{"chat_id": "42", "media": []map[string]string{{"type": "photo", "media": "a"}}}, "Bad Request: wrong number of media items, must be between 2 and 10"},
+		{"button without action", "sendMessage", map[string]interface{}{"chat_id": "42", "text": "x", "reply_markup": map[string]interface{}{
+			"inline_keyboard": [][]map[string]string{{{"text": "Ack"}}},
+		}}, "Bad Request: can't parse inline keyboard button: Text buttons are unallowed in the inline keyboard"},
+		{"long callback data", "sendMessage", map[string]interface{}{"chat_id": "42", "text": "x", "reply_markup": map[string]interface{}{
+			"inline_keyboard": [][]map[string]string{{{"text": "Ack", "callback_data": strings.Repeat("x", 65)}}},
+		}}, "Bad Request: BUTTON_DATA_INVALID"},
+		{"unknown method", "sendTelepathy", map[string]interface{}{}, "Not Found: method not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, result := postJSON(t, srv, tt.method, tt.params)
+			if status == http.StatusOK || result["description"] != tt.description {
+				t.Errorf("Expected %q, got %d %v", tt.description, status, result["description"])
+			}
+		})
+	}
+}
+
+func TestTelegramServerEntities(t *testing.T) {
+	tests := []struct {
+		mode  string
+		text  string
+		valid bool
+	}{
+		{"Markdown", "*bold* _italic_ `code` [link](https://example.com)", true},
+		{"Markdown", "snake\\_case is fine", true},
+		{"Markdown", "snake_case breaks", false},
+		{"Markdown", "```\nunterminated", false},
+		{"MarkdownV2", "*bold* __underline__ ||spoiler|| [link](https://example.com) v1\\.2", true},
+		{"MarkdownV2", "> quoted\nplain", true},
+		{"MarkdownV2", "version 1.2", false},
+		{"MarkdownV2", "*bold _italic* text_", false},
+		{"MarkdownV2", "*unterminated", false},
+		{"HTML", "<b>bold</b> <a href=\"https://example.com\">link</a> &lt;tag&gt;", true},
+		{"HTML", "<b>unterminated", false},
+		{"HTML", "<div>block</div>", false},
+		{"HTML", "1 < 2", false},
+		{"HTML", "<b><i>crossed</b></i>", false},
+		{"", "<b>*anything_", true},
+	}
+
+	for _, tt := range tests {
+		err := validateEntities(tt.mode, tt.text)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s %q: expected valid=%v, got %v", tt.mode, tt.text, tt.valid, err)
+		}
+		if err != nil && !strings.HasPrefix(err.description, "Bad Request: can't parse entities: ") {
+			t.Errorf("Unexpected description %q", err.description)
+		}
+	}
+}
+
+func TestTelegramServerFailures(t *testing.T) {
+	srv := NewTelegramServer()
+	defer srv.Close()
+
+	params := map[string]interface{}{"chat_id": "42", "text": "x"}
+
+	srv.RateLimit(1, 5*time.Second)
+	status, result := postJSON(t, srv, "sendMessage", params)
+	if status != http.StatusTooManyRequests || result["description"] != "Too Many Requests: retry after 5" {
+		t.Errorf("Expected a 429, got %d %v", status, result)
+	}
+	if parameters, _ := result["parameters"].(map[string]interface{}); parameters["retry_after"] != float64(5) {
+		t.Errorf("Expected retry_after 5, got %v", result["parameters"])
+	}
+
+	srv.Outage(1)
+	if status, _ := postJSON(t, srv, "sendMessage", params); status != http.StatusBadGateway {
+		t.Errorf("Expected a 502, got %d", status)
+	}
+
+	if status, _ := postJSON(t, srv, "sendMessage", params); status != http.StatusOK {
+		t.Errorf("Expected recovery, got %d", status)
+	}
+
+	srv.SetDown(true)
+	for i := 0; i < 3; i++ {
+		if status, _ := postJSON(t, srv, "sendMessage", params); status != http.StatusBadGateway {
+			t.Errorf("Expected a 502 while down, got %d", status)
+		}
+	}
+
+	if len(srv.Calls()) != 6 || len(srv.CallsTo("sendMessage")) != 1 {
+		t.Errorf("Expected 6 recorded calls with 1 success, got %d and %d", len(srv.Calls()), len(srv.CallsTo("sendMessage")))
+	}
+}
+
+func TestTelegramServerMultipart(t *testing.T) {
+	srv := NewTelegramServer()
+	defer srv.Close()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("chat_id", "42")
+	w.WriteField("caption", "report")
+	part, _ := w.CreateFormFile("document", "report.csv")
+	part.Write([]byte("a,b\n1,2\n"))
+	w.Close()
+
+	resp, err := http.Post(srv.URL+"/bot123:ABC/sendDocument", w.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected success, got %d: %+v", resp.StatusCode, srv.Calls())
+	}
+
+	call := srv.CallsTo("sendDocument")[0]
+	if file := call.Files["document"]; file.Filename != "report.csv" || string(file.Data) != "a,b\n1,2\n" {
+		t.Errorf("Unexpected upload: %+v", file)
+	}
+	if call.Param("caption") != "report" {
+		t.Errorf("Expected caption report, got %q", call.Param("caption"))
+	}
+}
+
+func TestTelegramServerUpdates(t *testing.T) {
+	srv := NewTelegramServer()
+	defer srv.Close()
+
+	status, result := postJSON(t, srv, "answerCallbackQuery", map[string]interface{}{})
+	if status == http.StatusOK || result["description"] != "Bad Request: query is too old and response timeout expired or query ID is invalid" {
+		t.Errorf("Expected a missing query ID to fail, got %d %v", status, result)
+	}
+
+	id := srv.AddUpdate(srv.CallbackQuery("42", 7, 1001, "ack"))
+	_, result = postJSON(t, srv, "getUpdates", map[string]interface{}{"timeout": 1})
+	if updates, _ := result["result"].([]interface{}); len(updates) != 1 {
+		t.Fatalf("Expected the queued update, got %v", result)
+	}
+
+	// Confirming the update with the offset long-polls until the next one
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		srv.AddUpdate(srv.CallbackQuery("42", 8, 1001, "mute"))
+	}()
+	_, result = postJSON(t, srv, "getUpdates", map[string]interface{}{"offset": id + 1, "timeout": 5})
+	updates, _ := result["result"].([]interface{})
+	if len(updates) != 1 {
+		t.Fatalf("Expected the new update only, got %v", result)
+	}
+	query := updates[0].(map[string]interface{})["callback_query"].(map[string]interface{})
+	if query["data"] != "mute" {
+		t.Errorf("Unexpected update %v", updates[0])
+	}
+
+	start := time.Now()
+	_, result = postJSON(t, srv, "getUpdates", map[string]interface{}{"offset": id + 2, "timeout": 1})
+	if updates, _ := result["result"].([]interface{}); len(updates) != 0 || time.Since(start) < time.Second {
+		t.Errorf("Expected an empty result after the timeout, got %v", result)
+	}
+}
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"context"
+	"time"
+)
+
+// Observer receives delivery events from a Manager, e.g. to record metrics.
+// Methods are called synchronously from the delivering goroutine and must be
+// safe for concurrent use.
+type Observer interface {
+	// OnAttempt is called after every delivery attempt
+	OnAttempt(ctx context.Context, event AttemptEvent)
+
+	// OnRetry is called when a failed attempt will be retried
+	OnRetry(ctx context.Context, event RetryEvent)
+
+	// OnQueueDepth is called when the number of pending asynchronous
+	// deliveries changes
+	OnQueueDepth(depth int)
+}
+
+// AttemptEvent describes a finished delivery attempt
+type AttemptEvent struct {
+	// Provider is the name of the notifier
+	Provider string
+
+	// Message is the message being delivered
+	Message *Message
+
+	// Attempt is the 1-based attempt number
+	Attempt int
+
+	// Duration is how long the attempt took
+	Duration time.Duration
+
+	// Err is the attempt's error, nil on success
+	Err error
+
+	// Final reports whether no further attempt will be made
+	Final bool
+}
+
+// RetryEvent describes a scheduled retry
+type RetryEvent struct {
+	// Provider is the name of the notifier
+	Provider string
+
+	// Message is the message being delivered
+	Message *Message
+
+	// Attempt is the 1-based number of the attempt that failed
+	Attempt int
+
+	// Delay is the backoff before the next attempt
+	Delay time.Duration
+
+	// Err is the error of the failed attempt
+	Err error
+}
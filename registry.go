@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ProviderFactory creates a notifier from raw configuration options.
+//
+// The options come from a config file, environment variables or service URL
+// query parameters. The instance name is passed as the "name" option.
+// Factories should return a *ConfigError naming the offending option when
+// validation fails.
+type ProviderFactory func(raw map[string]interface{}) (Notifier, error)
+
+var (
+	providers   = make(map[string]ProviderFactory)
+	providersMu sync.RWMutex
+)
+
+// RegisterProvider registers a factory for a provider kind, replacing any
+// factory previously registered for it. Provider packages usually call it
+// from an init function.
+func RegisterProvider(kind string, factory ProviderFactory) {
+	if factory == nil {
+		panic(fmt.Sprintf("notify: RegisterProvider factory for %q is nil", kind))
+	}
+
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	providers[strings.ToLower(kind)] = factory
+}
+
+// LookupProvider returns the factory registered for a provider kind
+func LookupProvider(kind string) (ProviderFactory, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	factory, exists := providers[strings.ToLower(kind)]
+	return factory, exists
+}
+
+// ProviderKinds returns all registered provider kinds
+func ProviderKinds() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	kinds := make([]string, 0, len(providers))
+	for kind := range providers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// NewProvider creates a notifier of the given kind from raw options
+func NewProvider(kind string, raw map[string]interface{}) (Notifier, error) {
+	factory, exists := LookupProvider(kind)
+	if !exists {
+		return nil, &ConfigError{Key: "type", Message: fmt.Sprintf("unknown provider type %q", kind)}
+	}
+
+	return factory(raw)
+}
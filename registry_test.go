@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"errors"
+	"testing"
+)
+
+func registerMockProvider(t *testing.T) {
+	t.Helper()
+	RegisterProvider("mock", func(raw map[string]interface{}) (Notifier, error) {
+		opts := newOptions(raw)
+		name := opts.String("name")
+		if name == "" {
+			name = "mock"
+		}
+		shouldFail := opts.Bool("fail")
+		if err := opts.Finish(); err != nil {
+			return nil, err
+		}
+
+		notifier := NewMockNotifier(name)
+		notifier.shouldFail = shouldFail
+		return notifier, nil
+	})
+	t.Cleanup(func() {
+		providersMu.Lock()
+		delete(providers, "mock")
+		providersMu.Unlock()
+	})
+}
+
+func TestRegisterProvider(t *testing.T) {
+	registerMockProvider(t)
+
+	if _, exists := LookupProvider("mock"); !exists {
+		t.Fatal("Expected mock provider to be registered")
+	}
+
+	for _, kind := range []string{"slack", "telegram"} {
+		if _, exists := LookupProvider(kind); !exists {
+			t.Errorf("Expected built-in provider '%s' to be registered", kind)
+		}
+	}
+
+	if _, err := NewProvider("pager", nil); err == nil {
+		t.Error("Expected error for unknown provider kind")
+	}
+}
+
+func TestRegisteredProviderFromConfig(t *testing.T) {
+	registerMockProvider(t)
+
+	manager, err := LoadConfig(writeConfig(t, "notify.yaml", `
+providers:
+  console:
+    type: mock
+    fail: true
+`))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	notifier, exists := manager.Get("console")
+	if !exists {
+		t.Fatal("Expected notifier 'console' to be registered")
+	}
+
+	if !notifier.(*MockNotifier).shouldFail {
+		t.Error("Expected option 'fail' to be passed to the factory")
+	}
+
+	_, err = LoadConfig(writeConfig(t, "notify.yaml", "providers:\n  console:\n    type: mock\n    colour: red\n"))
+
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Key != "providers.console.colour" {
+		t.Errorf("Expected error for providers.console.colour, got %v", err)
+	}
+}
+
+func TestRegisteredProviderFromURL(t *testing.T) {
+	registerMockProvider(t)
+
+	notifier, err := NewFromURL("mock://?name=console&fail=true")
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+
+	if notifier.Name() != "console" || !notifier.(*MockNotifier).shouldFail {
+		t.Errorf("Unexpected notifier: %+v", notifier)
+	}
+}
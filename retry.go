@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// RetryPolicy controls how the Manager retries failed deliveries
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per delivery (0 or 1 disables retries)
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry (defaults to 1s)
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between retries (defaults to 30s)
+	MaxBackoff time.Duration
+
+	// Multiplier is applied to the delay after each retry (defaults to 2)
+	Multiplier float64
+}
+
+// Defaults holds settings the Manager applies to every delivery
+type Defaults struct {
+	// Priority is used for messages that don't set one
+	Priority string
+
+	// Timeout bounds each delivery attempt (0 means no timeout)
+	Timeout time.Duration
+}
+
+// attempts returns the number of attempts allowed by the policy
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// backoff returns the delay to wait after the given failed attempt (1-based)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	if delay <= 0 {
+		delay = time.Second
+	}
+
+	maxDelay := p.MaxBackoff
+	if maxDelay <= 0 {
+		maxDelay = 30 * time.Second
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	for i := 1; i < attempt; i++ {
+		delay = time.Duration(float64(delay) * multiplier)
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+// Retryable reports whether a failed delivery may succeed when retried.
+// Network failures, timeouts, rate limits and server errors are retryable;
+// invalid messages, configuration errors, API rejections and partial
+// deliveries (ErrPartialDelivery) are permanent.
+// Errors can classify themselves with a Temporary() bool or Retryable() bool
+// method, like NotificationError does. Other errors, e.g. from custom
+// notifiers, are retryable.
+func Retryable(err error) bool {
+	switch {
+	case err == nil, errors.Is(err, ErrSuppressed), errors.Is(err, ErrPartialDelivery), errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded):
+		return true
+	}
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		switch e := err.(type) {
+		case net.Error:
+			// *url.Error only reports some transport failures as temporary
+			return true
+		case interface{ Temporary() bool }:
+			return e.Temporary()
+		case interface{ Retryable() bool }:
+			return e.Retryable()
+		case slack.SlackErrorResponse:
+			return slackRetryable(e.Err)
+		}
+	}
+	return true
+}
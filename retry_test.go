@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     300 * time.Millisecond,
+		Multiplier:     2,
+	}
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		300 * time.Millisecond,
+		300 * time.Millisecond,
+	}
+
+	for i, want := range expected {
+		if got := policy.backoff(i + 1); got != want {
+			t.Errorf("Attempt %d: expected backoff %v, got %v", i+1, want, got)
+		}
+	}
+
+	if (RetryPolicy{}).attempts() != 1 {
+		t.Error("Expected zero policy to allow a single attempt")
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	transport := &url.Error{Op: "Post", URL: "https://api.telegram.org", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"invalid message", &NotificationError{Provider: "slack", Message: "message text is required"}, false},
+		{"suppressed", fmt.Errorf("dedup: %w", ErrSuppressed), false},
+		{"canceled", context.Canceled, false},
+		{"timeout", &NotificationError{Provider: "slack", Message: "failed", Err: context.DeadlineExceeded}, true},
+		{"transport", &NotificationError{Provider: "telegram", Message: "failed to send request", Err: transport}, true},
+		{"telegram rejection", &NotificationError{Provider: "telegram", Err: &telegramAPIError{status: 400, description: "Bad Request: chat not found"}}, false},
+		{"telegram rate limit", &NotificationError{Provider: "telegram", Err: &telegramAPIError{status: 429}}, true},
+		{"telegram outage", &NotificationError{Provider: "telegram", Err: &telegramAPIError{status: 502}}, true},
+		{"slack rejection", &NotificationError{Provider: "slack", Err: slack.SlackErrorResponse{Err: "channel_not_found"}}, false},
+		{"slack internal error", &NotificationError{Provider: "slack", Err: slack.SlackErrorResponse{Err: "internal_error"}}, true},
+		{"slack rate limit", &NotificationError{Provider: "slack", Err: &slack.RateLimitedError{}}, true},
+		{"slack status", &NotificationError{Provider: "slack", Err: slack.StatusCodeError{Code: 404}}, false},
+		{"custom", errors.New("boom"), true},
+	}
+
+	for _, tt := range tests {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,379 @@
+// Package richtext is a provider-neutral rich text model. A Document is a
+// tree of nodes (bold, italic, code, links, lists, mentions, quotes...)
+// that each provider renders to its own markup with a Style, so the same
+// message looks right in Slack mrkdwn, Telegram MarkdownV2 or HTML, and as
+// plain text.
+//
+//	doc := richtext.New().
+//		Bold("Deploy failed").Text(" on ").Code("web-1").Line().
+//		Link("https://ci.example.com/builds/42", "Build log").
+//		List("check the migrations", "roll back").
+//		Document()
+package richtext
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Node is an element of rich text: one of Text, Span, Bold, Italic, Code,
+// Pre, Link, Mention, LineBreak, Paragraph, Quote or List
+type Node interface {
+	node()
+}
+
+// Text is literal text
+type Text struct {
+	Value string
+}
+
+// Span groups inline nodes, e.g. a list item mixing text and formatting
+type Span struct {
+	Children []Node
+}
+
+// Bold is bold text
+type Bold struct {
+	Children []Node
+}
+
+// Italic is italic text
+type Italic struct {
+	Children []Node
+}
+
+// Code is inline code
+type Code struct {
+	Value string
+}
+
+// Pre is a preformatted code block with an optional language
+type Pre struct {
+	Language string
+	Value    string
+}
+
+// Link is a hyperlink; without children the URL is shown
+type Link struct {
+	URL      string
+	Children []Node
+}
+
+// Mention mentions a user by provider-specific ID (e.g. a Slack user ID or a
+// Telegram user ID), showing Name where the provider needs a label
+type Mention struct {
+	ID   string
+	Name string
+}
+
+// LineBreak starts a new line
+type LineBreak struct{}
+
+// Paragraph is a block of inline nodes
+type Paragraph struct {
+	Children []Node
+}
+
+// Quote is a quoted block
+type Quote struct {
+	Children []Node
+}
+
+// List is a bulleted or numbered list
+type List struct {
+	Ordered bool
+	Items   []Node
+}
+
+func (Text) node()      {}
+func (Span) node()      {}
+func (Bold) node()      {}
+func (Italic) node()    {}
+func (Code) node()      {}
+func (Pre) node()       {}
+func (Link) node()      {}
+func (Mention) node()   {}
+func (LineBreak) node() {}
+func (Paragraph) node() {}
+func (Quote) node()     {}
+func (List) node()      {}
+
+// Plain returns literal text
+func Plain(text string) Node {
+	return Text{Value: text}
+}
+
+// Group returns a span of inline nodes
+func Group(children ...Node) Node {
+	return Span{Children: children}
+}
+
+// B returns bold nodes
+func B(children ...Node) Node {
+	return Bold{Children: children}
+}
+
+// I returns italic nodes
+func I(children ...Node) Node {
+	return Italic{Children: children}
+}
+
+// C returns inline code
+func C(code string) Node {
+	return Code{Value: code}
+}
+
+// A returns a link to url
+func A(url string, children ...Node) Node {
+	return Link{URL: url, Children: children}
+}
+
+// Document is rich text, a sequence of nodes. Treat it as immutable once
+// attached to a message.
+type Document struct {
+	Nodes []Node
+}
+
+// Empty reports whether the document has no content
+func (d *Document) Empty() bool {
+	return d == nil || strings.TrimSpace(d.Plain()) == ""
+}
+
+// Plain renders the document as plain text
+func (d *Document) Plain() string {
+	return d.Render(PlainText)
+}
+
+// String implements fmt.Stringer with the plain text
+func (d *Document) String() string {
+	return d.Plain()
+}
+
+// Builder builds a Document
+type Builder struct {
+	nodes []Node
+}
+
+// New returns an empty Builder
+func New() *Builder {
+	return &Builder{}
+}
+
+// Add appends nodes
+func (b *Builder) Add(nodes ...Node) *Builder {
+	b.nodes = append(b.nodes, nodes...)
+	return b
+}
+
+// Text appends literal text
+func (b *Builder) Text(text string) *Builder {
+	return b.Add(Text{Value: text})
+}
+
+// Bold appends bold text
+func (b *Builder) Bold(text string) *Builder {
+	return b.Add(Bold{Children: []Node{Text{Value: text}}})
+}
+
+// Italic appends italic text
+func (b *Builder) Italic(text string) *Builder {
+	return b.Add(Italic{Children: []Node{Text{Value: text}}})
+}
+
+// Code appends inline code
+func (b *Builder) Code(code string) *Builder {
+	return b.Add(Code{Value: code})
+}
+
+// Pre appends a code block
+func (b *Builder) Pre(language, code string) *Builder {
+	return b.Add(Pre{Language: language, Value: code})
+}
+
+// Link appends a link; an empty text shows the URL
+func (b *Builder) Link(url, text string) *Builder {
+	link := Link{URL: url}
+	if text != "" {
+		link.Children = []Node{Text{Value: text}}
+	}
+	return b.Add(link)
+}
+
+// Mention appends a user mention
+func (b *Builder) Mention(id, name string) *Builder {
+	return b.Add(Mention{ID: id, Name: name})
+}
+
+// Line appends a line break
+func (b *Builder) Line() *Builder {
+	return b.Add(LineBreak{})
+}
+
+// Paragraph appends a paragraph of text
+func (b *Builder) Paragraph(text string) *Builder {
+	return b.Add(Paragraph{Children: []Node{Text{Value: text}}})
+}
+
+// Quote appends a quoted block of text
+func (b *Builder) Quote(text string) *Builder {
+	return b.Add(Quote{Children: []Node{Text{Value: text}}})
+}
+
+// List appends a bulleted list of texts
+func (b *Builder) List(items ...string) *Builder {
+	return b.Add(List{Items: texts(items)})
+}
+
+// OrderedList appends a numbered list of texts
+func (b *Builder) OrderedList(items ...string) *Builder {
+	return b.Add(List{Ordered: true, Items: texts(items)})
+}
+
+// Document returns the built document
+func (b *Builder) Document() *Document {
+	return &Document{Nodes: append([]Node(nil), b.nodes...)}
+}
+
+func texts(items []string) []Node {
+	nodes := make([]Node, len(items))
+	for i, item := range items {
+		nodes[i] = Text{Value: item}
+	}
+	return nodes
+}
+
+// Style describes a markup language for Render. Functions receive rendered,
+// already escaped inner markup unless noted.
+type Style struct {
+	// Escape escapes literal text
+	Escape func(text string) string
+
+	Bold   func(inner string) string
+	Italic func(inner string) string
+
+	// Code and Pre receive the raw code and escape it themselves
+	Code func(code string) string
+	Pre  func(language, code string) string
+
+	// Link receives the raw URL; inner is empty for links without children
+	Link func(url, inner string) string
+
+	// Mention receives the raw ID and name
+	Mention func(id, name string) string
+
+	// Quote receives the rendered quoted block
+	Quote func(inner string) string
+
+	// Bullet returns the marker of the nth (1-based) list item
+	Bullet func(ordered bool, n int) string
+}
+
+// Render renders the document in style
+func (d *Document) Render(style Style) string {
+	if d == nil {
+		return ""
+	}
+	r := renderer{style: style}
+	return strings.TrimSpace(r.nodes(d.Nodes))
+}
+
+// renderer renders nodes with a style
+type renderer struct {
+	style Style
+}
+
+// nodes renders a sequence of nodes, separating blocks from their
+// neighbours by a blank line
+func (r renderer) nodes(nodes []Node) string {
+	var b strings.Builder
+	for i, n := range nodes {
+		block := isBlock(n)
+		if i > 0 && (block || isBlock(nodes[i-1])) {
+			trimmed := strings.TrimRight(b.String(), "\n ")
+			b.Reset()
+			b.WriteString(trimmed)
+			b.WriteString("\n\n")
+		}
+		b.WriteString(r.node(n))
+	}
+	return b.String()
+}
+
+func (r renderer) node(n Node) string {
+	s := r.style
+	switch n := n.(type) {
+	case Text:
+		return s.Escape(n.Value)
+	case Span:
+		return r.nodes(n.Children)
+	case Bold:
+		return s.Bold(r.nodes(n.Children))
+	case Italic:
+		return s.Italic(r.nodes(n.Children))
+	case Code:
+		return s.Code(n.Value)
+	case Pre:
+		return s.Pre(n.Language, strings.TrimRight(n.Value, "\n"))
+	case Link:
+		return s.Link(n.URL, r.nodes(n.Children))
+	case Mention:
+		return s.Mention(n.ID, n.Name)
+	case LineBreak:
+		return "\n"
+	case Paragraph:
+		return strings.TrimSpace(r.nodes(n.Children))
+	case Quote:
+		return s.Quote(strings.TrimSpace(r.nodes(n.Children)))
+	case List:
+		items := make([]string, len(n.Items))
+		for i, item := range n.Items {
+			items[i] = s.Bullet(n.Ordered, i+1) + strings.TrimSpace(r.node(item))
+		}
+		return strings.Join(items, "\n")
+	}
+	return ""
+}
+
+func isBlock(n Node) bool {
+	switch n.(type) {
+	case Paragraph, Quote, List, Pre:
+		return true
+	}
+	return false
+}
+
+// PrefixLines prefixes every line of text, e.g. with "> " for quotes
+func PrefixLines(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
+
+// Bullets returns "• " for bulleted and "1. " for numbered list items
+func Bullets(ordered bool, n int) string {
+	if ordered {
+		return strconv.Itoa(n) + ". "
+	}
+	return "• "
+}
+
+// PlainText renders documents as plain text, e.g. for SMS
+var PlainText = Style{
+	Escape: func(text string) string { return text },
+	Bold:   func(inner string) string { return inner },
+	Italic: func(inner string) string { return inner },
+	Code:   func(code string) string { return code },
+	Pre:    func(language, code string) string { return code },
+	Link: func(url, inner string) string {
+		if inner == "" || inner == url {
+			return url
+		}
+		return inner + " (" + url + ")"
+	},
+	Mention: func(id, name string) string {
+		if name == "" {
+			name = id
+		}
+		return "@" + name
+	},
+	Quote:  func(inner string) string { return PrefixLines(inner, "> ") },
+	Bullet: Bullets,
+}
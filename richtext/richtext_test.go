@@ -0,0 +1,68 @@
+package richtext
+
+import (
+	"strings"
+	"testing"
+)
+
+func testDocument() *Document {
+	return New().
+		Bold("Deploy failed").Text(" on ").Code("web-1").Text(" by ").Mention("U123", "alice").Line().
+		Link("https://ci.example.com/42", "Build log").
+		Pre("go", "panic: boom\n").
+		Quote("it worked\non my machine").
+		OrderedList("check migrations", "roll back").
+		Document()
+}
+
+func TestPlain(t *testing.T) {
+	want := "Deploy failed on web-1 by @alice\n" +
+		"Build log (https://ci.example.com/42)\n\n" +
+		"panic: boom\n\n" +
+		"> it worked\n> on my machine\n\n" +
+		"1. check migrations\n2. roll back"
+
+	if got := testDocument().Plain(); got != want {
+		t.Errorf("Unexpected plain text:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderNested(t *testing.T) {
+	markup := Style{
+		Escape:  strings.ToUpper,
+		Bold:    func(inner string) string { return "<b>" + inner + "</b>" },
+		Italic:  func(inner string) string { return "<i>" + inner + "</i>" },
+		Code:    func(code string) string { return "<code>" + code + "</code>" },
+		Pre:     func(language, code string) string { return "<pre " + language + ">" + code + "</pre>" },
+		Link:    func(url, inner string) string { return "<a " + url + ">" + inner + "</a>" },
+		Mention: func(id, name string) string { return "<@" + id + ">" },
+		Quote:   func(inner string) string { return "<q>" + inner + "</q>" },
+		Bullet:  Bullets,
+	}
+
+	doc := New().
+		Add(B(Plain("bold "), I(Plain("and italic")))).
+		Add(List{Items: []Node{Group(Plain("see "), A("u", Plain("docs"))), C("x")}}).
+		Document()
+
+	want := "<b>BOLD <i>AND ITALIC</i></b>\n\n• SEE <a u>DOCS</a>\n• <code>x</code>"
+	if got := doc.Render(markup); got != want {
+		t.Errorf("Unexpected rendering %q, want %q", got, want)
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	var nilDoc *Document
+	if !nilDoc.Empty() || !New().Document().Empty() || !New().Text("  ").Document().Empty() {
+		t.Error("Expected documents without text to be empty")
+	}
+	if New().Code("x").Document().Empty() {
+		t.Error("Expected a document with code not to be empty")
+	}
+}
+
+func TestLinkWithoutText(t *testing.T) {
+	if got := New().Link("https://example.com", "").Document().Plain(); got != "https://example.com" {
+		t.Errorf("Expected the URL, got %q", got)
+	}
+}
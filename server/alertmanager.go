@@ -0,0 +1,465 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/milano15662/notify"
+)
+
+// maxAlertAttachments caps the number of per-alert attachments in a message
+const maxAlertAttachments = 10
+
+// AlertmanagerPayload is the Prometheus Alertmanager webhook payload (version 4)
+type AlertmanagerPayload struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+// Alert is a single alert of an AlertmanagerPayload
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// Alert statuses
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// AlertmanagerHandler receives Alertmanager webhooks and delivers them
+// through Manager routing.
+//
+// The route is taken from the route query parameter, then from a route named
+// after the payload's receiver, then from DefaultRoute.
+//
+// Alerts are grouped by the payload's group key: the handler remembers the
+// alerts reported for each group, counts the alerts that are new since the
+// group's previous notification and doesn't report resolved alerts again.
+// Groups are only updated by delivered notifications.
+type AlertmanagerHandler struct {
+	manager *notify.Manager
+	groups  *alertGroups
+
+	// DefaultRoute is used when no route matches the request
+	DefaultRoute string
+
+	// Timeout bounds the delivery of each webhook (defaults to 30s)
+	Timeout time.Duration
+}
+
+// NewAlertmanagerHandler creates an Alertmanager webhook handler for manager
+func NewAlertmanagerHandler(manager *notify.Manager, defaultRoute string) *AlertmanagerHandler {
+	return &AlertmanagerHandler{
+		manager:      manager,
+		groups:       &alertGroups{groups: make(map[string]*alertGroup)},
+		DefaultRoute: defaultRoute,
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *AlertmanagerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var payload AlertmanagerPayload
+	if !decodeJSON(w, r, &payload, false) {
+		return
+	}
+
+	if payload.Version != "4" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported webhook version %q (want 4)", payload.Version))
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	route, ok := h.route(r, payload.Receiver)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no route for receiver %q", payload.Receiver))
+		return
+	}
+
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	// the group is only updated once delivered, so a webhook Alertmanager
+	// retries after a failure is reported the same way
+	grouped, fresh := h.groups.pending(&payload)
+	results := deliverRoute(ctx, h.manager, route, alertmanagerMessages(grouped, fresh))
+	if delivered(results) {
+		h.groups.record(&payload)
+	}
+	writeResults(w, results)
+}
+
+// route resolves the route for a request
+func (h *AlertmanagerHandler) route(r *http.Request, receiver string) (string, bool) {
+	for _, candidate := range []string{r.URL.Query().Get("route"), receiver, h.DefaultRoute} {
+		if candidate == "" {
+			continue
+		}
+		if _, exists := h.manager.Route(candidate); exists {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// deliverRoute sends each message to every provider of route and returns
+// one result per provider, failing a provider if any of its messages failed
+func deliverRoute(ctx context.Context, manager *notify.Manager, route string, msgs []*notify.Message) []Result {
+	providers, _ := manager.Route(route)
+
+	results := make([]Result, 0, len(providers))
+	for _, provider := range providers {
+		var err error
+		for _, msg := range msgs {
+			if sendErr := manager.SendWithOptions(ctx, provider, msg); sendErr != nil && err == nil {
+				err = sendErr
+			}
+		}
+		results = append(results, newResult(provider, err))
+	}
+	return results
+}
+
+// Validate checks that the payload and its alerts have known statuses
+func (p *AlertmanagerPayload) Validate() error {
+	if p.Status != StatusFiring && p.Status != StatusResolved {
+		return fmt.Errorf("unknown status %q (want %s or %s)", p.Status, StatusFiring, StatusResolved)
+	}
+	for i, alert := range p.Alerts {
+		if alert.Status != "" && alert.Status != StatusFiring && alert.Status != StatusResolved {
+			return fmt.Errorf("alerts[%d]: unknown status %q (want %s or %s)", i, alert.Status, StatusFiring, StatusResolved)
+		}
+	}
+	return nil
+}
+
+// AlertmanagerMessages renders a webhook payload as one message per alert
+// status, firing alerts first. Each message carries the group key in its
+// Metadata so providers and middleware can group related notifications.
+// It returns an error for unknown statuses.
+func AlertmanagerMessages(payload *AlertmanagerPayload) ([]*notify.Message, error) {
+	if err := payload.Validate(); err != nil {
+		return nil, err
+	}
+	return alertmanagerMessages(payload, nil), nil
+}
+
+// alertmanagerMessages renders a valid payload. fresh holds the fingerprints
+// of the firing alerts that are new to their group.
+func alertmanagerMessages(payload *AlertmanagerPayload, fresh map[string]bool) []*notify.Message {
+	byStatus := make(map[string][]Alert)
+	for _, alert := range payload.Alerts {
+		status := alertStatus(payload, alert)
+		byStatus[status] = append(byStatus[status], alert)
+	}
+
+	var msgs []*notify.Message
+	for _, status := range []string{StatusFiring, StatusResolved} {
+		if alerts := byStatus[status]; len(alerts) > 0 {
+			msg := alertMessage(payload, status, alerts)
+			if status == StatusFiring && fresh != nil {
+				markNewAlerts(msg, alerts, fresh)
+			}
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+// alertStatus returns the status of an alert, defaulting to the payload's
+func alertStatus(payload *AlertmanagerPayload, alert Alert) string {
+	if alert.Status == "" {
+		return payload.Status
+	}
+	return alert.Status
+}
+
+// markNewAlerts notes how many firing alerts are new to a group that was
+// notified before
+func markNewAlerts(msg *notify.Message, alerts []Alert, fresh map[string]bool) {
+	count := 0
+	for _, alert := range alerts {
+		if fresh[alert.Fingerprint] {
+			count++
+		}
+	}
+
+	msg.Metadata["new_alerts"] = count
+	if count > 0 && count < len(alerts) {
+		msg.Text += fmt.Sprintf("\n%d new since the last notification", count)
+	}
+}
+
+// maxAlertGroups caps the number of alert groups an AlertmanagerHandler
+// remembers; the least recently notified group is forgotten first
+const maxAlertGroups = 1000
+
+// alertGroups remembers the alerts reported for each group key
+type alertGroups struct {
+	mu     sync.Mutex
+	groups map[string]*alertGroup
+}
+
+// alertGroup is the state of the alerts of a group at its last notification
+type alertGroup struct {
+	// statuses maps alert fingerprints to their last reported status
+	statuses map[string]string
+	seen     time.Time
+}
+
+// pending returns the payload without the resolved alerts that were already
+// reported, and the fingerprints of the firing alerts that are new to a
+// group notified before. It doesn't change the groups: call record once the
+// notification is delivered.
+func (g *alertGroups) pending(payload *AlertmanagerPayload) (*AlertmanagerPayload, map[string]bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	group, known := g.groups[payload.GroupKey]
+
+	grouped := *payload
+	grouped.Alerts = nil
+	fresh := make(map[string]bool)
+	for _, alert := range payload.Alerts {
+		if alert.Fingerprint != "" && known {
+			status, previous := alertStatus(payload, alert), group.statuses[alert.Fingerprint]
+			if status == StatusResolved && previous == StatusResolved {
+				continue
+			}
+			if status == StatusFiring && previous != StatusFiring {
+				fresh[alert.Fingerprint] = true
+			}
+		}
+		grouped.Alerts = append(grouped.Alerts, alert)
+	}
+	return &grouped, fresh
+}
+
+// record records a delivered group notification. Groups are forgotten once
+// all their alerts are resolved.
+func (g *alertGroups) record(payload *AlertmanagerPayload) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	group, known := g.groups[payload.GroupKey]
+	if !known {
+		group = &alertGroup{statuses: make(map[string]string)}
+	}
+	group.seen = time.Now()
+
+	for _, alert := range payload.Alerts {
+		if alert.Fingerprint != "" {
+			group.statuses[alert.Fingerprint] = alertStatus(payload, alert)
+		}
+	}
+
+	if payload.Status == StatusResolved {
+		delete(g.groups, payload.GroupKey)
+		return
+	}
+
+	g.groups[payload.GroupKey] = group
+	if len(g.groups) > maxAlertGroups {
+		oldest := payload.GroupKey
+		for key, other := range g.groups {
+			if other.seen.Before(g.groups[oldest].seen) {
+				oldest = key
+			}
+		}
+		delete(g.groups, oldest)
+	}
+}
+
+// alertMessage renders the alerts of one status
+func alertMessage(payload *AlertmanagerPayload, status string, alerts []Alert) *notify.Message {
+	msg := &notify.Message{
+		Title:    alertTitle(payload, status, len(alerts)),
+		Text:     alertSummary(payload, alerts),
+		Priority: alertPriority(payload, status),
+		Metadata: map[string]interface{}{
+			"group_key": payload.GroupKey,
+			"status":    status,
+			"receiver":  payload.Receiver,
+		},
+	}
+
+	if payload.ExternalURL != "" {
+		msg.Metadata["external_url"] = payload.ExternalURL
+	}
+
+	color := "danger"
+	if status == StatusResolved {
+		color = "good"
+	} else if payload.CommonLabels["severity"] == "warning" {
+		color = "warning"
+	}
+
+	for i, alert := range alerts {
+		if i == maxAlertAttachments {
+			msg.Attachments = append(msg.Attachments, notify.Attachment{
+				Text:  fmt.Sprintf("… and %d more", len(alerts)-maxAlertAttachments),
+				Color: color,
+			})
+			break
+		}
+		msg.Attachments = append(msg.Attachments, alertAttachment(payload, alert, color))
+	}
+
+	if payload.TruncatedAlerts > 0 {
+		msg.Text += fmt.Sprintf("\n(%d alerts truncated by Alertmanager)", payload.TruncatedAlerts)
+	}
+
+	return msg
+}
+
+// alertTitle formats a title like "[FIRING:2] HighCPU critical"
+func alertTitle(payload *AlertmanagerPayload, status string, count int) string {
+	labels := payload.GroupLabels
+	if len(labels) == 0 {
+		labels = payload.CommonLabels
+	}
+
+	title := fmt.Sprintf("[%s:%d]", strings.ToUpper(status), count)
+	if name := labels["alertname"]; name != "" {
+		title += " " + name
+	}
+	for _, key := range sortedKeys(labels) {
+		if key != "alertname" {
+			title += " " + labels[key]
+		}
+	}
+	return title
+}
+
+// alertSummary uses the common summary or description, falling back to the alert names
+func alertSummary(payload *AlertmanagerPayload, alerts []Alert) string {
+	for _, key := range []string{"summary", "description", "message"} {
+		if text := payload.CommonAnnotations[key]; text != "" {
+			return text
+		}
+	}
+
+	if len(alerts) == 1 {
+		for _, key := range []string{"summary", "description", "message"} {
+			if text := alerts[0].Annotations[key]; text != "" {
+				return text
+			}
+		}
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, alert := range alerts {
+		if name := alert.Labels["alertname"]; name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return fmt.Sprintf("%d alerts", len(alerts))
+	}
+	return strings.Join(names, ", ")
+}
+
+// alertPriority maps the status and severity to a message priority
+func alertPriority(payload *AlertmanagerPayload, status string) string {
+	if status == StatusResolved {
+		return notify.PriorityLow
+	}
+
+	switch payload.CommonLabels["severity"] {
+	case "critical", "page", "error":
+		return notify.PriorityHigh
+	case "info", "none":
+		return notify.PriorityLow
+	}
+	return notify.PriorityNormal
+}
+
+// alertAttachment renders one alert's labels and annotations as fields.
+// Labels shared by the whole group are left out since the title shows them.
+func alertAttachment(payload *AlertmanagerPayload, alert Alert, color string) notify.Attachment {
+	att := notify.Attachment{
+		Title: alert.Labels["alertname"],
+		Color: color,
+	}
+
+	for _, key := range []string{"summary", "description", "message"} {
+		if text := alert.Annotations[key]; text != "" && text != payload.CommonAnnotations[key] {
+			att.Text = text
+			break
+		}
+	}
+
+	for _, key := range sortedKeys(alert.Labels) {
+		if key == "alertname" || payload.CommonLabels[key] == alert.Labels[key] {
+			continue
+		}
+		att.Fields = append(att.Fields, notify.Field{Title: key, Value: alert.Labels[key], Short: true})
+	}
+
+	for _, key := range sortedKeys(alert.Annotations) {
+		switch key {
+		case "summary", "description", "message":
+			continue
+		}
+		value := alert.Annotations[key]
+		att.Fields = append(att.Fields, notify.Field{Title: key, Value: value, Short: len(value) <= 40})
+	}
+
+	if !alert.StartsAt.IsZero() {
+		att.Footer = "Started " + alert.StartsAt.UTC().Format(time.RFC3339)
+		if alert.Status == StatusResolved && !alert.EndsAt.IsZero() {
+			att.Footer += " · resolved " + alert.EndsAt.UTC().Format(time.RFC3339)
+		}
+	}
+
+	if alert.GeneratorURL != "" {
+		att.Fields = append(att.Fields, notify.Field{Title: "Source", Value: alert.GeneratorURL})
+	}
+
+	return att
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/milano15662/notify"
+	"github.com/milano15662/notify/notifytest"
+)
+
+const alertmanagerPayload = `{
+  "version": "4",
+  "groupKey": "{}:{alertname=\"HighCPU\"}",
+  "truncatedAlerts": 0,
+  "status": "firing",
+  "receiver": "all",
+  "groupLabels": {"alertname": "HighCPU"},
+  "commonLabels": {"alertname": "HighCPU", "severity": "critical", "job": "node"},
+  "commonAnnotations": {"summary": "CPU usage above 90%"},
+  "externalURL": "http://alertmanager:9093",
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": {"alertname": "HighCPU", "severity": "critical", "job": "node", "instance": "web-1"},
+      "annotations": {"summary": "CPU usage above 90%", "runbook_url": "https://runbooks/cpu"},
+      "startsAt": "2024-01-02T03:04:05Z",
+      "endsAt": "0001-01-01T00:00:00Z",
+      "generatorURL": "http://prometheus/graph",
+      "fingerprint": "abc"
+    },
+    {
+      "status": "resolved",
+      "labels": {"alertname": "HighCPU", "severity": "critical", "job": "node", "instance": "web-2"},
+      "annotations": {"summary": "CPU usage above 90%"},
+      "startsAt": "2024-01-02T03:00:00Z",
+      "endsAt": "2024-01-02T03:10:00Z",
+      "fingerprint": "def"
+    }
+  ]
+}`
+
+func TestAlertmanagerWebhook(t *testing.T) {
+	s, ok, _ := newTestServer(t, Options{})
+
+	rec := do(s, http.MethodPost, "/v1/alertmanager", "", alertmanagerPayload)
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("Expected 502 because of the failing provider, got %d: %s", rec.Code, rec.Body)
+	}
+
+	msgs := sent(ok)
+
+	if len(msgs) != 2 {
+		t.Fatalf("Expected firing and resolved messages, got %d", len(msgs))
+	}
+
+	firing, resolved := msgs[0], msgs[1]
+	if firing.Title != "[FIRING:1] HighCPU" || firing.Text != "CPU usage above 90%" || firing.Priority != notify.PriorityHigh {
+		t.Errorf("Unexpected firing message: %+v", firing)
+	}
+
+	if firing.Metadata["group_key"] != `{}:{alertname="HighCPU"}` {
+		t.Errorf("Expected group key in metadata, got %v", firing.Metadata)
+	}
+
+	att := firing.Attachments[0]
+	if att.Color != "danger" || att.Fields[0].Title != "instance" || att.Fields[0].Value != "web-1" {
+		t.Errorf("Unexpected firing attachment: %+v", att)
+	}
+
+	if att.Fields[1].Title != "runbook_url" {
+		t.Errorf("Expected annotation field, got %+v", att.Fields)
+	}
+
+	if resolved.Title != "[RESOLVED:1] HighCPU" || resolved.Priority != notify.PriorityLow || resolved.Attachments[0].Color != "good" {
+		t.Errorf("Unexpected resolved message: %+v", resolved)
+	}
+
+	if !strings.Contains(resolved.Attachments[0].Footer, "resolved 2024-01-02T03:10:00Z") {
+		t.Errorf("Expected resolve time in footer, got %q", resolved.Attachments[0].Footer)
+	}
+}
+
+func TestAlertmanagerRouting(t *testing.T) {
+	s, _, _ := newTestServer(t, Options{})
+	payload := strings.Replace(alertmanagerPayload, `"receiver": "all"`, `"receiver": "team-db"`, 1)
+
+	if rec := do(s, http.MethodPost, "/v1/alertmanager", "", payload); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unrouted receiver, got %d", rec.Code)
+	}
+
+	if rec := do(s, http.MethodPost, "/v1/alertmanager?route=all", "", payload); rec.Code == http.StatusNotFound {
+		t.Errorf("Expected route query parameter to be used, got %d", rec.Code)
+	}
+
+	s, _, _ = newTestServer(t, Options{AlertmanagerRoute: "all"})
+	if rec := do(s, http.MethodPost, "/v1/alertmanager", "", payload); rec.Code == http.StatusNotFound {
+		t.Errorf("Expected default route to be used, got %d", rec.Code)
+	}
+}
+
+func TestAlertmanagerRejectsOtherVersions(t *testing.T) {
+	s, _, _ := newTestServer(t, Options{})
+	payload := strings.Replace(alertmanagerPayload, `"version": "4"`, `"version": "3"`, 1)
+
+	if rec := do(s, http.MethodPost, "/v1/alertmanager", "", payload); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", rec.Code)
+	}
+}
+
+func TestAlertmanagerRejectsUnknownStatus(t *testing.T) {
+	s, ok, _ := newTestServer(t, Options{})
+
+	for _, payload := range []string{
+		strings.Replace(alertmanagerPayload, `"status": "firing",
+  "receiver"`, `"status": "pending",
+  "receiver"`, 1),
+		strings.Replace(alertmanagerPayload, `"status": "resolved"`, `"status": "silenced"`, 1),
+	} {
+		if rec := do(s, http.MethodPost, "/v1/alertmanager", "", payload); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400, got %d: %s", rec.Code, rec.Body)
+		}
+	}
+
+	if msgs := sent(ok); len(msgs) != 0 {
+		t.Errorf("Expected no messages, got %+v", msgs)
+	}
+}
+
+func TestAlertmanagerGroupsByGroupKey(t *testing.T) {
+	s, ok, _ := newTestServer(t, Options{})
+	s.manager.SetRoute("ok-only", "ok")
+	send := func(payload string) []*notify.Message {
+		t.Helper()
+		before := len(sent(ok))
+
+		if rec := do(s, http.MethodPost, "/v1/alertmanager?route=ok-only", "", payload); rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+		}
+
+		return sent(ok)[before:]
+	}
+
+	send(alertmanagerPayload)
+
+	// web-3 joins the group, web-2 was already reported as resolved
+	msgs := send(strings.Replace(alertmanagerPayload, `"fingerprint": "abc"
+    },`, `"fingerprint": "abc"
+    },
+    {
+      "status": "firing",
+      "labels": {"alertname": "HighCPU", "severity": "critical", "job": "node", "instance": "web-3"},
+      "annotations": {},
+      "startsAt": "2024-01-02T03:20:00Z",
+      "fingerprint": "ghi"
+    },`, 1))
+	if len(msgs) != 1 {
+		t.Fatalf("Expected the firing message only, got %+v", msgs)
+	}
+	if msgs[0].Title != "[FIRING:2] HighCPU" || !strings.HasSuffix(msgs[0].Text, "\n1 new since the last notification") || msgs[0].Metadata["new_alerts"] != 1 {
+		t.Errorf("Expected the new alert to be counted, got %+v", msgs[0])
+	}
+
+	// Once the group is resolved it is forgotten
+	resolved := strings.NewReplacer(`"status": "firing"`, `"status": "resolved"`).Replace(alertmanagerPayload)
+	if msgs := send(resolved); len(msgs) != 1 || msgs[0].Title != "[RESOLVED:1] HighCPU" {
+		t.Fatalf("Expected web-1 to be resolved, got %+v", msgs)
+	}
+	if msgs := send(alertmanagerPayload); len(msgs) != 2 || strings.Contains(msgs[0].Text, "new since") {
+		t.Errorf("Expected a new group, got %+v", msgs)
+	}
+}
+
+func TestAlertmanagerRetryAfterFailure(t *testing.T) {
+	s, _, _ := newTestServer(t, Options{})
+	// Both messages of the first delivery fail
+	down := errors.New("connection refused")
+	flaky := notifytest.New("flaky", notifytest.Fail(down, down))
+	s.manager.Register(flaky)
+	s.manager.SetRoute("flaky", "flaky")
+
+	if rec := do(s, http.MethodPost, "/v1/alertmanager?route=flaky", "", alertmanagerPayload); rec.Code != http.StatusBadGateway {
+		t.Fatalf("Expected 502, got %d: %s", rec.Code, rec.Body)
+	}
+
+	// Alertmanager retries the webhook once the provider recovers
+	before := len(sent(flaky))
+
+	if rec := do(s, http.MethodPost, "/v1/alertmanager?route=flaky", "", alertmanagerPayload); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	msgs := sent(flaky)[before:]
+	if len(msgs) != 2 || msgs[0].Title != "[FIRING:1] HighCPU" || msgs[1].Title != "[RESOLVED:1] HighCPU" {
+		t.Fatalf("Expected the retry to report the firing and resolved alerts again, got %+v", msgs)
+	}
+	if msgs[0].Metadata["new_alerts"] != 0 {
+		t.Errorf("Expected no new alerts in a group that was never notified, got %v", msgs[0].Metadata["new_alerts"])
+	}
+}
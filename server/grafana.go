@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/milano15662/notify"
+)
+
+// GrafanaPayload is the Grafana unified alerting webhook payload (version 1)
+type GrafanaPayload struct {
+	Receiver          string            `json:"receiver"`
+	Status            string            `json:"status"`
+	OrgID             int64             `json:"orgId"`
+	Alerts            []GrafanaAlert    `json:"alerts"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Title             string            `json:"title"`
+	State             string            `json:"state"`
+	Message           string            `json:"message"`
+}
+
+// GrafanaAlert is a single alert of a GrafanaPayload
+type GrafanaAlert struct {
+	Alert
+	SilenceURL   string             `json:"silenceURL"`
+	DashboardURL string             `json:"dashboardURL"`
+	PanelURL     string             `json:"panelURL"`
+	ImageURL     string             `json:"imageURL"`
+	Values       map[string]float64 `json:"values"`
+	ValueString  string             `json:"valueString"`
+}
+
+// GrafanaHandler receives Grafana unified alerting webhooks and delivers them
+// through Manager routing, resolving routes like AlertmanagerHandler
+type GrafanaHandler struct {
+	manager *notify.Manager
+
+	// DefaultRoute is used when no route matches the request
+	DefaultRoute string
+
+	// Timeout bounds the delivery of each webhook (defaults to 30s)
+	Timeout time.Duration
+}
+
+// NewGrafanaHandler creates a Grafana webhook handler for manager
+func NewGrafanaHandler(manager *notify.Manager, defaultRoute string) *GrafanaHandler {
+	return &GrafanaHandler{manager: manager, DefaultRoute: defaultRoute}
+}
+
+// ServeHTTP implements http.Handler
+func (h *GrafanaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var payload GrafanaPayload
+	if !decodeJSON(w, r, &payload, false) {
+		return
+	}
+
+	if payload.Version != "1" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported webhook version %q (want 1)", payload.Version))
+		return
+	}
+
+	am := &AlertmanagerHandler{manager: h.manager, DefaultRoute: h.DefaultRoute}
+	route, ok := am.route(r, payload.Receiver)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no route for receiver %q", payload.Receiver))
+		return
+	}
+
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	msgs, err := GrafanaMessages(&payload)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeResults(w, deliverRoute(ctx, h.manager, route, msgs))
+}
+
+// GrafanaMessages renders a Grafana payload like AlertmanagerMessages, adding
+// the alert values, images and dashboard, panel and silence links. When the
+// payload has the title or message rendered by Grafana's notification
+// template, they describe the whole notification, so the alerts of both
+// statuses go in one message with that title and text. It returns an error
+// for unknown statuses.
+func GrafanaMessages(payload *GrafanaPayload) ([]*notify.Message, error) {
+	am := &AlertmanagerPayload{
+		Version:           "4",
+		GroupKey:          payload.GroupKey,
+		TruncatedAlerts:   payload.TruncatedAlerts,
+		Status:            payload.Status,
+		Receiver:          payload.Receiver,
+		GroupLabels:       payload.GroupLabels,
+		CommonLabels:      payload.CommonLabels,
+		CommonAnnotations: payload.CommonAnnotations,
+		ExternalURL:       payload.ExternalURL,
+	}
+
+	byStatus := make(map[string][]GrafanaAlert)
+	for _, alert := range payload.Alerts {
+		am.Alerts = append(am.Alerts, alert.Alert)
+
+		status := alert.Status
+		if status == "" {
+			status = payload.Status
+		}
+		byStatus[status] = append(byStatus[status], alert)
+	}
+
+	msgs, err := AlertmanagerMessages(am)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, msg := range msgs {
+		msg.Metadata["org_id"] = payload.OrgID
+
+		alerts := byStatus[msg.Metadata["status"].(string)]
+		for i := range msg.Attachments {
+			if i >= len(alerts) || i >= maxAlertAttachments {
+				break
+			}
+			enrichGrafanaAttachment(&msg.Attachments[i], alerts[i])
+		}
+	}
+
+	if len(msgs) == 0 || (payload.Title == "" && payload.Message == "") {
+		return msgs, nil
+	}
+
+	msg := msgs[0]
+	for _, other := range msgs[1:] {
+		msg.Attachments = append(msg.Attachments, other.Attachments...)
+	}
+	msg.Metadata["status"] = payload.Status
+	if payload.Title != "" {
+		msg.Title = payload.Title
+	}
+	if payload.Message != "" {
+		msg.Text = payload.Message
+	}
+	return []*notify.Message{msg}, nil
+}
+
+// enrichGrafanaAttachment adds Grafana-specific alert details to an attachment
+func enrichGrafanaAttachment(att *notify.Attachment, alert GrafanaAlert) {
+	att.ImageURL = alert.ImageURL
+
+	if len(alert.Values) > 0 {
+		names := make([]string, 0, len(alert.Values))
+		for name := range alert.Values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		values := make([]string, len(names))
+		for i, name := range names {
+			values[i] = fmt.Sprintf("%s=%s", name, formatValue(alert.Values[name]))
+		}
+		att.Fields = append(att.Fields, notify.Field{Title: "Values", Value: strings.Join(values, ", "), Short: true})
+	}
+
+	links := []struct {
+		title string
+		url   string
+	}{
+		{"Dashboard", alert.DashboardURL},
+		{"Panel", alert.PanelURL},
+		{"Silence", alert.SilenceURL},
+	}
+	for _, link := range links {
+		if link.url != "" {
+			att.Fields = append(att.Fields, notify.Field{Title: link.title, Value: link.url})
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+const grafanaPayload = `{
+  "receiver": "all",
+  "status": "firing",
+  "orgId": 1,
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": {"alertname": "DiskFull", "grafana_folder": "Infra", "instance": "db-1"},
+      "annotations": {"summary": "Disk usage is 97%"},
+      "startsAt": "2024-01-02T03:04:05Z",
+      "endsAt": "0001-01-01T00:00:00Z",
+      "generatorURL": "http://grafana/alerting/grafana/abc/view",
+      "fingerprint": "57c6d9296de2ad39",
+      "silenceURL": "http://grafana/alerting/silence/new",
+      "dashboardURL": "http://grafana/d/abc",
+      "panelURL": "http://grafana/d/abc?viewPanel=2",
+      "imageURL": "http://grafana/render/abc.png",
+      "values": {"B": 97.2, "C": 1},
+      "valueString": "[ var='B' labels={} value=97.2 ]"
+    }
+  ],
+  "groupLabels": {"alertname": "DiskFull"},
+  "commonLabels": {"alertname": "DiskFull", "grafana_folder": "Infra", "instance": "db-1"},
+  "commonAnnotations": {"summary": "Disk usage is 97%"},
+  "externalURL": "http://grafana/",
+  "version": "1",
+  "groupKey": "{}:{alertname=\"DiskFull\"}",
+  "truncatedAlerts": 0,
+  "title": "[FIRING:1] DiskFull Infra (db-1)",
+  "state": "alerting",
+  "message": "**Firing**\n\nValue: B=97.2"
+}`
+
+func TestGrafanaWebhook(t *testing.T) {
+	s, ok, _ := newTestServer(t, Options{})
+
+	rec := do(s, http.MethodPost, "/v1/grafana", "", grafanaPayload)
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("Expected 502 because of the failing provider, got %d: %s", rec.Code, rec.Body)
+	}
+
+	msg := last(t, ok)
+	if msg.Title != "[FIRING:1] DiskFull Infra (db-1)" || msg.Text != "**Firing**\n\nValue: B=97.2" {
+		t.Errorf("Expected Grafana's rendered title and message, got %+v", msg)
+	}
+
+	att := msg.Attachments[0]
+	if att.ImageURL != "http://grafana/render/abc.png" {
+		t.Errorf("Expected alert image, got %q", att.ImageURL)
+	}
+
+	fields := make(map[string]string)
+	for _, field := range att.Fields {
+		fields[field.Title] = field.Value
+	}
+
+	if fields["Values"] != "B=97.2, C=1" || fields["Dashboard"] != "http://grafana/d/abc" || fields["Silence"] == "" {
+		t.Errorf("Unexpected fields: %v", fields)
+	}
+}
+
+func TestGrafanaMessagesWithoutTemplate(t *testing.T) {
+	var payload GrafanaPayload
+	if err := json.Unmarshal([]byte(grafanaPayload), &payload); err != nil {
+		t.Fatal(err)
+	}
+	payload.Title, payload.Message = "", ""
+
+	resolved := payload.Alerts[0]
+	resolved.Status, resolved.Fingerprint = StatusResolved, "0a1b"
+	payload.Alerts = append(payload.Alerts, resolved)
+
+	msgs, err := GrafanaMessages(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || msgs[0].Title != "[FIRING:1] DiskFull" || msgs[0].Text != "Disk usage is 97%" || msgs[1].Title != "[RESOLVED:1] DiskFull" {
+		t.Errorf("Expected per-status messages from the alert fields, got %+v", msgs)
+	}
+
+	payload.Title = "[FIRING:1, RESOLVED:1] DiskFull"
+	msgs, _ = GrafanaMessages(&payload)
+	if len(msgs) != 1 || msgs[0].Title != payload.Title || msgs[0].Text != "Disk usage is 97%" || len(msgs[0].Attachments) != 2 {
+		t.Errorf("Expected one message with the rendered title, got %+v", msgs)
+	}
+	if msgs[0].Attachments[1].Color != "good" || msgs[0].Attachments[1].ImageURL == "" {
+		t.Errorf("Expected the enriched resolved alert, got %+v", msgs[0].Attachments[1])
+	}
+}
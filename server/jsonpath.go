@@ -0,0 +1,202 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pathStep is one step of a parsed JSONPath: a member name, an array index,
+// or a wildcard over all members or elements
+type pathStep struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parsePath parses the JSONPath subset supported by webhook mappings:
+// $, .name, ['name'], ["name"], [0], [-1], .* and [*]
+func parsePath(path string) ([]pathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("JSONPath %q must start with $", path)
+	}
+
+	var steps []pathStep
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			if name == "" {
+				return nil, fmt.Errorf("JSONPath %q has an empty member name", path)
+			}
+			if name == "*" {
+				steps = append(steps, pathStep{wildcard: true})
+			} else {
+				steps = append(steps, pathStep{name: name})
+			}
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath %q has an unterminated [", path)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			switch {
+			case inner == "*":
+				steps = append(steps, pathStep{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				steps = append(steps, pathStep{name: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("JSONPath %q has an invalid subscript [%s]", path, inner)
+				}
+				steps = append(steps, pathStep{index: index, isIndex: true})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("JSONPath %q has unexpected %q", path, rest[0])
+		}
+	}
+
+	return steps, nil
+}
+
+// evalPath returns the values selected by steps in a decoded JSON document
+func evalPath(doc interface{}, steps []pathStep) []interface{} {
+	values := []interface{}{doc}
+	for _, step := range steps {
+		var next []interface{}
+		for _, value := range values {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				if step.wildcard {
+					keys := make([]string, 0, len(v))
+					for key := range v {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						next = append(next, v[key])
+					}
+				} else if item, ok := v[step.name]; ok && !step.isIndex {
+					next = append(next, item)
+				}
+			case []interface{}:
+				switch {
+				case step.wildcard:
+					next = append(next, v...)
+				case step.isIndex:
+					index := step.index
+					if index < 0 {
+						index += len(v)
+					}
+					if index >= 0 && index < len(v) {
+						next = append(next, v[index])
+					}
+				}
+			}
+		}
+		values = next
+	}
+
+	return values
+}
+
+// formatValue renders a JSON value as text
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+var placeholderPattern = regexp.MustCompile(`\{(\$(?:[.\[][^}]*)?)\}`)
+
+// template is a mapping expression: either a bare JSONPath ($, $.a.b or
+// $[0]) or text with {$.path} placeholders. Other text, even when it starts
+// with "$", is literal.
+type template struct {
+	text  string
+	paths map[string][]pathStep
+}
+
+// compileTemplate parses a mapping expression
+func compileTemplate(expr string) (*template, error) {
+	t := &template{text: expr, paths: make(map[string][]pathStep)}
+	if expr == "" {
+		return t, nil
+	}
+
+	if isPath(expr) {
+		steps, err := parsePath(expr)
+		if err != nil {
+			return nil, err
+		}
+		t.paths[expr] = steps
+		return t, nil
+	}
+
+	for _, match := range placeholderPattern.FindAllStringSubmatch(expr, -1) {
+		steps, err := parsePath(match[1])
+		if err != nil {
+			return nil, err
+		}
+		t.paths[match[1]] = steps
+	}
+	return t, nil
+}
+
+// isPath reports whether a mapping expression is a bare JSONPath
+func isPath(expr string) bool {
+	return expr == "$" || strings.HasPrefix(expr, "$.") || strings.HasPrefix(expr, "$[")
+}
+
+// render evaluates the expression against doc. Multiple selected values are
+// joined with ", ".
+func (t *template) render(doc interface{}) string {
+	if t == nil || t.text == "" {
+		return ""
+	}
+
+	lookup := func(path string) string {
+		values := evalPath(doc, t.paths[path])
+		parts := make([]string, 0, len(values))
+		for _, value := range values {
+			parts = append(parts, formatValue(value))
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	if isPath(t.text) {
+		return lookup(t.text)
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(t.text, func(placeholder string) string {
+		return lookup(placeholder[1 : len(placeholder)-1])
+	})
+}
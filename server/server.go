@@ -0,0 +1,367 @@
+// Package server exposes a notify.Manager over an HTTP API, so services
+// written in other languages can send through the same provider setup.
+//
+// Endpoints:
+//
+//	POST /v1/send        send a message to providers and/or a route
+//	POST /v1/broadcast   send a message to every provider
+//	GET  /v1/providers   list providers and routes
+//	POST /v1/alertmanager Prometheus Alertmanager webhook receiver
+//	POST /v1/grafana      Grafana unified alerting webhook receiver
+//	POST /v1/webhook/NAME generic JSON webhook, mapped by Options.Webhooks
+//	GET  /healthz        liveness check (no authentication)
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/milano15662/notify"
+)
+
+// DefaultMaxBodyBytes is the default request body limit
+const DefaultMaxBodyBytes = 1 << 20
+
+// Options configures a Server
+type Options struct {
+	// Token is the bearer token clients must present (empty disables authentication)
+	Token string
+
+	// MaxBodyBytes limits the size of request bodies (defaults to 1 MiB)
+	MaxBodyBytes int64
+
+	// Timeout bounds the delivery of each request (defaults to 30s)
+	Timeout time.Duration
+
+	// AlertmanagerRoute is the default route for Alertmanager webhooks
+	AlertmanagerRoute string
+
+	// GrafanaRoute is the default route for Grafana webhooks
+	GrafanaRoute string
+
+	// Webhooks maps generic webhook names to payload mappings
+	Webhooks map[string]Mapping
+}
+
+// Server is an http.Handler exposing a Manager
+type Server struct {
+	manager *notify.Manager
+	opts    Options
+	mux     *http.ServeMux
+}
+
+// SendRequest is the body of POST /v1/send: a message plus its targets
+type SendRequest struct {
+	notify.Message
+
+	// Provider is a single provider instance to send to
+	Provider string `json:"provider,omitempty"`
+
+	// Providers lists provider instances to send to
+	Providers []string `json:"providers,omitempty"`
+
+	// Route is a route to send to
+	Route string `json:"route,omitempty"`
+}
+
+// Result is the delivery outcome for one provider
+type Result struct {
+	Provider string `json:"provider"`
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Response is the body returned by the send and broadcast endpoints
+type Response struct {
+	OK      bool     `json:"ok"`
+	Results []Result `json:"results"`
+}
+
+// ProvidersResponse is the body returned by GET /v1/providers
+type ProvidersResponse struct {
+	Providers []string            `json:"providers"`
+	Routes    map[string][]string `json:"routes"`
+}
+
+// New creates a Server for manager. It returns an error if a webhook mapping is invalid.
+func New(manager *notify.Manager, opts Options) (*Server, error) {
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+
+	s := &Server{
+		manager: manager,
+		opts:    opts,
+		mux:     http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	s.Handle("/v1/send", http.HandlerFunc(s.handleSend))
+	s.Handle("/v1/broadcast", http.HandlerFunc(s.handleBroadcast))
+	s.Handle("/v1/providers", http.HandlerFunc(s.handleProviders))
+	alertmanager := NewAlertmanagerHandler(manager, opts.AlertmanagerRoute)
+	alertmanager.Timeout = opts.Timeout
+	s.Handle("/v1/alertmanager", alertmanager)
+	s.Handle("/v1/grafana", &GrafanaHandler{
+		manager:      manager,
+		DefaultRoute: opts.GrafanaRoute,
+		Timeout:      opts.Timeout,
+	})
+
+	webhooks, err := NewWebhookHandler(manager, "/v1/webhook/", opts.Webhooks)
+	if err != nil {
+		return nil, err
+	}
+	webhooks.Timeout = opts.Timeout
+	s.Handle("/v1/webhook/", webhooks)
+
+	return s, nil
+}
+
+// Handle registers an additional handler behind the server's authentication
+// and body size limit
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, s.protect(handler))
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// protect wraps handler with bearer token authentication and a body size limit
+func (s *Server) protect(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.opts.Token != "" && !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="notify"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, s.opts.MaxBodyBytes)
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// authorized reports whether r carries the configured bearer token
+func (s *Server) authorized(r *http.Request) bool {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.opts.Token)) == 1
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req SendRequest
+	if !decodeJSON(w, r, &req, true) || !validMessage(w, &req.Message) {
+		return
+	}
+
+	targets := append([]string(nil), req.Providers...)
+	if req.Provider != "" {
+		targets = append(targets, req.Provider)
+	}
+
+	if req.Route != "" {
+		providers, exists := s.manager.Route(req.Route)
+		if !exists {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("route %s not found", req.Route))
+			return
+		}
+		targets = append(targets, providers...)
+	}
+
+	if len(targets) == 0 {
+		writeError(w, http.StatusBadRequest, "provider, providers or route is required")
+		return
+	}
+
+	for _, name := range targets {
+		if _, exists := s.manager.Get(name); !exists {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("provider %s not found", name))
+			return
+		}
+	}
+	targets = unique(targets)
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.opts.Timeout)
+	defer cancel()
+
+	results := make([]Result, 0, len(targets))
+	for _, name := range targets {
+		results = append(results, newResult(name, s.manager.SendWithOptions(ctx, name, &req.Message)))
+	}
+
+	writeResults(w, results)
+}
+
+func (s *Server) handleBroadcast(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var msg notify.Message
+	if !decodeJSON(w, r, &msg, true) || !validMessage(w, &msg) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.opts.Timeout)
+	defer cancel()
+
+	results := make([]Result, 0)
+	for res := range s.manager.BroadcastAsyncWithOptions(ctx, &msg) {
+		results = append(results, newResult(res.Provider, res.Error))
+	}
+
+	writeResults(w, results)
+}
+
+func (s *Server) handleProviders(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	providers := s.manager.List()
+	sort.Strings(providers)
+
+	routes := make(map[string][]string)
+	for _, route := range s.manager.Routes() {
+		routes[route], _ = s.manager.Route(route)
+	}
+
+	writeJSON(w, http.StatusOK, ProvidersResponse{Providers: providers, Routes: routes})
+}
+
+// allowMethod rejects requests that don't use method
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed", r.Method))
+	return false
+}
+
+// decodeJSON decodes the request body into v, writing an error response on
+// failure. Strict decoding rejects unknown fields.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}, strict bool) bool {
+	dec := json.NewDecoder(r.Body)
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	return decode(w, dec, v)
+}
+
+// decodeDocument decodes an arbitrary JSON body, keeping numbers as
+// json.Number so large integer IDs keep their digits
+func decodeDocument(w http.ResponseWriter, r *http.Request, doc *interface{}) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	return decode(w, dec, doc)
+}
+
+// decode decodes the next value of dec into v, writing an error response on
+// failure
+func decode(w http.ResponseWriter, dec *json.Decoder, v interface{}) bool {
+	if err := dec.Decode(v); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit))
+			return false
+		}
+
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %v", err))
+		return false
+	}
+
+	return true
+}
+
+// validMessage rejects messages no provider can send, writing an error
+// response, so they fail with 400 instead of as delivery failures
+func validMessage(w http.ResponseWriter, msg *notify.Message) bool {
+	if strings.TrimSpace(msg.Text) == "" {
+		writeError(w, http.StatusBadRequest, "text is required")
+		return false
+	}
+	return true
+}
+
+// unique returns names without duplicates, keeping the first occurrence, so
+// a provider named directly and through a route gets the message once
+func unique(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	out := names[:0:0]
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// newResult converts a delivery error into a Result, without the secrets
+// that provider errors may contain
+func newResult(provider string, err error) Result {
+	result := Result{Provider: provider, OK: err == nil}
+	if err != nil {
+		result.Error = notify.Redact(err.Error())
+	}
+	return result
+}
+
+// delivered reports whether every delivery succeeded
+func delivered(results []Result) bool {
+	for _, result := range results {
+		if !result.OK {
+			return false
+		}
+	}
+	return true
+}
+
+// writeResults writes per-provider results, using 502 when any delivery failed
+func writeResults(w http.ResponseWriter, results []Result) {
+	sort.Slice(results, func(i, j int) bool { return results[i].Provider < results[j].Provider })
+
+	resp := Response{OK: delivered(results), Results: results}
+
+	status := http.StatusOK
+	if !resp.OK {
+		status = http.StatusBadGateway
+	}
+	writeJSON(w, status, resp)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
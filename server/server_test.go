@@ -0,0 +1,186 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/milano15662/notify"
+	"github.com/milano15662/notify/notifytest"
+)
+
+// sent returns every message n received, including failed deliveries
+func sent(n *notifytest.Notifier) []*notify.Message {
+	var msgs []*notify.Message
+	for _, d := range n.Recorder().Deliveries() {
+		msgs = append(msgs, d.Message)
+	}
+	return msgs
+}
+
+// last returns the latest message n received
+func last(t *testing.T, n *notifytest.Notifier) *notify.Message {
+	t.Helper()
+	msgs := sent(n)
+	if len(msgs) == 0 {
+		t.Fatalf("Expected a message for %s", n.Name())
+	}
+	return msgs[len(msgs)-1]
+}
+
+func newTestServer(t *testing.T, opts Options) (*Server, *notifytest.Notifier, *notifytest.Notifier) {
+	t.Helper()
+	ok := notifytest.New("ok")
+	broken := notifytest.New("broken", notifytest.FailAlways(errors.New(`Post "https://api.telegram.org/bot123456:AAHsecret/sendMessage": connection refused`)))
+
+	manager := notify.NewManager()
+	manager.Register(ok)
+	manager.Register(broken)
+	manager.SetRoute("all", "ok", "broken")
+
+	s, err := New(manager, opts)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	return s, ok, broken
+}
+
+func do(s http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSend(t *testing.T) {
+	s, ok, _ := newTestServer(t, Options{})
+
+	rec := do(s, http.MethodPost, "/v1/send", "", `{"provider":"ok","title":"Deploy","text":"v1.2.3 is live","priority":"low"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	msg := last(t, ok)
+	if msg.Title != "Deploy" || msg.Text != "v1.2.3 is live" || msg.Priority != notify.PriorityLow {
+		t.Errorf("Unexpected message: %+v", msg)
+	}
+}
+
+func TestSendRouteReportsPerProviderResults(t *testing.T) {
+	s, _, _ := newTestServer(t, Options{})
+
+	rec := do(s, http.MethodPost, "/v1/send", "", `{"route":"all","text":"hello"}`)
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("Expected 502, got %d", rec.Code)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.OK || len(resp.Results) != 2 || resp.Results[0].Provider != "broken" || resp.Results[1].OK != true {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+	if errText := resp.Results[0].Error; strings.Contains(errText, "AAHsecret") || !strings.Contains(errText, "connection refused") {
+		t.Errorf("Expected the error without the bot token, got %q", errText)
+	}
+}
+
+func TestSendDeduplicatesTargets(t *testing.T) {
+	s, ok, _ := newTestServer(t, Options{})
+
+	rec := do(s, http.MethodPost, "/v1/send", "", `{"provider":"ok","providers":["ok"],"route":"all","text":"once"}`)
+
+	var resp Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 2 {
+		t.Errorf("Expected one result per provider, got %+v", resp.Results)
+	}
+
+	if n := len(sent(ok)); n != 1 {
+		t.Errorf("Expected the provider to receive the message once, got %d", n)
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	s, ok, broken := newTestServer(t, Options{})
+
+	rec := do(s, http.MethodPost, "/v1/broadcast", "", `{"text":"everyone"}`)
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("Expected 502, got %d", rec.Code)
+	}
+
+	if last(t, ok).Text != "everyone" || last(t, broken).Text != "everyone" {
+		t.Error("Expected both providers to receive the broadcast")
+	}
+}
+
+func TestProviders(t *testing.T) {
+	s, _, _ := newTestServer(t, Options{})
+
+	rec := do(s, http.MethodGet, "/v1/providers", "", "")
+	var resp ProvidersResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.Providers) != 2 || resp.Providers[0] != "broken" || len(resp.Routes["all"]) != 2 {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	s, _, _ := newTestServer(t, Options{Token: "secret"})
+
+	if rec := do(s, http.MethodGet, "/v1/providers", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", rec.Code)
+	}
+
+	if rec := do(s, http.MethodGet, "/v1/providers", "wrong", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with wrong token, got %d", rec.Code)
+	}
+
+	if rec := do(s, http.MethodGet, "/v1/providers", "secret", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 with token, got %d", rec.Code)
+	}
+
+	if rec := do(s, http.MethodGet, "/healthz", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected unauthenticated health check, got %d", rec.Code)
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	s, _, _ := newTestServer(t, Options{MaxBodyBytes: 64})
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "/v1/send", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/v1/send", `{"text":"no target"}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/send", `{"provider":"missing","text":"x"}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/send", `{"route":"missing","text":"x"}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/send", `{"provider":"ok"}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/send", `{"provider":"ok","text":"  "}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/broadcast", `{"title":"no text"}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/send", `{"provider":"ok","txt":"typo"}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/send", `{"provider":"ok","text":"` + strings.Repeat("x", 100) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		if rec := do(s, tt.method, tt.path, "", tt.body); rec.Code != tt.status {
+			t.Errorf("%s %s %s: expected %d, got %d (%s)", tt.method, tt.path, tt.body, tt.status, rec.Code, rec.Body)
+		}
+	}
+}
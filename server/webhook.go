@@ -0,0 +1,305 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/milano15662/notify"
+	"gopkg.in/yaml.v3"
+)
+
+// Mapping describes how to turn an arbitrary JSON payload into a Message.
+//
+// Each expression is either a bare JSONPath such as $.build.status, or text
+// with {$.path} placeholders such as "Build {$.build.id} failed". Paths support
+// member access (.name, ['name']), array indexes ([0], [-1]) and wildcards
+// (.*, [*]); multiple matches are joined with ", ".
+type Mapping struct {
+	// Route is the route messages are delivered to (a route query parameter overrides it)
+	Route string `yaml:"route" json:"route"`
+
+	Title    string `yaml:"title" json:"title"`
+	Text     string `yaml:"text" json:"text"`
+	Priority string `yaml:"priority" json:"priority"`
+	Channel  string `yaml:"channel" json:"channel"`
+
+	// PriorityMap translates rendered priority values (e.g., critical: high)
+	PriorityMap map[string]string `yaml:"priority_map" json:"priority_map"`
+
+	// Color, ImageURL and Footer describe an attachment added to the message
+	Color    string `yaml:"color" json:"color"`
+	ImageURL string `yaml:"image_url" json:"image_url"`
+	Footer   string `yaml:"footer" json:"footer"`
+
+	// ColorMap translates rendered color values (e.g., failed: danger)
+	ColorMap map[string]string `yaml:"color_map" json:"color_map"`
+
+	// Fields are added to the attachment in order
+	Fields []FieldMapping `yaml:"fields" json:"fields"`
+
+	// FieldsFrom selects an object whose members are added as fields
+	FieldsFrom string `yaml:"fields_from" json:"fields_from"`
+}
+
+// FieldMapping maps a payload value to an attachment field
+type FieldMapping struct {
+	Title string `yaml:"title" json:"title"`
+	Value string `yaml:"value" json:"value"`
+	Short bool   `yaml:"short" json:"short"`
+}
+
+// LoadMappings reads webhook mappings keyed by name from a YAML or JSON file
+func LoadMappings(path string) (map[string]Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var mappings map[string]Mapping
+	if err := dec.Decode(&mappings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for name, mapping := range mappings {
+		if _, err := mapping.compile(); err != nil {
+			return nil, fmt.Errorf("%s: mapping %s: %w", path, name, err)
+		}
+	}
+
+	return mappings, nil
+}
+
+// compiledMapping is a Mapping with parsed expressions
+type compiledMapping struct {
+	mapping    Mapping
+	title      *template
+	text       *template
+	priority   *template
+	channel    *template
+	color      *template
+	imageURL   *template
+	footer     *template
+	fields     []*template
+	fieldsFrom []pathStep
+}
+
+// compile parses the mapping expressions
+func (m Mapping) compile() (*compiledMapping, error) {
+	if m.Text == "" {
+		return nil, fmt.Errorf("text is required")
+	}
+
+	c := &compiledMapping{mapping: m}
+	exprs := []struct {
+		name string
+		expr string
+		dst  **template
+	}{
+		{"title", m.Title, &c.title},
+		{"text", m.Text, &c.text},
+		{"priority", m.Priority, &c.priority},
+		{"channel", m.Channel, &c.channel},
+		{"color", m.Color, &c.color},
+		{"image_url", m.ImageURL, &c.imageURL},
+		{"footer", m.Footer, &c.footer},
+	}
+
+	for _, e := range exprs {
+		t, err := compileTemplate(e.expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.name, err)
+		}
+		*e.dst = t
+	}
+
+	for i, field := range m.Fields {
+		t, err := compileTemplate(field.Value)
+		if err != nil {
+			return nil, fmt.Errorf("fields[%d]: %w", i, err)
+		}
+		c.fields = append(c.fields, t)
+	}
+
+	if m.FieldsFrom != "" {
+		steps, err := parsePath(m.FieldsFrom)
+		if err != nil {
+			return nil, fmt.Errorf("fields_from: %w", err)
+		}
+		c.fieldsFrom = steps
+	}
+
+	return c, nil
+}
+
+// message renders a decoded payload as a Message
+func (c *compiledMapping) message(doc interface{}) *notify.Message {
+	msg := &notify.Message{
+		Title:   c.title.render(doc),
+		Text:    c.text.render(doc),
+		Channel: c.channel.render(doc),
+	}
+
+	priority := c.priority.render(doc)
+	if mapped, ok := lookupFold(c.mapping.PriorityMap, priority); ok {
+		priority = mapped
+	}
+	switch strings.ToLower(priority) {
+	case notify.PriorityHigh, notify.PriorityNormal, notify.PriorityLow:
+		msg.Priority = strings.ToLower(priority)
+	}
+
+	color := c.color.render(doc)
+	if mapped, ok := lookupFold(c.mapping.ColorMap, color); ok {
+		color = mapped
+	}
+
+	att := notify.Attachment{
+		Color:    color,
+		ImageURL: c.imageURL.render(doc),
+		Footer:   c.footer.render(doc),
+	}
+
+	for i, field := range c.mapping.Fields {
+		if value := c.fields[i].render(doc); value != "" {
+			att.Fields = append(att.Fields, notify.Field{Title: field.Title, Value: value, Short: field.Short})
+		}
+	}
+
+	if c.fieldsFrom != nil {
+		for _, value := range evalPath(doc, c.fieldsFrom) {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			keys := make([]string, 0, len(object))
+			for key := range object {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				text := formatValue(object[key])
+				att.Fields = append(att.Fields, notify.Field{Title: key, Value: text, Short: len(text) <= 40})
+			}
+		}
+	}
+
+	if att.Color != "" || att.ImageURL != "" || att.Footer != "" || len(att.Fields) > 0 {
+		msg.Attachments = []notify.Attachment{att}
+	}
+
+	return msg
+}
+
+// lookupFold looks up key in m ignoring case
+func lookupFold(m map[string]string, key string) (string, bool) {
+	if value, ok := m[key]; ok {
+		return value, true
+	}
+	for k, value := range m {
+		if strings.EqualFold(k, key) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// WebhookHandler bridges arbitrary JSON webhooks to a Manager using named
+// mappings. It serves POST <prefix><name>, where name selects the mapping.
+type WebhookHandler struct {
+	manager  *notify.Manager
+	prefix   string
+	mappings map[string]*compiledMapping
+
+	// Timeout bounds the delivery of each webhook (defaults to 30s)
+	Timeout time.Duration
+}
+
+// NewWebhookHandler creates a handler for requests under prefix (e.g., /v1/webhook/)
+func NewWebhookHandler(manager *notify.Manager, prefix string, mappings map[string]Mapping) (*WebhookHandler, error) {
+	h := &WebhookHandler{
+		manager:  manager,
+		prefix:   prefix,
+		mappings: make(map[string]*compiledMapping, len(mappings)),
+	}
+
+	for name, mapping := range mappings {
+		compiled, err := mapping.compile()
+		if err != nil {
+			return nil, fmt.Errorf("mapping %s: %w", name, err)
+		}
+		h.mappings[name] = compiled
+	}
+
+	return h, nil
+}
+
+// ServeHTTP implements http.Handler
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, h.prefix)
+	mapping, exists := h.mappings[name]
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("webhook mapping %q not found", name))
+		return
+	}
+
+	var doc interface{}
+	if !decodeDocument(w, r, &doc) {
+		return
+	}
+
+	route := r.URL.Query().Get("route")
+	if route == "" {
+		route = mapping.mapping.Route
+	}
+	if _, exists := h.manager.Route(route); !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("route %q not found", route))
+		return
+	}
+
+	msg := mapping.message(doc)
+	if msg.Text == "" {
+		writeError(w, http.StatusUnprocessableEntity, "mapped message text is empty")
+		return
+	}
+
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	writeResults(w, deliverRoute(ctx, h.manager, route, []*notify.Message{msg}))
+}
+
+// MappingMessage renders a JSON payload with mapping, for testing mappings
+func MappingMessage(mapping Mapping, payload []byte) (*notify.Message, error) {
+	compiled, err := mapping.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return compiled.message(doc), nil
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/milano15662/notify"
+)
+
+const buildPayload = `{
+  "repository": {"name": "api", "url": "https://git/api"},
+  "build": {"id": 42, "status": "failed", "duration": 12.5, "stages": [{"name": "test"}, {"name": "deploy"}]},
+  "labels": {"branch": "main", "author": "sam"}
+}`
+
+func TestParsePath(t *testing.T) {
+	valid := []string{"$", "$.a", "$.a.b", "$['a b']", `$["a"][0]`, "$.a[*].b", "$.*", "$.a[-1]"}
+	for _, path := range valid {
+		if _, err := parsePath(path); err != nil {
+			t.Errorf("Expected %q to parse, got %v", path, err)
+		}
+	}
+
+	invalid := []string{"a.b", "$.", "$[", "$[x]", "$a"}
+	for _, path := range invalid {
+		if _, err := parsePath(path); err == nil {
+			t.Errorf("Expected %q to be rejected", path)
+		}
+	}
+}
+
+func TestMappingMessage(t *testing.T) {
+	mapping := Mapping{
+		Title:       "Build #{$.build.id} of {$.repository.name}",
+		Text:        "Build {$.build.status} after {$.build.duration}s (stages: {$.build.stages[*].name})",
+		Priority:    "$.build.status",
+		PriorityMap: map[string]string{"failed": "high", "passed": "low"},
+		Color:       "$.build.status",
+		ColorMap:    map[string]string{"FAILED": "danger"},
+		Fields: []FieldMapping{
+			{Title: "Repository", Value: "$.repository.url"},
+			{Title: "Missing", Value: "$.nope"},
+		},
+		FieldsFrom: "$.labels",
+	}
+
+	msg, err := MappingMessage(mapping, []byte(buildPayload))
+	if err != nil {
+		t.Fatalf("Failed to map payload: %v", err)
+	}
+
+	if msg.Title != "Build #42 of api" {
+		t.Errorf("Unexpected title %q", msg.Title)
+	}
+
+	if msg.Text != "Build failed after 12.5s (stages: test, deploy)" {
+		t.Errorf("Unexpected text %q", msg.Text)
+	}
+
+	if msg.Priority != notify.PriorityHigh {
+		t.Errorf("Expected high priority, got %q", msg.Priority)
+	}
+
+	att := msg.Attachments[0]
+	if att.Color != "danger" {
+		t.Errorf("Expected mapped color 'danger', got %q", att.Color)
+	}
+
+	if len(att.Fields) != 3 || att.Fields[0].Value != "https://git/api" || att.Fields[1].Title != "author" || att.Fields[2].Value != "main" {
+		t.Errorf("Unexpected fields: %+v", att.Fields)
+	}
+}
+
+func TestMappingLiteralsAndLargeNumbers(t *testing.T) {
+	mapping := Mapping{
+		Title: "$5 off for {$}",
+		Text:  "Order {$.id} costs {$.total}",
+	}
+
+	msg, err := MappingMessage(mapping, []byte(`{"id": 12345678901234567890, "total": 1e3}`))
+	if err != nil {
+		t.Fatalf("Failed to map payload: %v", err)
+	}
+
+	if msg.Title != `$5 off for {"id":12345678901234567890,"total":1e3}` {
+		t.Errorf("Unexpected title %q", msg.Title)
+	}
+
+	if msg.Text != "Order 12345678901234567890 costs 1e3" {
+		t.Errorf("Unexpected text %q", msg.Text)
+	}
+}
+
+func TestWebhookEndpoint(t *testing.T) {
+	s, ok, _ := newTestServer(t, Options{
+		Webhooks: map[string]Mapping{
+			"ci": {Route: "all", Title: "$.repository.name", Text: "$.build.status"},
+		},
+	})
+
+	rec := do(s, http.MethodPost, "/v1/webhook/ci", "", buildPayload)
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("Expected 502 because of the failing provider, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if msg := last(t, ok); msg.Title != "api" || msg.Text != "failed" {
+		t.Errorf("Unexpected message: %+v", msg)
+	}
+
+	if rec := do(s, http.MethodPost, "/v1/webhook/unknown", "", buildPayload); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown mapping, got %d", rec.Code)
+	}
+
+	if rec := do(s, http.MethodPost, "/v1/webhook/ci", "", `{"other": true}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for empty text, got %d", rec.Code)
+	}
+}
+
+func TestLoadMappings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.yaml")
+	content := `
+ci:
+  route: builds
+  title: "Build #{$.build.id}"
+  text: $.build.status
+  priority_map:
+    failed: high
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mappings, err := LoadMappings(path)
+	if err != nil {
+		t.Fatalf("Failed to load mappings: %v", err)
+	}
+
+	if mappings["ci"].Route != "builds" || mappings["ci"].PriorityMap["failed"] != "high" {
+		t.Errorf("Unexpected mappings: %+v", mappings)
+	}
+
+	if err := os.WriteFile(path, []byte("ci:\n  txt: $.x\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadMappings(path); err == nil {
+		t.Error("Expected error for unknown mapping key")
+	}
+}
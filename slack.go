@@ -2,25 +2,45 @@ package notify
 
 import (
 	"context"
-	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/milano15662/notify/richtext"
 	"github.com/slack-go/slack"
 )
 
+func init() {
+	RegisterProvider("slack", newSlackProvider)
+	RegisterScheme("slack", parseSlackURL)
+}
+
 // SlackNotifier sends notifications via Slack API
 type SlackNotifier struct {
+	name           string
 	client         *slack.Client
+	token          string
+	apiURL         string
+	webhookURL     string
 	defaultChannel string
 	username       string
 	iconEmoji      string
+	httpClient     *http.Client
 }
 
 // SlackConfig holds configuration for Slack notifications
 type SlackConfig struct {
+	// Name is the instance name reported by Name (optional, defaults to "slack")
+	Name string
+
 	// Token is the Slack Bot or User OAuth token
 	Token string
 
-	// DefaultChannel is the default channel to send messages to (e.g., #general or @username)
+	// DefaultChannel is the default channel to send messages to. Messages
+	// accept a channel ID (e.g., C0123456), a name (#general) or a user
+	// (@username), but files can only be shared to a channel ID.
 	DefaultChannel string
 
 	// Username is the bot username (optional)
@@ -31,6 +51,13 @@ type SlackConfig struct {
 
 	// WebhookURL for incoming webhooks (alternative to Token)
 	WebhookURL string
+
+	// APIURL is the Web API base URL, e.g. a test server (optional, defaults
+	// to https://slack.com/api/)
+	APIURL string
+
+	// HTTPClient allows custom HTTP client (optional)
+	HTTPClient *http.Client
 }
 
 // NewSlackNotifier creates a new Slack notifier
@@ -42,25 +69,66 @@ func NewSlackNotifier(config SlackConfig) (*SlackNotifier, error) {
 		}
 	}
 
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout: 30 * time.Second,
+		}
+	}
+
+	apiURL := slack.APIURL
+	if config.APIURL != "" {
+		apiURL = strings.TrimRight(config.APIURL, "/") + "/"
+	}
+
 	var client *slack.Client
 	if config.Token != "" {
-		client = slack.New(config.Token)
+		client = slack.New(config.Token, slack.OptionHTTPClient(httpClient), slack.OptionAPIURL(apiURL))
 	} else {
-		// For webhook, we'll handle it differently in Send methods
+		// Without a token, messages are posted to the incoming webhook
 		client = nil
 	}
 
+	name := config.Name
+	if name == "" {
+		name = "slack"
+	}
+
 	return &SlackNotifier{
+		name:           name,
 		client:         client,
+		token:          config.Token,
+		apiURL:         apiURL,
+		webhookURL:     config.WebhookURL,
 		defaultChannel: config.DefaultChannel,
 		username:       config.Username,
 		iconEmoji:      config.IconEmoji,
+		httpClient:     httpClient,
 	}, nil
 }
 
+// newSlackProvider creates a Slack notifier from raw configuration options
+func newSlackProvider(raw map[string]interface{}) (Notifier, error) {
+	opts := newOptions(raw)
+	config := SlackConfig{
+		Name:           opts.String("name"),
+		Token:          opts.String("token"),
+		WebhookURL:     opts.String("webhook_url"),
+		DefaultChannel: opts.String("channel"),
+		Username:       opts.String("username"),
+		IconEmoji:      opts.String("icon_emoji"),
+		APIURL:         opts.String("api_url"),
+	}
+	if err := opts.Finish(); err != nil {
+		return nil, err
+	}
+
+	return NewSlackNotifier(config)
+}
+
 // Name returns the name of the provider
 func (s *SlackNotifier) Name() string {
-	return "slack"
+	return s.name
 }
 
 // Send sends a simple text message
@@ -73,14 +141,7 @@ func (s *SlackNotifier) Send(ctx context.Context, message string) error {
 
 // SendWithOptions sends a message with additional options
 func (s *SlackNotifier) SendWithOptions(ctx context.Context, msg *Message) error {
-	if s.client == nil {
-		return &NotificationError{
-			Provider: "slack",
-			Message:  "slack client not initialized (webhook support not yet implemented for SendWithOptions)",
-		}
-	}
-
-	if msg.Text == "" {
+	if !msg.hasText() {
 		return &NotificationError{
 			Provider: "slack",
 			Message:  "message text is required",
@@ -92,6 +153,10 @@ func (s *SlackNotifier) SendWithOptions(ctx context.Context, msg *Message) error
 		channel = s.defaultChannel
 	}
 
+	if s.client == nil {
+		return s.sendWebhook(ctx, channel, msg)
+	}
+
 	if channel == "" {
 		return &NotificationError{
 			Provider: "slack",
@@ -99,46 +164,73 @@ func (s *SlackNotifier) SendWithOptions(ctx context.Context, msg *Message) error
 		}
 	}
 
-	// Build message options
-	options := []slack.MsgOption{
-		slack.MsgOptionText(msg.Text, false),
+	options := append(s.messageOptions(msg), s.identityOptions()...)
+
+	_, _, err := s.client.PostMessageContext(ctx, channel, options...)
+	if err != nil {
+		return &NotificationError{
+			Provider: "slack",
+			Message:  "failed to send message",
+			Err:      err,
+		}
+	}
+
+	return nil
+}
+
+// messageOptions returns the text, attachments and blocks of msg
+func (s *SlackNotifier) messageOptions(msg *Message) []slack.MsgOption {
+	var options []slack.MsgOption
+	if msg.Title == "" {
+		// Titled messages are sent as blocks only
+		options = append(options, slack.MsgOptionText(slackText(msg), false))
 	}
 
+	// Add attachments if present
+	if len(msg.Attachments) > 0 {
+		options = append(options, slack.MsgOptionAttachments(s.convertAttachments(msg.Attachments)...))
+	}
+
+	// Add title and buttons as blocks if present
+	if blocks := messageBlocks(msg); len(blocks) > 0 {
+		options = append(options, slack.MsgOptionBlocks(blocks...))
+	}
+	return options
+}
+
+// identityOptions returns the configured username and icon
+func (s *SlackNotifier) identityOptions() []slack.MsgOption {
+	var options []slack.MsgOption
 	if s.username != "" {
 		options = append(options, slack.MsgOptionUsername(s.username))
 	}
-
 	if s.iconEmoji != "" {
 		options = append(options, slack.MsgOptionIconEmoji(s.iconEmoji))
 	}
+	return options
+}
+
+// sendWebhook posts a message to the configured incoming webhook
+func (s *SlackNotifier) sendWebhook(ctx context.Context, channel string, msg *Message) error {
+	webhookMsg := &slack.WebhookMessage{
+		Username:  s.username,
+		IconEmoji: s.iconEmoji,
+		Channel:   channel,
+		Text:      slackText(msg),
+	}
 
-	// Add attachments if present
 	if len(msg.Attachments) > 0 {
-		slackAttachments := s.convertAttachments(msg.Attachments)
-		options = append(options, slack.MsgOptionAttachments(slackAttachments...))
+		webhookMsg.Attachments = s.convertAttachments(msg.Attachments)
 	}
 
-	// Add title as a block if present
-	if msg.Title != "" {
-		blocks := []slack.Block{
-			slack.NewHeaderBlock(
-				slack.NewTextBlockObject("plain_text", msg.Title, false, false),
-			),
-			slack.NewSectionBlock(
-				slack.NewTextBlockObject("mrkdwn", msg.Text, false, false),
-				nil, nil,
-			),
-		}
-		options = append(options, slack.MsgOptionBlocks(blocks...))
-		// Remove text option when using blocks
-		options = options[1:]
+	if blocks := messageBlocks(msg); len(blocks) > 0 {
+		webhookMsg.Blocks = &slack.Blocks{BlockSet: blocks}
 	}
 
-	_, _, err := s.client.PostMessageContext(ctx, channel, options...)
-	if err != nil {
+	if err := slack.PostWebhookCustomHTTPContext(ctx, s.webhookURL, s.httpClient, webhookMsg); err != nil {
 		return &NotificationError{
 			Provider: "slack",
-			Message:  "failed to send message",
+			Message:  "failed to post webhook message",
 			Err:      err,
 		}
 	}
@@ -146,6 +238,100 @@ func (s *SlackNotifier) SendWithOptions(ctx context.Context, msg *Message) error
 	return nil
 }
 
+// slackText returns the text of msg in Slack mrkdwn
+func slackText(msg *Message) string {
+	if msg.Rich.Empty() {
+		return msg.Text
+	}
+	return msg.Rich.Render(slackStyle)
+}
+
+// slackEscaper escapes the characters Slack treats as control sequences
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackStyle renders rich text as Slack mrkdwn
+var slackStyle = richtext.Style{
+	Escape: slackEscaper.Replace,
+	Bold:   func(inner string) string { return "*" + inner + "*" },
+	Italic: func(inner string) string { return "_" + inner + "_" },
+	Code:   func(code string) string { return "`" + slackEscaper.Replace(code) + "`" },
+	Pre: func(language, code string) string {
+		return "```\n" + slackEscaper.Replace(code) + "\n```"
+	},
+	Link: func(url, inner string) string {
+		if inner == "" {
+			return "<" + url + ">"
+		}
+		return "<" + url + "|" + inner + ">"
+	},
+	Mention: func(id, name string) string {
+		if id == "" {
+			return "@" + slackEscaper.Replace(name)
+		}
+		return "<@" + id + ">"
+	},
+	Quote:  func(inner string) string { return richtext.PrefixLines(inner, "> ") },
+	Bullet: richtext.Bullets,
+}
+
+// messageBlocks renders a message with a title or actions as blocks: the
+// title as a header, the text as a section and the actions as buttons. It
+// returns nil for messages that are sent as plain text.
+func messageBlocks(msg *Message) []slack.Block {
+	if msg.Title == "" && len(msg.Actions) == 0 {
+		return nil
+	}
+
+	var blocks []slack.Block
+	if msg.Title != "" {
+		blocks = append(blocks, slack.NewHeaderBlock(
+			slack.NewTextBlockObject("plain_text", msg.Title, false, false),
+		))
+	}
+	blocks = append(blocks, slack.NewSectionBlock(
+		slack.NewTextBlockObject("mrkdwn", slackText(msg), false, false),
+		nil, nil,
+	))
+	return append(blocks, actionBlocks(msg.Actions)...)
+}
+
+// slackMaxActions is the most elements Slack accepts in an actions block
+const slackMaxActions = 25
+
+// slackMaxButtonLabel is the length limit of button labels
+const slackMaxButtonLabel = 75
+
+// actionBlocks renders actions as buttons in actions blocks
+func actionBlocks(actions []Action) []slack.Block {
+	var blocks []slack.Block
+	for len(actions) > 0 {
+		n := len(actions)
+		if n > slackMaxActions {
+			n = slackMaxActions
+		}
+
+		elements := make([]slack.BlockElement, n)
+		for i, action := range actions[:n] {
+			label := action.Label
+			if runes := []rune(label); len(runes) > slackMaxButtonLabel {
+				label = string(runes[:slackMaxButtonLabel-1]) + "…"
+			}
+
+			button := slack.NewButtonBlockElement(action.ID, action.Value,
+				slack.NewTextBlockObject("plain_text", label, true, false))
+			button.URL = action.URL
+			if action.Style == ActionPrimary || action.Style == ActionDanger {
+				button.Style = slack.Style(action.Style)
+			}
+			elements[i] = button
+		}
+
+		blocks = append(blocks, slack.NewActionBlock("", elements...))
+		actions = actions[n:]
+	}
+	return blocks
+}
+
 // SendRichMessage sends a message with blocks for rich formatting
 func (s *SlackNotifier) SendRichMessage(ctx context.Context, channel string, blocks []slack.Block) error {
 	if s.client == nil {
@@ -207,39 +393,36 @@ func (s *SlackNotifier) convertAttachments(attachments []Attachment) []slack.Att
 	return slackAttachments
 }
 
+// slackRetryable reports whether a Slack API error code is a temporary
+// failure of Slack rather than a rejected request
+func slackRetryable(code string) bool {
+	switch code {
+	case "ratelimited", "internal_error", "fatal_error", "service_unavailable", "request_timeout":
+		return true
+	}
+	return false
+}
+
 // GetClient returns the underlying Slack client for advanced usage
 func (s *SlackNotifier) GetClient() *slack.Client {
 	return s.client
 }
 
-// SendFile uploads a file to Slack
+// SendFile uploads a file to Slack and shares it to channel, a channel ID
+// (defaults to the notifier's channel)
 func (s *SlackNotifier) SendFile(ctx context.Context, channel, filePath, title, comment string) error {
-	if s.client == nil {
-		return &NotificationError{
-			Provider: "slack",
-			Message:  "slack client not initialized",
-		}
-	}
-
-	if channel == "" {
-		channel = s.defaultChannel
-	}
-
-	params := slack.FileUploadParameters{
-		File:           filePath,
-		Channels:       []string{channel},
-		Title:          title,
-		InitialComment: comment,
-	}
-
-	_, err := s.client.UploadFileContext(ctx, params)
+	file, err := os.Open(filePath)
 	if err != nil {
 		return &NotificationError{
 			Provider: "slack",
-			Message:  fmt.Sprintf("failed to upload file: %v", err),
+			Message:  "failed to open file",
 			Err:      err,
 		}
 	}
+	defer file.Close()
 
-	return nil
+	return s.SendFiles(ctx, []File{{Name: filepath.Base(filePath), Reader: file, Title: title}}, FileOptions{
+		Channel: channel,
+		Comment: comment,
+	})
 }
@@ -0,0 +1,213 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// SendFiles uploads files with the files.getUploadURLExternal and
+// files.completeUploadExternal flow and shares them in a single message,
+// with opts.Comment as its text and in the thread opts.ThreadID if set.
+// The upload flow only accepts channel IDs such as C0123456, so channel
+// names are rejected. Without a channel, the files are uploaded but not
+// shared.
+func (s *SlackNotifier) SendFiles(ctx context.Context, files []File, opts FileOptions) error {
+	if s.client == nil {
+		return &NotificationError{
+			Provider: "slack",
+			Message:  "slack client not initialized",
+		}
+	}
+	if len(files) == 0 {
+		return &NotificationError{
+			Provider: "slack",
+			Message:  "no files to upload",
+		}
+	}
+
+	channel := opts.Channel
+	if channel == "" {
+		channel = s.defaultChannel
+	}
+	if channel != "" && !slackConversationID(channel) {
+		return &NotificationError{
+			Provider: "slack",
+			Message:  fmt.Sprintf("files can only be shared to a channel ID such as C0123456, not %q", channel),
+		}
+	}
+
+	summaries := make([]slack.FileSummary, len(files))
+	for i, file := range files {
+		id, err := s.uploadFile(ctx, file)
+		if err != nil {
+			return &NotificationError{
+				Provider: "slack",
+				Message:  fmt.Sprintf("failed to upload file %s", file.name()),
+				Err:      err,
+			}
+		}
+		summaries[i] = slack.FileSummary{ID: id, Title: file.Title}
+	}
+
+	data, err := json.Marshal(summaries)
+	if err != nil {
+		return &NotificationError{
+			Provider: "slack",
+			Message:  "failed to marshal files",
+			Err:      err,
+		}
+	}
+
+	values := url.Values{"files": {string(data)}}
+	if channel != "" {
+		values.Set("channel_id", channel)
+	}
+	if opts.Comment != "" {
+		values.Set("initial_comment", opts.Comment)
+	}
+	if opts.ThreadID != "" {
+		values.Set("thread_ts", opts.ThreadID)
+	}
+
+	if err := s.apiCall(ctx, "files.completeUploadExternal", values, nil); err != nil {
+		return &NotificationError{
+			Provider: "slack",
+			Message:  "failed to share files",
+			Err:      err,
+		}
+	}
+
+	return nil
+}
+
+// slackConversationID reports whether channel is the ID of a public or
+// private channel (C…, G…) or a direct message (D…)
+func slackConversationID(channel string) bool {
+	if len(channel) < 2 || !strings.ContainsRune("CGD", rune(channel[0])) {
+		return false
+	}
+	for _, r := range channel[1:] {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// uploadFile gets an upload URL for a file, uploads the content to it and
+// returns the file ID
+func (s *SlackNotifier) uploadFile(ctx context.Context, file File) (string, error) {
+	if file.Reader == nil {
+		return "", fmt.Errorf("file has no reader")
+	}
+
+	content, size, err := file.sized()
+	if err != nil {
+		return "", err
+	}
+	if size == 0 {
+		return "", fmt.Errorf("file is empty")
+	}
+
+	var upload struct {
+		UploadURL string `json:"upload_url"`
+		FileID    string `json:"file_id"`
+	}
+	values := url.Values{"filename": {file.name()}, "length": {strconv.FormatInt(size, 10)}}
+	if err := s.apiCall(ctx, "files.getUploadURLExternal", values, &upload); err != nil {
+		return "", err
+	}
+
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		part, err := form.CreateFormFile("file", file.name())
+		if err == nil {
+			_, err = io.Copy(part, content)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+	defer body.Close()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", upload.UploadURL, body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if err := slackStatusError(resp); err != nil {
+		return "", err
+	}
+	return upload.FileID, nil
+}
+
+// apiCall posts a form to a Web API method and decodes the response into
+// result unless it is nil. Errors match those of the slack package.
+func (s *SlackNotifier) apiCall(ctx context.Context, method string, values url.Values, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", s.apiURL+method, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := slackStatusError(resp); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var response slack.SlackResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return err
+	}
+	if err := response.Err(); err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
+
+// slackStatusError returns the error of a failed HTTP response
+func slackStatusError(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusTooManyRequests:
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return &slack.RateLimitedError{RetryAfter: time.Duration(retryAfter) * time.Second}
+	}
+	return slack.StatusCodeError{Code: resp.StatusCode, Status: resp.Status}
+}
@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/slack-go/slack"
+)
+
+// maxInteractionBytes limits the size of interaction requests
+const maxInteractionBytes = 1 << 20
+
+// InteractivityHandler returns the HTTP handler of the app's interactivity
+// request URL. It verifies requests with the app's signing secret and
+// dispatches button clicks to interactions, which can reply and update
+// messages through the notifier's token. Slack expects a response within 3
+// seconds, so slow handlers should continue in the background. Without a
+// signing secret, every request is rejected.
+func (s *SlackNotifier) InteractivityHandler(signingSecret string, interactions *Interactions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if signingSecret == "" {
+			// anyone could sign requests with an empty secret
+			http.Error(w, "signing secret not configured", http.StatusInternalServerError)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxInteractionBytes))
+		if err != nil {
+			http.Error(w, "failed to read request", http.StatusBadRequest)
+			return
+		}
+
+		verifier, err := slack.NewSecretsVerifier(r.Header, signingSecret)
+		if err == nil {
+			verifier.Write(body)
+			err = verifier.Ensure()
+		}
+		if err != nil {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		form, err := url.ParseQuery(string(body))
+		var callback slack.InteractionCallback
+		if err == nil {
+			err = json.Unmarshal([]byte(form.Get("payload")), &callback)
+		}
+		if err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+
+		if callback.Type != slack.InteractionTypeBlockActions {
+			return
+		}
+
+		ref := MessageRef{Channel: callback.Container.ChannelID, ID: callback.Container.MessageTs}
+		if ref.Channel == "" {
+			ref.Channel = callback.Channel.ID
+		}
+		for _, action := range callback.ActionCallback.BlockActions {
+			in := &Interaction{
+				Provider:   s.name,
+				User:       User{ID: callback.User.ID, Name: callback.User.Name},
+				ActionID:   action.ActionID,
+				Value:      action.Value,
+				MessageRef: ref,
+				responder:  s,
+			}
+			if err := interactions.Dispatch(r.Context(), in); err != nil {
+				http.Error(w, "interaction failed", http.StatusInternalServerError)
+				return
+			}
+		}
+	})
+}
+
+// reply posts msg in the thread of a message
+func (s *SlackNotifier) reply(ctx context.Context, ref MessageRef, msg *Message) error {
+	if s.client == nil {
+		return &NotificationError{
+			Provider: "slack",
+			Message:  "slack client not initialized",
+		}
+	}
+
+	options := append(s.messageOptions(msg), s.identityOptions()...)
+	options = append(options, slack.MsgOptionTS(ref.ID))
+
+	if _, _, err := s.client.PostMessageContext(ctx, ref.Channel, options...); err != nil {
+		return &NotificationError{
+			Provider: "slack",
+			Message:  "failed to send reply",
+			Err:      err,
+		}
+	}
+	return nil
+}
+
+// update replaces a message by msg
+func (s *SlackNotifier) update(ctx context.Context, ref MessageRef, msg *Message) error {
+	if s.client == nil {
+		return &NotificationError{
+			Provider: "slack",
+			Message:  "slack client not initialized",
+		}
+	}
+
+	options := s.messageOptions(msg)
+	if messageBlocks(msg) == nil {
+		// Slack keeps the previous blocks unless they are replaced
+		options = append(options, slack.MsgOptionBlocks(slack.NewSectionBlock(
+			slack.NewTextBlockObject("mrkdwn", slackText(msg), false, false),
+			nil, nil,
+		)))
+	}
+
+	if _, _, _, err := s.client.UpdateMessageContext(ctx, ref.Channel, ref.ID, options...); err != nil {
+		return &NotificationError{
+			Provider: "slack",
+			Message:  "failed to update message",
+			Err:      err,
+		}
+	}
+	return nil
+}
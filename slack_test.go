@@ -0,0 +1,502 @@
+package notify_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milano15662/notify"
+	"github.com/milano15662/notify/notifytest"
+	"github.com/milano15662/notify/richtext"
+	"github.com/slack-go/slack"
+)
+
+func newTestSlack(t *testing.T, srv *notifytest.SlackServer, config notify.SlackConfig) *notify.SlackNotifier {
+	t.Helper()
+	if config.Token == "" && config.WebhookURL == "" {
+		config.Token = "xoxb-test"
+		config.APIURL = srv.APIURL()
+	}
+
+	notifier, err := notify.NewSlackNotifier(config)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	return notifier
+}
+
+func TestSlackSendWithOptions(t *testing.T) {
+	srv := notifytest.NewSlackServer()
+	srv.Token = "xoxb-test"
+	defer srv.Close()
+
+	notifier := newTestSlack(t, srv, notify.SlackConfig{DefaultChannel: "#alerts", Username: "notify-bot"})
+	err := notifier.SendWithOptions(context.Background(), &notify.Message{
+		Title: "Deploy",
+		Text:  "v1.2.3 is *live*",
+		Attachments: []notify.Attachment{{
+			Color:  "good",
+			Fields: []notify.Field{{Title: "Env", Value: "prod", Short: true}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := srv.CallsTo("chat.postMessage")
+	if len(calls) != 1 {
+		t.Fatalf("Expected 1 chat.postMessage call, got %d", len(calls))
+	}
+
+	call := calls[0]
+	if call.Param("channel") != "#alerts" || call.Param("username") != "notify-bot" {
+		t.Errorf("Unexpected call parameters: %v", call.Params)
+	}
+
+	var blocks []struct {
+		Type string `json:"type"`
+		Text struct {
+			Text string `json:"text"`
+		} `json:"text"`
+	}
+	if err := call.Decode("blocks", &blocks); err != nil {
+		t.Fatalf("Failed to decode blocks: %v", err)
+	}
+	if len(blocks) != 2 || blocks[0].Type != "header" || blocks[0].Text.Text != "Deploy" || blocks[1].Text.Text != "v1.2.3 is *live*" {
+		t.Errorf("Unexpected blocks: %+v", blocks)
+	}
+
+	var attachments []slack.Attachment
+	if err := call.Decode("attachments", &attachments); err != nil {
+		t.Fatalf("Failed to decode attachments: %v", err)
+	}
+	if len(attachments) != 1 || attachments[0].Color != "good" || attachments[0].Fields[0].Value != "prod" {
+		t.Errorf("Unexpected attachments: %+v", attachments)
+	}
+}
+
+func TestSlackAPIErrors(t *testing.T) {
+	srv := notifytest.NewSlackServer()
+	srv.Channels = []string{"alerts"}
+	defer srv.Close()
+
+	notifier := newTestSlack(t, srv, notify.SlackConfig{DefaultChannel: "#alerts"})
+
+	err := notifier.SendWithOptions(context.Background(), &notify.Message{Text: "hi", Channel: "#missing"})
+	if err == nil || err.Error() != "slack notification error: failed to send message - channel_not_found" {
+		t.Errorf("Expected channel_not_found, got %v", err)
+	}
+
+	srv.RateLimit(1, 7*time.Second)
+	err = notifier.Send(context.Background(), "hi")
+	var rateLimited *slack.RateLimitedError
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter != 7*time.Second {
+		t.Errorf("Expected a rate limit error with Retry-After 7s, got %v", err)
+	}
+
+	srv.Outage(1)
+	var status slack.StatusCodeError
+	if err := notifier.Send(context.Background(), "hi"); !errors.As(err, &status) || status.Code != 503 {
+		t.Errorf("Expected a 503, got %v", err)
+	}
+
+	if err := notifier.Send(context.Background(), "hi"); err != nil {
+		t.Errorf("Expected recovery, got %v", err)
+	}
+}
+
+func TestSlackSendFile(t *testing.T) {
+	srv := notifytest.NewSlackServer()
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(path, []byte("all good"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := newTestSlack(t, srv, notify.SlackConfig{DefaultChannel: "C0REPORTS"})
+	if err := notifier.SendFile(context.Background(), "", path, "Nightly", "see attached"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	uploads := srv.Uploads()
+	if len(uploads) != 1 {
+		t.Fatalf("Expected 1 upload, got %d", len(uploads))
+	}
+	if upload := uploads[0]; upload.Filename != "report.txt" || upload.Title != "Nightly" || string(upload.Data) != "all good" || upload.Channels[0] != "C0REPORTS" {
+		t.Errorf("Unexpected upload: %+v", upload)
+	}
+
+	srv.Reset()
+	for _, channel := range []string{"#reports", "@alice", "reports"} {
+		err := notifier.SendFile(context.Background(), channel, path, "Nightly", "")
+		if err == nil || !strings.Contains(err.Error(), "channel ID") {
+			t.Errorf("Expected %s to be rejected as a channel name, got %v", channel, err)
+		}
+	}
+	if calls := srv.Calls(); len(calls) != 0 {
+		t.Errorf("Expected nothing to be uploaded for a channel name, got %+v", calls)
+	}
+
+	unshared := newTestSlack(t, srv, notify.SlackConfig{})
+	if err := unshared.SendFile(context.Background(), "", path, "Nightly", ""); err != nil {
+		t.Fatalf("Unexpected error without a channel: %v", err)
+	}
+	if calls := srv.CallsTo("files.completeUploadExternal"); len(calls) != 1 {
+		t.Fatalf("Expected the upload to complete, got %+v", srv.Calls())
+	} else if _, set := calls[0].Params["channel_id"]; set {
+		t.Errorf("Expected no channel_id without a channel, got %q", calls[0].Param("channel_id"))
+	}
+}
+
+func TestSlackSendFiles(t *testing.T) {
+	srv := notifytest.NewSlackServer()
+	srv.Token = "xoxb-test"
+	defer srv.Close()
+
+	notifier := newTestSlack(t, srv, notify.SlackConfig{DefaultChannel: "C123"})
+	var _ notify.FileSender = notifier
+
+	err := notifier.SendFiles(context.Background(), []notify.File{
+		{Name: "trace.txt", Reader: strings.NewReader("panic: boom"), Title: "Stack trace"},
+		{Name: "heap.pprof", Reader: io.MultiReader(strings.NewReader("pprof"), strings.NewReader("data"))},
+	}, notify.FileOptions{Comment: "Crash on db-1", ThreadID: "1700000000.000100"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if calls := srv.CallsTo("files.getUploadURLExternal"); len(calls) != 2 || calls[0].Param("length") != "11" || calls[1].Param("length") != "9" {
+		t.Errorf("Expected upload URLs for both files with their sizes, got %+v", calls)
+	}
+	if calls := srv.CallsTo("files.completeUploadExternal"); len(calls) != 1 {
+		t.Fatalf("Expected a single files.completeUploadExternal call, got %d", len(calls))
+	}
+
+	uploads := srv.Uploads()
+	if len(uploads) != 2 {
+		t.Fatalf("Expected 2 uploads, got %d", len(uploads))
+	}
+	if upload := uploads[0]; upload.Filename != "trace.txt" || upload.Title != "Stack trace" || string(upload.Data) != "panic: boom" {
+		t.Errorf("Unexpected upload: %+v", upload)
+	}
+	if upload := uploads[1]; upload.Filename != "heap.pprof" || string(upload.Data) != "pprofdata" {
+		t.Errorf("Unexpected upload: %+v", upload)
+	}
+	for _, upload := range uploads {
+		if upload.Channels[0] != "C123" || upload.Comment != "Crash on db-1" || upload.ThreadTS != "1700000000.000100" {
+			t.Errorf("Expected the files shared in the thread with the comment, got %+v", upload)
+		}
+	}
+}
+
+func TestSlackSendFilesErrors(t *testing.T) {
+	srv := notifytest.NewSlackServer()
+	srv.Channels = []string{"C123"}
+	defer srv.Close()
+
+	notifier := newTestSlack(t, srv, notify.SlackConfig{DefaultChannel: "C123"})
+	file := func() []notify.File {
+		return []notify.File{{Name: "a.txt", Reader: strings.NewReader("a")}}
+	}
+
+	err := notifier.SendFiles(context.Background(), file(), notify.FileOptions{Channel: "C999"})
+	var slackErr slack.SlackErrorResponse
+	if !errors.As(err, &slackErr) || slackErr.Err != "channel_not_found" {
+		t.Errorf("Expected channel_not_found, got %v", err)
+	}
+
+	srv.RateLimit(1, 3*time.Second)
+	var rateLimited *slack.RateLimitedError
+	if err := notifier.SendFiles(context.Background(), file(), notify.FileOptions{}); !errors.As(err, &rateLimited) || rateLimited.RetryAfter != 3*time.Second {
+		t.Errorf("Expected a rate limit error with Retry-After 3s, got %v", err)
+	}
+
+	if err := notifier.SendFiles(context.Background(), nil, notify.FileOptions{}); err == nil {
+		t.Error("Expected an error without files")
+	}
+	if err := notifier.SendFiles(context.Background(), []notify.File{{Name: "empty", Reader: strings.NewReader("")}}, notify.FileOptions{}); err == nil {
+		t.Error("Expected an error for an empty file")
+	}
+
+	webhook := newTestSlack(t, srv, notify.SlackConfig{WebhookURL: srv.WebhookURL()})
+	if err := webhook.SendFiles(context.Background(), file(), notify.FileOptions{}); err == nil {
+		t.Error("Expected an error without a token")
+	}
+}
+
+func TestSlackActions(t *testing.T) {
+	srv := notifytest.NewSlackServer()
+	defer srv.Close()
+
+	notifier := newTestSlack(t, srv, notify.SlackConfig{DefaultChannel: "#alerts"})
+	actions := []notify.Action{
+		{ID: "ack", Label: "Acknowledge", Value: "alert-7", Style: notify.ActionPrimary},
+		notify.CallbackAction("silence", "Silence 1h", "1h"),
+		notify.LinkAction("Open runbook", "https://runbooks.example.com/db"),
+	}
+	if err := notifier.SendWithOptions(context.Background(), &notify.Message{Text: "DB down", Actions: actions}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	call := srv.CallsTo("chat.postMessage")[0]
+	if call.Param("text") != "DB down" {
+		t.Errorf("Expected the text as notification fallback, got %q", call.Param("text"))
+	}
+
+	var blocks []struct {
+		Type     string `json:"type"`
+		Elements []struct {
+			Type     string `json:"type"`
+			ActionID string `json:"action_id"`
+			Value    string `json:"value"`
+			URL      string `json:"url"`
+			Style    string `json:"style"`
+			Text     struct {
+				Text string `json:"text"`
+			} `json:"text"`
+		} `json:"elements"`
+	}
+	if err := call.Decode("blocks", &blocks); err != nil {
+		t.Fatalf("Failed to decode blocks: %v", err)
+	}
+	if len(blocks) != 2 || blocks[0].Type != "section" || blocks[1].Type != "actions" || len(blocks[1].Elements) != 3 {
+		t.Fatalf("Unexpected blocks: %+v", blocks)
+	}
+	buttons := blocks[1].Elements
+	if buttons[0].ActionID != "ack" || buttons[0].Value != "alert-7" || buttons[0].Style != "primary" || buttons[0].Text.Text != "Acknowledge" {
+		t.Errorf("Unexpected callback button %+v", buttons[0])
+	}
+	if buttons[2].URL != "https://runbooks.example.com/db" || buttons[2].ActionID != "" {
+		t.Errorf("Unexpected link button %+v", buttons[2])
+	}
+
+	webhook := newTestSlack(t, srv, notify.SlackConfig{WebhookURL: srv.WebhookURL()})
+	if err := webhook.SendWithOptions(context.Background(), &notify.Message{Title: "DB", Text: "down", Actions: actions}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := srv.CallsTo("webhook")[0].Decode("blocks", &blocks); err != nil || len(blocks) != 3 || blocks[2].Type != "actions" {
+		t.Errorf("Expected header, section and actions blocks, got %+v (%v)", blocks, err)
+	}
+}
+
+// slackInteraction builds a signed interactivity request for a button click
+func slackInteraction(t *testing.T, secret string, timestamp time.Time, actionID, value string) *http.Request {
+	t.Helper()
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":      "block_actions",
+		"user":      map[string]string{"id": "U123", "name": "alice"},
+		"channel":   map[string]string{"id": "C123"},
+		"container": map[string]string{"type": "message", "channel_id": "C123", "message_ts": "1700000000.000100"},
+		"actions":   []map[string]string{{"block_id": "b1", "action_id": actionID, "value": value, "type": "button"}},
+	})
+	body := url.Values{"payload": {string(payload)}}.Encode()
+
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+
+	req := httptest.NewRequest("POST", "/slack/interactions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestSlackInteractivityHandler(t *testing.T) {
+	srv := notifytest.NewSlackServer()
+	defer srv.Close()
+
+	notifier := newTestSlack(t, srv, notify.SlackConfig{DefaultChannel: "C123"})
+	interactions := notify.NewInteractions()
+
+	var received *notify.Interaction
+	interactions.Handle("ack", func(ctx context.Context, in *notify.Interaction) error {
+		received = in
+		if err := in.Reply(ctx, &notify.Message{Text: "Acknowledged by " + in.User.Name}); err != nil {
+			return err
+		}
+		return in.Update(ctx, &notify.Message{Title: "DB down", Text: "Acknowledged"})
+	})
+	interactions.Handle("fail", func(ctx context.Context, in *notify.Interaction) error {
+		return errors.New("boom")
+	})
+	handler := notifier.InteractivityHandler("s3cret", interactions)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, slackInteraction(t, "s3cret", time.Now(), "ack", "alert-7"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	want := notify.Interaction{
+		Provider:   "slack",
+		User:       notify.User{ID: "U123", Name: "alice"},
+		ActionID:   "ack",
+		Value:      "alert-7",
+		MessageRef: notify.MessageRef{Channel: "C123", ID: "1700000000.000100"},
+	}
+	if received == nil || received.Provider != want.Provider || received.User != want.User ||
+		received.ActionID != want.ActionID || received.Value != want.Value || received.MessageRef != want.MessageRef {
+		t.Fatalf("Unexpected interaction %+v", received)
+	}
+
+	replies := srv.CallsTo("chat.postMessage")
+	if len(replies) != 1 || replies[0].Param("thread_ts") != "1700000000.000100" || replies[0].Param("text") != "Acknowledged by alice" {
+		t.Errorf("Expected a reply in the thread, got %+v", replies)
+	}
+	updates := srv.CallsTo("chat.update")
+	if len(updates) != 1 || updates[0].Param("ts") != "1700000000.000100" || updates[0].Param("channel") != "C123" {
+		t.Fatalf("Expected the message to be updated, got %+v", updates)
+	}
+	if blocks := updates[0].Param("blocks"); strings.Contains(blocks, "actions") || !strings.Contains(blocks, "Acknowledged") {
+		t.Errorf("Expected the buttons to be replaced, got %s", blocks)
+	}
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		code int
+	}{
+		{"wrong secret", slackInteraction(t, "other", time.Now(), "ack", ""), http.StatusUnauthorized},
+		{"stale", slackInteraction(t, "s3cret", time.Now().Add(-10*time.Minute), "ack", ""), http.StatusUnauthorized},
+		{"handler error", slackInteraction(t, "s3cret", time.Now(), "fail", ""), http.StatusInternalServerError},
+		{"unhandled", slackInteraction(t, "s3cret", time.Now(), "open_runbook", ""), http.StatusOK},
+		{"GET", httptest.NewRequest("GET", "/slack/interactions", nil), http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, tt.req)
+		if rec.Code != tt.code {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.code, rec.Code)
+		}
+	}
+
+	// a request signed with an empty secret must not get through
+	received = nil
+	rec = httptest.NewRecorder()
+	notifier.InteractivityHandler("", interactions).ServeHTTP(rec, slackInteraction(t, "", time.Now(), "ack", "alert-7"))
+	if rec.Code != http.StatusInternalServerError || received != nil {
+		t.Errorf("Expected requests to be rejected without a signing secret, got %d and %+v", rec.Code, received)
+	}
+}
+
+func TestSlackWebhook(t *testing.T) {
+	srv := notifytest.NewSlackServer()
+	defer srv.Close()
+
+	notifier := newTestSlack(t, srv, notify.SlackConfig{WebhookURL: srv.WebhookURL()})
+	if err := notifier.SendWithOptions(context.Background(), &notify.Message{Title: "Backup", Text: "done"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := srv.CallsTo("webhook")
+	if len(calls) != 1 || calls[0].Param("text") != "done" || calls[0].Param("blocks") == "" {
+		t.Errorf("Unexpected webhook calls: %+v", calls)
+	}
+}
+
+func TestSlackProviderAPIURL(t *testing.T) {
+	srv := notifytest.NewSlackServer()
+	defer srv.Close()
+
+	notifier, err := notify.NewProvider("slack", map[string]interface{}{
+		"token":   "xoxb-test",
+		"channel": "#general",
+		"api_url": srv.APIURL(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	if err := notifier.Send(context.Background(), "hello"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(srv.CallsTo("chat.postMessage")) != 1 {
+		t.Error("Expected the message to reach the configured API URL")
+	}
+}
+
+func TestSlackConformance(t *testing.T) {
+	notifytest.RunConformance(t, func(t *testing.T) *notifytest.Harness {
+		srv := notifytest.NewSlackServer()
+		srv.Token = "xoxb-test"
+		srv.Channels = []string{"#alerts", "#ops"}
+		t.Cleanup(srv.Close)
+
+		return &notifytest.Harness{
+			Notifier: newTestSlack(t, srv, notify.SlackConfig{DefaultChannel: "#alerts"}),
+			Delivered: func() []notifytest.Delivered {
+				var delivered []notifytest.Delivered
+				for _, call := range srv.CallsTo("chat.postMessage") {
+					delivered = append(delivered, notifytest.Delivered{Channel: call.Param("channel"), Text: slackText(t, call)})
+				}
+				return delivered
+			},
+			DefaultChannel: "#alerts",
+			OtherChannel:   "#ops",
+			SetFailing:     srv.SetDown,
+			Hold:           srv.Hold,
+		}
+	})
+}
+
+// slackText returns the text of a chat.postMessage call, including block text
+func slackText(t *testing.T, call notifytest.SlackCall) string {
+	text := call.Param("text")
+	if call.Param("blocks") == "" {
+		return text
+	}
+
+	var blocks []struct {
+		Text struct {
+			Text string `json:"text"`
+		} `json:"text"`
+	}
+	if err := call.Decode("blocks", &blocks); err != nil {
+		t.Errorf("Failed to decode blocks: %v", err)
+	}
+	for _, block := range blocks {
+		text += "\n" + block.Text.Text
+	}
+	return text
+}
+
+func TestSlackRichText(t *testing.T) {
+	srv := notifytest.NewSlackServer()
+	defer srv.Close()
+
+	doc := richtext.New().
+		Bold("Deploy failed").Text(" on ").Code("web<1>").Text(" for ").Mention("U123", "alice").Line().
+		Link("https://ci.example.com/42", "Build log").
+		Pre("go", "a && b").
+		Quote("see logs").
+		List("check migrations", "roll back").
+		Document()
+
+	notifier := newTestSlack(t, srv, notify.SlackConfig{DefaultChannel: "#alerts"})
+	if err := notifier.SendWithOptions(context.Background(), &notify.Message{Text: "fallback", Rich: doc}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := "*Deploy failed* on `web&lt;1&gt;` for <@U123>\n" +
+		"<https://ci.example.com/42|Build log>\n\n" +
+		"```\na &amp;&amp; b\n```\n\n" +
+		"> see logs\n\n" +
+		"• check migrations\n• roll back"
+	if got := srv.CallsTo("chat.postMessage")[0].Param("text"); got != want {
+		t.Errorf("Unexpected text:\n%s\nwant:\n%s", got, want)
+	}
+}
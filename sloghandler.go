@@ -0,0 +1,310 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SlogHandlerOptions configures a SlogHandler
+type SlogHandlerOptions struct {
+	// Route is the Manager route notifications are sent to (required)
+	Route string
+
+	// Level is the minimum level forwarded as a notification (defaults to error)
+	Level slog.Leveler
+
+	// Burst is the number of notifications allowed per Interval (defaults to 5).
+	// Records beyond it are dropped and counted in the next notification.
+	Burst int
+
+	// Interval is the rate limiting window (defaults to 1 minute)
+	Interval time.Duration
+
+	// QueueSize is the number of pending notifications buffered before
+	// records are dropped (defaults to 100)
+	QueueSize int
+
+	// Timeout bounds each delivery (defaults to 10s)
+	Timeout time.Duration
+
+	// OnError is called from the delivery goroutine with the error of each
+	// provider a notification could not be delivered to.
+	// It must not log through the SlogHandler's Manager route.
+	OnError func(err error)
+}
+
+// SlogHandler is a slog.Handler that passes records to another handler and
+// sends those at or above a level as notifications to a Manager route.
+// Record attributes become attachment fields.
+//
+// Sending never blocks logging: notifications are queued and delivered by a
+// background goroutine, and records are dropped when the queue is full or the
+// rate limit is exceeded. Records logged while delivering a notification are
+// not forwarded, so delivery failures can't loop.
+//
+//	handler := notify.NewSlogHandler(slog.NewJSONHandler(os.Stderr, nil), manager,
+//		notify.SlogHandlerOptions{Route: "oncall"})
+//	defer handler.Close()
+//	slog.SetDefault(slog.New(handler))
+type SlogHandler struct {
+	next   slog.Handler
+	state  *slogForwarder
+	attrs  []slog.Attr
+	groups []string
+}
+
+// slogForwarder is the state shared by a SlogHandler and its derived handlers
+type slogForwarder struct {
+	manager *Manager
+	opts    SlogHandlerOptions
+	queue   chan *Message
+	done    chan struct{}
+
+	mu          sync.Mutex
+	windowStart time.Time
+	sent        int
+	unreported  int
+	dropped     int
+	failed      int
+	closed      bool
+}
+
+// forwardingKey marks contexts of deliveries made by a SlogHandler
+type forwardingKey struct{}
+
+// NewSlogHandler creates a handler wrapping next that forwards records to
+// manager. Call Close to flush pending notifications.
+func NewSlogHandler(next slog.Handler, manager *Manager, opts SlogHandlerOptions) *SlogHandler {
+	if opts.Level == nil {
+		opts.Level = slog.LevelError
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = 5
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 100
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	state := &slogForwarder{
+		manager: manager,
+		opts:    opts,
+		queue:   make(chan *Message, opts.QueueSize),
+		done:    make(chan struct{}),
+	}
+	go state.run()
+
+	return &SlogHandler{next: next, state: state}
+}
+
+// Enabled implements slog.Handler
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.state.opts.Level.Level() || h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= h.state.opts.Level.Level() && ctx.Value(forwardingKey{}) == nil {
+		h.state.enqueue(record.Time, h.message(record))
+	}
+
+	if !h.next.Enabled(ctx, record.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.attrs = append(append([]slog.Attr(nil), h.attrs...), h.prefixed(attrs)...)
+	return &clone
+}
+
+// WithGroup implements slog.Handler
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.groups = append(append([]string(nil), h.groups...), name)
+	return &clone
+}
+
+// Close stops forwarding and waits for queued notifications to be sent
+func (h *SlogHandler) Close() error {
+	h.state.mu.Lock()
+	if !h.state.closed {
+		h.state.closed = true
+		close(h.state.queue)
+	}
+	h.state.mu.Unlock()
+
+	<-h.state.done
+	return nil
+}
+
+// Dropped returns the number of records dropped by rate limiting or a full queue
+func (h *SlogHandler) Dropped() int {
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+
+	return h.state.dropped
+}
+
+// Failed returns the number of failed deliveries, one per provider of the route
+func (h *SlogHandler) Failed() int {
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+
+	return h.state.failed
+}
+
+// prefixed qualifies attribute keys with the handler's groups
+func (h *SlogHandler) prefixed(attrs []slog.Attr) []slog.Attr {
+	if len(h.groups) == 0 {
+		return attrs
+	}
+
+	prefix := strings.Join(h.groups, ".") + "."
+	out := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		out[i] = slog.Attr{Key: prefix + attr.Key, Value: attr.Value}
+	}
+	return out
+}
+
+// message renders a record as a notification
+func (h *SlogHandler) message(record slog.Record) *Message {
+	attrs := append([]slog.Attr(nil), h.attrs...)
+	var recordAttrs []slog.Attr
+	record.Attrs(func(attr slog.Attr) bool {
+		recordAttrs = append(recordAttrs, attr)
+		return true
+	})
+	attrs = append(attrs, h.prefixed(recordAttrs)...)
+
+	att := Attachment{Color: "warning"}
+	for _, attr := range attrs {
+		att.Fields = appendAttrFields(att.Fields, "", attr)
+	}
+	if !record.Time.IsZero() {
+		att.Footer = record.Time.Format(time.RFC3339)
+	}
+
+	msg := &Message{
+		Title:       record.Level.String(),
+		Text:        record.Message,
+		Priority:    PriorityNormal,
+		Attachments: []Attachment{att},
+	}
+	if record.Level >= slog.LevelError {
+		msg.Priority = PriorityHigh
+		msg.Attachments[0].Color = "danger"
+	}
+	return msg
+}
+
+// appendAttrFields adds an attribute as fields, flattening groups
+func appendAttrFields(fields []Field, prefix string, attr slog.Attr) []Field {
+	value := attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	key := prefix + attr.Key
+	if value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix = key + "."
+		}
+		for _, member := range value.Group() {
+			fields = append(fields, appendAttrFields(nil, prefix, member)...)
+		}
+		return fields
+	}
+
+	text := Redact(value.String())
+	return append(fields, Field{Title: key, Value: text, Short: len(text) <= 40})
+}
+
+// enqueue queues a notification unless rate limited or the queue is full. The
+// message is built by the caller, so attribute values are resolved without
+// holding the lock.
+func (s *slogForwarder) enqueue(now time.Time, msg *Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if now.Sub(s.windowStart) >= s.opts.Interval {
+		s.windowStart = now
+		s.sent = 0
+	}
+	if s.sent >= s.opts.Burst {
+		s.drop()
+		return
+	}
+
+	if s.unreported > 0 {
+		msg.Attachments[0].Fields = append(msg.Attachments[0].Fields,
+			Field{Title: "Dropped", Value: fmt.Sprintf("%d earlier records", s.unreported), Short: true})
+	}
+
+	select {
+	case s.queue <- msg:
+		s.sent++
+		s.unreported = 0
+	default:
+		s.drop()
+	}
+}
+
+// drop counts a record that was not forwarded
+func (s *slogForwarder) drop() {
+	s.dropped++
+	s.unreported++
+}
+
+// run delivers queued notifications until the queue is closed
+func (s *slogForwarder) run() {
+	defer close(s.done)
+
+	for msg := range s.queue {
+		ctx := context.WithValue(context.Background(), forwardingKey{}, true)
+		ctx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+		errs := s.manager.SendRoute(ctx, s.opts.Route, msg)
+		cancel()
+
+		for _, err := range errs {
+			s.fail(err)
+		}
+	}
+}
+
+// fail counts a failed delivery and reports it to OnError
+func (s *slogForwarder) fail(err error) {
+	s.mu.Lock()
+	s.failed++
+	s.mu.Unlock()
+
+	if s.opts.OnError != nil {
+		s.opts.OnError(err)
+	}
+}
@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+// lockingValuer checks whether the forwarder's lock is held while it is resolved
+type lockingValuer struct {
+	state    *slogForwarder
+	locked   bool
+	resolved bool
+}
+
+func (v *lockingValuer) LogValue() slog.Value {
+	if v.state.mu.TryLock() {
+		v.state.mu.Unlock()
+	} else {
+		v.locked = true
+	}
+	v.resolved = true
+	return slog.StringValue("resolved")
+}
+
+func TestSlogHandlerBuildsOutsideLock(t *testing.T) {
+	notifier := NewMockNotifier("pager")
+	manager := NewManager()
+	manager.Register(notifier)
+	manager.SetRoute("alerts", notifier.Name())
+	handler := NewSlogHandler(slog.NewTextHandler(&bytes.Buffer{}, nil), manager, SlogHandlerOptions{Route: "alerts"})
+
+	valuer := &lockingValuer{state: handler.state}
+	slog.New(handler).Error("failed", "value", valuer)
+	handler.Close()
+
+	if !valuer.resolved {
+		t.Fatal("Expected the attribute to be resolved")
+	}
+	if valuer.locked {
+		t.Error("Expected attributes to be resolved without holding the handler lock")
+	}
+	if !notifier.sendCalled || notifier.lastMessage != "failed" {
+		t.Errorf("Expected the record to be sent, got %q", notifier.lastMessage)
+	}
+}
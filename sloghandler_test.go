@@ -0,0 +1,181 @@
+package notify_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/milano15662/notify"
+	"github.com/milano15662/notify/notifytest"
+)
+
+// received returns every message n received, including failed deliveries
+func received(n *notifytest.Notifier) []*notify.Message {
+	var msgs []*notify.Message
+	for _, d := range n.Recorder().Deliveries() {
+		msgs = append(msgs, d.Message)
+	}
+	return msgs
+}
+
+func newSlogTestManager(notifier notify.Notifier) *notify.Manager {
+	manager := notify.NewManager()
+	manager.Register(notifier)
+	manager.SetRoute("alerts", notifier.Name())
+	return manager
+}
+
+func fieldValue(msg *notify.Message, title string) (string, bool) {
+	for _, att := range msg.Attachments {
+		for _, field := range att.Fields {
+			if field.Title == title {
+				return field.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+func TestSlogHandlerForwards(t *testing.T) {
+	notifier := notifytest.New("pager")
+
+	var buf bytes.Buffer
+	next := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+	handler := notify.NewSlogHandler(next, newSlogTestManager(notifier), notify.SlogHandlerOptions{Route: "alerts"})
+
+	logger := slog.New(handler).With("service", "billing").WithGroup("req")
+	logger.Info("request served", "id", 1)
+	logger.Error("payment failed", "id", 2, slog.Group("card", "brand", "visa"),
+		"url", "https://api.telegram.org/bot123:SECRET/sendMessage")
+	logger.Debug("ignored entirely")
+	handler.Close()
+
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Errorf("Expected the wrapped handler to receive 2 records, got %d:\n%s", lines, buf.String())
+	}
+
+	sent := received(notifier)
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 notification, got %d", len(sent))
+	}
+
+	msg := sent[0]
+	if msg.Text != "payment failed" || msg.Title != "ERROR" || msg.Priority != notify.PriorityHigh {
+		t.Errorf("Unexpected message: %+v", msg)
+	}
+
+	want := map[string]string{
+		"service":        "billing",
+		"req.id":         "2",
+		"req.card.brand": "visa",
+		"req.url":        "https://api.telegram.org/bot<redacted>/sendMessage",
+	}
+	for title, value := range want {
+		if got, ok := fieldValue(msg, title); !ok || got != value {
+			t.Errorf("Expected field %s=%q, got %q", title, value, got)
+		}
+	}
+}
+
+func TestSlogHandlerRateLimit(t *testing.T) {
+	notifier := notifytest.New("pager")
+	handler := notify.NewSlogHandler(slog.NewTextHandler(&bytes.Buffer{}, nil), newSlogTestManager(notifier),
+		notify.SlogHandlerOptions{Route: "alerts", Burst: 2, Interval: time.Minute})
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		handler.Handle(context.Background(), slog.NewRecord(start.Add(time.Duration(i)*time.Second), slog.LevelError, "burst", 0))
+	}
+	handler.Handle(context.Background(), slog.NewRecord(start.Add(2*time.Minute), slog.LevelError, "later", 0))
+	handler.Close()
+
+	if handler.Dropped() != 3 {
+		t.Errorf("Expected 3 dropped records, got %d", handler.Dropped())
+	}
+
+	sent := received(notifier)
+	if len(sent) != 3 {
+		t.Fatalf("Expected 3 notifications, got %d", len(sent))
+	}
+	if got, ok := fieldValue(sent[2], "Dropped"); !ok || got != "3 earlier records" {
+		t.Errorf("Expected the next notification to report dropped records, got %q", got)
+	}
+}
+
+func TestSlogHandlerNonBlocking(t *testing.T) {
+	notifier := notifytest.New("pager", notifytest.Latency(100*time.Millisecond))
+	handler := notify.NewSlogHandler(slog.NewTextHandler(&bytes.Buffer{}, nil), newSlogTestManager(notifier),
+		notify.SlogHandlerOptions{Route: "alerts", Burst: 100, QueueSize: 1})
+	logger := slog.New(handler)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			logger.Error("stuck")
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected logging not to block on a stalled notifier")
+	}
+
+	handler.Close()
+
+	if handler.Dropped() == 0 {
+		t.Error("Expected records to be dropped while the queue was full")
+	}
+}
+
+func TestSlogHandlerNoFeedbackLoop(t *testing.T) {
+	notifier := notifytest.New("pager", notifytest.FailAlways(errors.New("down")))
+	manager := newSlogTestManager(notifier)
+
+	handler := notify.NewSlogHandler(slog.NewTextHandler(&bytes.Buffer{}, nil), manager, notify.SlogHandlerOptions{Route: "alerts"})
+	logger := slog.New(handler)
+	manager.AddObserver(notify.NewLogObserver(logger, notify.LogOptions{}))
+
+	logger.Error("first failure")
+	// Give the failure log a chance to be forwarded before closing
+	time.Sleep(10 * time.Millisecond)
+	handler.Close()
+
+	if sent := received(notifier); len(sent) != 1 {
+		t.Errorf("Expected delivery failures not to be forwarded, got %d notifications", len(sent))
+	}
+}
+
+func TestSlogHandlerReportsFailures(t *testing.T) {
+	notifier := notifytest.New("pager", notifytest.FailAlways(errors.New("down")))
+
+	var mu sync.Mutex
+	var reported []error
+	handler := notify.NewSlogHandler(slog.NewTextHandler(&bytes.Buffer{}, nil), newSlogTestManager(notifier), notify.SlogHandlerOptions{
+		Route: "alerts",
+		OnError: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			reported = append(reported, err)
+		},
+	})
+
+	slog.New(handler).Error("first failure")
+	handler.Close()
+
+	if handler.Failed() != 1 {
+		t.Errorf("Expected 1 failed delivery, got %d", handler.Failed())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 1 || !strings.Contains(reported[0].Error(), "down") {
+		t.Errorf("Expected the delivery error to be reported, got %v", reported)
+	}
+}
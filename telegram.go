@@ -4,33 +4,79 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+func init() {
+	RegisterProvider("telegram", newTelegramProvider)
+	RegisterScheme("telegram", parseTelegramURL)
+}
+
 // TelegramNotifier sends notifications via Telegram Bot API
 type TelegramNotifier struct {
+	name      string
 	botToken  string
 	chatID    string
+	chatIDs   []string
 	client    *http.Client
 	parseMode string
+	rawText   bool
+	apiURL    string
+	maxLength int
+	markers   bool
+	long      string
 }
 
+// DefaultTelegramAPIURL is the base URL of the Telegram Bot API
+const DefaultTelegramAPIURL = "https://api.telegram.org"
+
 // TelegramConfig holds configuration for Telegram notifications
 type TelegramConfig struct {
+	// Name is the instance name reported by Name (optional, defaults to "telegram")
+	Name string
+
 	// BotToken is the Telegram Bot API token
 	BotToken string
 
 	// ChatID is the default chat ID to send messages to
 	ChatID string
 
-	// ParseMode defines how to parse the message (Markdown, HTML, or empty for plain text)
+	// ChatIDs lists additional default chats; messages without a Channel go to all of them
+	ChatIDs []string
+
+	// ParseMode is how messages are formatted: TelegramMarkdownV2 (default),
+	// TelegramHTML, legacy TelegramMarkdown or TelegramPlainText
 	ParseMode string
 
+	// RawText sends Text as-is instead of escaping it, so it can use the
+	// formatting of ParseMode (see EscapeTelegram). Legacy TelegramMarkdown
+	// always sends Text as-is, as it did before escaping was added.
+	RawText bool
+
 	// HTTPClient allows custom HTTP client (optional)
 	HTTPClient *http.Client
+
+	// APIURL is the Bot API base URL, e.g. a local Bot API server or a test
+	// server (optional, defaults to DefaultTelegramAPIURL)
+	APIURL string
+
+	// MaxMessageLength is the length at which texts are split or sent as a
+	// document (optional, defaults to TelegramMaxMessageLength)
+	MaxMessageLength int
+
+	// ChunkMarkers appends "(1/3)" markers to the parts of split messages
+	ChunkMarkers bool
+
+	// LongMessages is how texts over MaxMessageLength are sent:
+	// TelegramLongMessageSplit (default) or TelegramLongMessageDocument
+	LongMessages string
 }
 
 // NewTelegramNotifier creates a new Telegram notifier
@@ -42,7 +88,12 @@ func NewTelegramNotifier(config TelegramConfig) (*TelegramNotifier, error) {
 		}
 	}
 
-	if config.ChatID == "" {
+	chatIDs := config.ChatIDs
+	if config.ChatID != "" {
+		chatIDs = append([]string{config.ChatID}, chatIDs...)
+	}
+
+	if len(chatIDs) == 0 {
 		return nil, &NotificationError{
 			Provider: "telegram",
 			Message:  "chat ID is required",
@@ -56,56 +107,232 @@ func NewTelegramNotifier(config TelegramConfig) (*TelegramNotifier, error) {
 		}
 	}
 
-	parseMode := config.ParseMode
+	parseMode := TelegramMarkdownV2
+	if config.ParseMode != "" {
+		parseMode = ""
+		for _, mode := range []string{TelegramMarkdownV2, TelegramHTML, TelegramMarkdown, TelegramPlainText} {
+			if strings.EqualFold(config.ParseMode, mode) {
+				parseMode = mode
+			}
+		}
+	}
 	if parseMode == "" {
-		parseMode = "Markdown"
+		return nil, &NotificationError{
+			Provider: "telegram",
+			Message:  fmt.Sprintf("unknown parse mode %q (want %s, %s, %s or %s)", config.ParseMode, TelegramMarkdownV2, TelegramHTML, TelegramMarkdown, TelegramPlainText),
+		}
+	}
+
+	name := config.Name
+	if name == "" {
+		name = "telegram"
+	}
+
+	apiURL := strings.TrimRight(config.APIURL, "/")
+	if apiURL == "" {
+		apiURL = DefaultTelegramAPIURL
+	}
+
+	maxLength := config.MaxMessageLength
+	if maxLength <= 0 || maxLength > TelegramMaxMessageLength {
+		maxLength = TelegramMaxMessageLength
+	}
+
+	long := config.LongMessages
+	switch long {
+	case "":
+		long = TelegramLongMessageSplit
+	case TelegramLongMessageSplit, TelegramLongMessageDocument:
+	default:
+		return nil, &NotificationError{
+			Provider: "telegram",
+			Message:  fmt.Sprintf("unknown long message mode %q (want %s or %s)", long, TelegramLongMessageSplit, TelegramLongMessageDocument),
+		}
 	}
 
 	return &TelegramNotifier{
+		name:      name,
 		botToken:  config.BotToken,
-		chatID:    config.ChatID,
+		chatID:    chatIDs[0],
+		chatIDs:   chatIDs,
 		client:    client,
 		parseMode: parseMode,
+		rawText:   config.RawText || parseMode == TelegramMarkdown,
+		apiURL:    apiURL,
+		maxLength: maxLength,
+		markers:   config.ChunkMarkers,
+		long:      long,
 	}, nil
 }
 
+// newTelegramProvider creates a Telegram notifier from raw configuration options
+func newTelegramProvider(raw map[string]interface{}) (Notifier, error) {
+	opts := newOptions(raw)
+	config := TelegramConfig{
+		Name:      opts.String("name"),
+		BotToken:  opts.Required("bot_token"),
+		ChatID:    opts.String("chat_id"),
+		ChatIDs:   opts.Strings("chat_ids"),
+		ParseMode: opts.String("parse_mode"),
+		RawText:   opts.Bool("raw_text"),
+		APIURL:    opts.String("api_url"),
+
+		MaxMessageLength: opts.Int("max_message_length"),
+		ChunkMarkers:     opts.Bool("chunk_markers"),
+		LongMessages:     opts.String("long_messages"),
+	}
+	if config.ChatID == "" && len(config.ChatIDs) == 0 {
+		opts.fail("chat_id", "is required")
+	}
+	if err := opts.Finish(); err != nil {
+		return nil, err
+	}
+
+	return NewTelegramNotifier(config)
+}
+
 // Name returns the name of the provider
 func (t *TelegramNotifier) Name() string {
-	return "telegram"
+	return t.name
 }
 
 // Send sends a simple text message
 func (t *TelegramNotifier) Send(ctx context.Context, message string) error {
 	return t.SendWithOptions(ctx, &Message{
-		Text:    message,
-		Channel: t.chatID,
+		Text: message,
 	})
 }
 
 // SendWithOptions sends a message with additional options
 func (t *TelegramNotifier) SendWithOptions(ctx context.Context, msg *Message) error {
-	if msg.Text == "" {
+	if !msg.hasText() {
 		return &NotificationError{
 			Provider: "telegram",
 			Message:  "message text is required",
 		}
 	}
 
-	chatID := msg.Channel
-	if chatID == "" {
-		chatID = t.chatID
+	keyboard, err := telegramKeyboard(msg.Actions)
+	if err != nil {
+		return err
+	}
+	messageText := t.format(msg)
+
+	chatIDs := t.chatIDs
+	if msg.Channel != "" {
+		chatIDs = []string{msg.Channel}
+	}
+
+	var errs []error
+	for _, chatID := range chatIDs {
+		messageID, err := t.sendText(ctx, chatID, messageText, keyboard, 0, msg)
+		if err == nil {
+			if err = t.sendImages(ctx, chatID, messageID, msg); err != nil {
+				err = &NotificationError{
+					Provider: "telegram",
+					Message:  "failed to send images after the text",
+					Err:      partialDelivery(err),
+				}
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return t.joinChatErrors(errs, len(chatIDs))
+}
+
+// format renders the title, text and attachments of msg in the parse mode,
+// escaping everything but raw text
+func (t *TelegramNotifier) format(msg *Message) string {
+	text := msg.Text
+	switch {
+	case !msg.Rich.Empty():
+		text = msg.Rich.Render(telegramStyle(t.parseMode))
+	case !t.rawText:
+		text = EscapeTelegram(text, t.parseMode)
 	}
 
-	// Build the message text
-	messageText := msg.Text
 	if msg.Title != "" {
-		messageText = fmt.Sprintf("*%s*\n\n%s", msg.Title, msg.Text)
+		text = telegramBold(EscapeTelegram(msg.Title, t.parseMode), t.parseMode) + "\n\n" + text
+	}
+	if attachments := attachmentsDocument(msg.Attachments); !attachments.Empty() {
+		text += "\n\n" + attachments.Render(telegramStyle(t.parseMode))
 	}
+	return text
+}
 
+// sendText sends text to a chat, splitting it or sending it as a document
+// when it is over the message length limit, with the keyboard below the
+// last part. The first part replies to the message replyTo unless it is 0.
+// It returns the ID of the first message sent.
+func (t *TelegramNotifier) sendText(ctx context.Context, chatID, text string, keyboard map[string]interface{}, replyTo int, msg *Message) (int, error) {
+	if t.long == TelegramLongMessageDocument && utf8.RuneCountInString(text) > t.maxLength {
+		return t.sendTextDocument(ctx, chatID, text, replyTo, msg)
+	}
+
+	firstID := 0
+	chunks := splitTelegramText(text, t.parseMode, t.maxLength, t.markers)
+	for i, chunk := range chunks {
+		var markup map[string]interface{}
+		if i == len(chunks)-1 {
+			markup = keyboard
+		}
+
+		messageID, err := t.sendMessage(ctx, chatID, chunk, t.parseMode, markup, replyTo, msg)
+		if isEntityError(err) {
+			// resend what Telegram couldn't parse as plain text
+			messageID, err = t.sendMessage(ctx, chatID, telegramPlain(chunk, t.parseMode), TelegramPlainText, markup, replyTo, msg)
+		}
+		if err == nil {
+			if i == 0 {
+				firstID = messageID
+			}
+			replyTo = 0
+			continue
+		}
+
+		switch {
+		case len(chunks) == 1:
+			return 0, err
+		case i > 0:
+			// earlier parts were delivered
+			err = partialDelivery(err)
+		}
+		return 0, &NotificationError{
+			Provider: "telegram",
+			Message:  fmt.Sprintf("failed to send part %d of %d", i+1, len(chunks)),
+			Err:      err,
+		}
+	}
+
+	return firstID, nil
+}
+
+// telegramMessage is the part of a sent Message the notifier uses
+type telegramMessage struct {
+	MessageID int `json:"message_id"`
+}
+
+// sendMessage sends a single text message with an optional keyboard,
+// replying to the message replyTo unless it is 0, and returns its ID
+func (t *TelegramNotifier) sendMessage(ctx context.Context, chatID, text, parseMode string, keyboard map[string]interface{}, replyTo int, msg *Message) (int, error) {
 	payload := map[string]interface{}{
-		"chat_id":    chatID,
-		"text":       messageText,
-		"parse_mode": t.parseMode,
+		"chat_id": chatID,
+		"text":    text,
+	}
+	if parseMode != TelegramPlainText {
+		payload["parse_mode"] = parseMode
+	}
+	if keyboard != nil {
+		payload["reply_markup"] = keyboard
+	}
+	if replyTo != 0 {
+		payload["reply_parameters"] = map[string]interface{}{
+			"message_id":                  replyTo,
+			"allow_sending_without_reply": true,
+		}
 	}
 
 	// Add priority-based notification settings
@@ -113,28 +340,59 @@ func (t *TelegramNotifier) SendWithOptions(ctx context.Context, msg *Message) er
 		payload["disable_notification"] = true
 	}
 
-	return t.sendRequest(ctx, "sendMessage", payload)
+	var sent telegramMessage
+	err := t.sendRequest(ctx, "sendMessage", payload, &sent)
+	return sent.MessageID, err
 }
 
-// SendPhoto sends a photo with caption
-func (t *TelegramNotifier) SendPhoto(ctx context.Context, chatID, photoURL, caption string) error {
-	if chatID == "" {
-		chatID = t.chatID
-	}
+// sendTextDocument sends text as a message.txt document captioned with the
+// message title and returns the message ID
+func (t *TelegramNotifier) sendTextDocument(ctx context.Context, chatID, text string, replyTo int, msg *Message) (int, error) {
+	return t.SendDocument(ctx, FileReader("message.txt", strings.NewReader(text)), TelegramMediaOptions{
+		ChatID:    chatID,
+		Caption:   msg.Title,
+		ParseMode: TelegramPlainText,
+		ReplyTo:   replyTo,
+		Silent:    msg.Priority == PriorityLow,
+		Actions:   msg.Actions,
+	})
+}
 
-	payload := map[string]interface{}{
-		"chat_id": chatID,
-		"photo":   photoURL,
-		"caption": caption,
+// joinChatErrors combines the errors of a message sent to several chats
+func (t *TelegramNotifier) joinChatErrors(errs []error, chats int) error {
+	switch {
+	case len(errs) == 0:
+		return nil
+	case chats == 1:
+		return errs[0]
 	}
 
-	return t.sendRequest(ctx, "sendPhoto", payload)
+	err := errors.Join(errs...)
+	if len(errs) < chats {
+		// the other chats received the message
+		err = partialDelivery(err)
+	}
+	return &NotificationError{
+		Provider: "telegram",
+		Message:  fmt.Sprintf("failed to send to %d of %d chats", len(errs), chats),
+		Err:      err,
+	}
 }
 
-// sendRequest sends a request to the Telegram Bot API
-func (t *TelegramNotifier) sendRequest(ctx context.Context, method string, payload map[string]interface{}) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/%s", t.botToken, method)
+// SendPhoto sends a photo with caption. The caption is sent as plain text;
+// use SendPhotoFile for formatted captions, uploads and replies.
+func (t *TelegramNotifier) SendPhoto(ctx context.Context, chatID, photoURL, caption string) error {
+	_, err := t.SendPhotoFile(ctx, FileURL(photoURL), TelegramMediaOptions{
+		ChatID:    chatID,
+		Caption:   caption,
+		ParseMode: TelegramPlainText,
+	})
+	return err
+}
 
+// sendRequest sends a JSON request to the Telegram Bot API, decoding the
+// result into result unless it is nil
+func (t *TelegramNotifier) sendRequest(ctx context.Context, method string, payload map[string]interface{}, result interface{}) error {
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return &NotificationError{
@@ -144,28 +402,36 @@ func (t *TelegramNotifier) sendRequest(ctx context.Context, method string, paylo
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	return t.post(ctx, method, "application/json", bytes.NewBuffer(jsonData), result)
+}
+
+// post sends a request body to a Bot API method, checks the response and
+// decodes its result into result unless it is nil
+func (t *TelegramNotifier) post(ctx context.Context, method, contentType string, body io.Reader, result interface{}) error {
+	endpoint := fmt.Sprintf("%s/bot%s/%s", t.apiURL, t.botToken, method)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, body)
 	if err != nil {
 		return &NotificationError{
 			Provider: "telegram",
 			Message:  "failed to create request",
-			Err:      err,
+			Err:      t.withoutToken(err),
 		}
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 
 	resp, err := t.client.Do(req)
 	if err != nil {
 		return &NotificationError{
 			Provider: "telegram",
 			Message:  "failed to send request",
-			Err:      err,
+			Err:      t.withoutToken(err),
 		}
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return &NotificationError{
 			Provider: "telegram",
@@ -174,32 +440,82 @@ func (t *TelegramNotifier) sendRequest(ctx context.Context, method string, paylo
 		}
 	}
 
+	var response struct {
+		Ok          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	jsonErr := json.Unmarshal(respBody, &response)
+
 	if resp.StatusCode != http.StatusOK {
+		description := response.Description
+		if jsonErr != nil || description == "" {
+			description = strings.TrimSpace(string(respBody))
+		}
 		return &NotificationError{
 			Provider: "telegram",
-			Message:  fmt.Sprintf("API request failed with status %d: %s", resp.StatusCode, string(body)),
+			Message:  fmt.Sprintf("API request failed with status %d", resp.StatusCode),
+			Err:      &telegramAPIError{status: resp.StatusCode, description: description},
 		}
 	}
 
-	var result struct {
-		Ok          bool   `json:"ok"`
-		Description string `json:"description"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
+	if jsonErr != nil {
 		return &NotificationError{
 			Provider: "telegram",
 			Message:  "failed to parse response",
-			Err:      err,
+			Err:      jsonErr,
 		}
 	}
 
-	if !result.Ok {
+	if !response.Ok {
 		return &NotificationError{
 			Provider: "telegram",
-			Message:  fmt.Sprintf("API returned error: %s", result.Description),
+			Message:  "API returned error",
+			Err:      &telegramAPIError{status: resp.StatusCode, description: response.Description},
+		}
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(response.Result, result); err != nil {
+			return &NotificationError{
+				Provider: "telegram",
+				Message:  "failed to parse result",
+				Err:      err,
+			}
 		}
 	}
 
 	return nil
 }
+
+// withoutToken removes the bot token from the request URL included in
+// transport errors, so that errors can be shown to users and logged
+func (t *TelegramNotifier) withoutToken(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && t.botToken != "" {
+		urlErr.URL = strings.ReplaceAll(urlErr.URL, t.botToken, "<redacted>")
+	}
+	return err
+}
+
+// telegramAPIError is an error response of the Bot API
+type telegramAPIError struct {
+	status      int
+	description string
+}
+
+func (e *telegramAPIError) Error() string {
+	return e.description
+}
+
+// Temporary reports whether the request may succeed later: rate limits and
+// server errors are temporary, other rejections are not
+func (e *telegramAPIError) Temporary() bool {
+	return e.status == http.StatusTooManyRequests || e.status >= http.StatusInternalServerError
+}
+
+// isEntityError reports whether err is Telegram failing to parse the formatting of a text
+func isEntityError(err error) bool {
+	var apiErr *telegramAPIError
+	return errors.As(err, &apiErr) && strings.Contains(apiErr.description, "can't parse entities")
+}
@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// telegramActionsPerRow is the number of buttons in a keyboard row
+	telegramActionsPerRow = 3
+
+	// telegramMaxCallbackData is the length limit of callback data in bytes
+	telegramMaxCallbackData = 64
+)
+
+// telegramKeyboard renders actions as an inline keyboard, or returns nil
+// without actions. Callback buttons carry the action ID, joined with the
+// value by "|" if there is one, so IDs can't contain "|".
+func telegramKeyboard(actions []Action) (map[string]interface{}, error) {
+	if len(actions) == 0 {
+		return nil, nil
+	}
+
+	var rows [][]map[string]string
+	for i, action := range actions {
+		button := map[string]string{"text": action.Label}
+		if action.IsLink() {
+			button["url"] = action.URL
+		} else {
+			if strings.Contains(action.ID, "|") {
+				// the ID ends at the first "|" of the callback data
+				return nil, &NotificationError{
+					Provider: "telegram",
+					Message:  fmt.Sprintf("action ID %q must not contain \"|\"", action.ID),
+				}
+			}
+			data := telegramCallbackData(action)
+			if data == "" || len(data) > telegramMaxCallbackData {
+				return nil, &NotificationError{
+					Provider: "telegram",
+					Message:  fmt.Sprintf("callback data of action %q must be 1 to %d bytes", action.Label, telegramMaxCallbackData),
+				}
+			}
+			button["callback_data"] = data
+		}
+
+		if i%telegramActionsPerRow == 0 {
+			rows = append(rows, nil)
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], button)
+	}
+
+	return map[string]interface{}{"inline_keyboard": rows}, nil
+}
+
+// telegramCallbackData returns the callback data of a callback action
+func telegramCallbackData(action Action) string {
+	if action.Value == "" {
+		return action.ID
+	}
+	return action.ID + "|" + action.Value
+}
@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTelegramKeyboard(t *testing.T) {
+	keyboard, err := telegramKeyboard([]Action{
+		CallbackAction("ack", "Acknowledge", ""),
+		CallbackAction("silence", "Silence 1h", "1h"),
+		LinkAction("Open runbook", "https://runbooks.example.com/db"),
+		CallbackAction("resolve", "Resolve", "db-1"),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, _ := json.Marshal(keyboard)
+	want := `{"inline_keyboard":[[{"callback_data":"ack","text":"Acknowledge"},` +
+		`{"callback_data":"silence|1h","text":"Silence 1h"},` +
+		`{"text":"Open runbook","url":"https://runbooks.example.com/db"}],` +
+		`[{"callback_data":"resolve|db-1","text":"Resolve"}]]}`
+	if string(data) != want {
+		t.Errorf("Unexpected keyboard:\n%s\nwant:\n%s", data, want)
+	}
+
+	if keyboard, err := telegramKeyboard(nil); keyboard != nil || err != nil {
+		t.Errorf("Expected no keyboard without actions, got %v, %v", keyboard, err)
+	}
+	if _, err := telegramKeyboard([]Action{{Label: "No ID"}}); err == nil {
+		t.Error("Expected an error for a callback action without an ID")
+	}
+	if _, err := telegramKeyboard([]Action{CallbackAction("ack", "Ack", strings.Repeat("x", 61))}); err == nil {
+		t.Error("Expected an error for callback data over 64 bytes")
+	}
+}
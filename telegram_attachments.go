@@ -0,0 +1,158 @@
+package notify
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/milano15662/notify/richtext"
+)
+
+// telegramMaxMediaGroup is the most items Telegram accepts in a media group
+const telegramMaxMediaGroup = 10
+
+// attachmentColors maps Slack-style attachment colors to status emoji
+var attachmentColors = map[string]string{
+	"good":    "🟢",
+	"warning": "🟡",
+	"danger":  "🔴",
+}
+
+// colorEmojis are the emoji hex colors are matched against
+var colorEmojis = []struct {
+	emoji   string
+	r, g, b int
+}{
+	{"🔴", 0xdd, 0x2e, 0x44},
+	{"🟠", 0xf4, 0x90, 0x0c},
+	{"🟡", 0xfd, 0xcb, 0x58},
+	{"🟢", 0x78, 0xb1, 0x59},
+	{"🔵", 0x55, 0xac, 0xee},
+	{"🟣", 0xaa, 0x8e, 0xd6},
+	{"🟤", 0xc1, 0x69, 0x4f},
+	{"⚫", 0x31, 0x37, 0x3d},
+	{"⚪", 0xe6, 0xe7, 0xe8},
+}
+
+// colorEmoji returns the status emoji closest to an attachment color: good,
+// warning, danger or a #RRGGBB hex color
+func colorEmoji(color string) string {
+	if emoji, ok := attachmentColors[strings.ToLower(color)]; ok {
+		return emoji
+	}
+
+	hex := strings.TrimPrefix(color, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return ""
+	}
+	r, g, b := int(rgb>>16), int(rgb>>8&0xff), int(rgb&0xff)
+
+	best, bestDistance := "", -1
+	for _, c := range colorEmojis {
+		distance := (r-c.r)*(r-c.r) + (g-c.g)*(g-c.g) + (b-c.b)*(b-c.b)
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = c.emoji, distance
+		}
+	}
+	return best
+}
+
+// attachmentsDocument renders attachments as rich text: the color as a
+// status emoji before the bold title, the text, fields as aligned key/value
+// lines and the footer in italics
+func attachmentsDocument(attachments []Attachment) *richtext.Document {
+	doc := richtext.New()
+	for _, att := range attachments {
+		if att.Title == "" && att.Text == "" && len(att.Fields) == 0 && att.Footer == "" {
+			continue
+		}
+
+		var lead []richtext.Node
+		if emoji := colorEmoji(att.Color); emoji != "" {
+			lead = append(lead, richtext.Plain(emoji+" "))
+		}
+
+		var lines []richtext.Node
+		switch {
+		case att.Title != "":
+			lines = append(lines, richtext.Group(append(lead, richtext.B(richtext.Plain(att.Title)))...))
+			if att.Text != "" {
+				lines = append(lines, richtext.Plain(att.Text))
+			}
+		case att.Text != "":
+			lines = append(lines, richtext.Group(append(lead, richtext.Plain(att.Text))...))
+		}
+		if len(lines) > 0 {
+			doc.Add(richtext.Paragraph{Children: joinLines(lines)})
+		}
+
+		if len(att.Fields) > 0 {
+			doc.Add(richtext.Pre{Value: alignFields(att.Fields)})
+		}
+		if att.Footer != "" {
+			doc.Add(richtext.Paragraph{Children: []richtext.Node{richtext.I(richtext.Plain(att.Footer))}})
+		}
+	}
+	return doc.Document()
+}
+
+// joinLines separates nodes with line breaks
+func joinLines(nodes []richtext.Node) []richtext.Node {
+	joined := make([]richtext.Node, 0, 2*len(nodes))
+	for i, n := range nodes {
+		if i > 0 {
+			joined = append(joined, richtext.LineBreak{})
+		}
+		joined = append(joined, n)
+	}
+	return joined
+}
+
+// alignFields renders fields as "Key: value" lines with the values aligned
+func alignFields(fields []Field) string {
+	width := 0
+	for _, field := range fields {
+		if n := utf8.RuneCountInString(field.Title); n > width {
+			width = n
+		}
+	}
+
+	lines := make([]string, len(fields))
+	for i, field := range fields {
+		if field.Title == "" {
+			lines[i] = field.Value
+			continue
+		}
+		key := field.Title + ":" + strings.Repeat(" ", width-utf8.RuneCountInString(field.Title)+1)
+		indent := strings.Repeat(" ", width+2)
+		lines[i] = key + strings.ReplaceAll(field.Value, "\n", "\n"+indent)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sendImages sends the attachment images to a chat as a photo or media
+// groups, replying to the text message replyTo
+func (t *TelegramNotifier) sendImages(ctx context.Context, chatID string, replyTo int, msg *Message) error {
+	var photos []TelegramMedia
+	for _, att := range msg.Attachments {
+		if att.ImageURL != "" {
+			photos = append(photos, TelegramMedia{Type: TelegramPhoto, File: FileURL(att.ImageURL), Caption: att.Title})
+		}
+	}
+	if len(photos) == 0 {
+		return nil
+	}
+
+	_, err := t.SendMediaGroup(ctx, photos, TelegramMediaOptions{
+		ChatID:    chatID,
+		ParseMode: TelegramPlainText,
+		ReplyTo:   replyTo,
+		Silent:    msg.Priority == PriorityLow,
+	})
+	return err
+}
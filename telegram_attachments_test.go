@@ -0,0 +1,50 @@
+package notify
+
+import "testing"
+
+func TestColorEmoji(t *testing.T) {
+	tests := map[string]string{
+		"good":     "🟢",
+		"Warning":  "🟡",
+		"danger":   "🔴",
+		"#36a64f":  "🟢",
+		"#ff0000":  "🔴",
+		"#439FE0":  "🔵",
+		"#f90":     "🟠",
+		"":         "",
+		"teal-ish": "",
+	}
+
+	for color, want := range tests {
+		if got := colorEmoji(color); got != want {
+			t.Errorf("colorEmoji(%q) = %q, want %q", color, got, want)
+		}
+	}
+}
+
+func TestAttachmentsDocument(t *testing.T) {
+	doc := attachmentsDocument([]Attachment{
+		{
+			Title:  "Details",
+			Text:   "CPU is high",
+			Color:  "danger",
+			Fields: []Field{{Title: "Host", Value: "db-1"}, {Title: "Region", Value: "eu\nus"}},
+			Footer: "monitoring",
+		},
+		{Text: "No title", Color: "good"},
+		{ImageURL: "https://example.com/graph.png"},
+	})
+
+	want := "🔴 Details\nCPU is high\n\n" +
+		"Host:   db-1\nRegion: eu\n        us\n\n" +
+		"monitoring\n\n" +
+		"🟢 No title"
+	if got := doc.Plain(); got != want {
+		t.Errorf("Unexpected rendering:\n%s\nwant:\n%s", got, want)
+	}
+
+	markdown := doc.Render(telegramStyle(TelegramMarkdownV2))
+	if want := "🔴 *Details*\nCPU is high\n\n```\nHost:   db-1"; markdown[:len(want)] != want {
+		t.Errorf("Unexpected MarkdownV2 rendering:\n%s", markdown)
+	}
+}
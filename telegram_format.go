@@ -0,0 +1,216 @@
+package notify
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/milano15662/notify/richtext"
+)
+
+// Telegram parse modes
+const (
+	// TelegramMarkdownV2 is Telegram's MarkdownV2 (the default)
+	TelegramMarkdownV2 = "MarkdownV2"
+
+	// TelegramHTML is Telegram's HTML subset
+	TelegramHTML = "HTML"
+
+	// TelegramMarkdown is Telegram's legacy Markdown
+	TelegramMarkdown = "Markdown"
+
+	// TelegramPlainText sends messages without a parse mode
+	TelegramPlainText = "Text"
+)
+
+var (
+	markdownV2Escaper = strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+		"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+		"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+	)
+	markdownEscaper = strings.NewReplacer("_", `\_`, "*", `\*`, "`", "\\`", "[", `\[`)
+	htmlEscaper     = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+)
+
+// EscapeTelegram escapes text so Telegram shows it literally in parseMode.
+// Use it for user data in texts sent with TelegramConfig.RawText.
+func EscapeTelegram(text, parseMode string) string {
+	switch parseMode {
+	case TelegramMarkdownV2:
+		return markdownV2Escaper.Replace(text)
+	case TelegramMarkdown:
+		return markdownEscaper.Replace(text)
+	case TelegramHTML:
+		return htmlEscaper.Replace(text)
+	}
+	return text
+}
+
+// telegramCaption shortens text in parseMode to the caption length limit,
+// closing formatting open at the cut
+func telegramCaption(text, parseMode string) string {
+	if utf8.RuneCountInString(text) <= telegramMaxCaptionLength {
+		return text
+	}
+	if parseMode == TelegramPlainText {
+		return string([]rune(text)[:telegramMaxCaptionLength-1]) + "…"
+	}
+	return splitTelegramText(text, parseMode, telegramMaxCaptionLength-1, false)[0] + "…"
+}
+
+// telegramBold formats escaped text as bold in parseMode
+func telegramBold(text, parseMode string) string {
+	switch parseMode {
+	case TelegramMarkdownV2, TelegramMarkdown:
+		return "*" + text + "*"
+	case TelegramHTML:
+		return "<b>" + text + "</b>"
+	}
+	return text
+}
+
+// telegramPlain strips the formatting from text in parseMode, for resending
+// a message Telegram couldn't parse as plain text
+func telegramPlain(text, parseMode string) string {
+	if parseMode == TelegramPlainText {
+		return text
+	}
+
+	var b strings.Builder
+	for _, tok := range tokenizeTelegram(text, parseMode) {
+		switch {
+		case tok.push != nil || tok.pop != "":
+		case parseMode == TelegramHTML && strings.HasPrefix(tok.text, "<"):
+		case parseMode == TelegramHTML:
+			b.WriteString(html.UnescapeString(tok.text))
+		case strings.HasPrefix(tok.text, `\`) && len(tok.text) > 1:
+			b.WriteString(tok.text[1:])
+		case strings.HasPrefix(tok.text, "[") && len(tok.text) > 1:
+			// a [text](url) link
+			label := tok.text[1:strings.Index(tok.text, "](")]
+			url := tok.text[len(label)+3 : len(tok.text)-1]
+			b.WriteString(telegramPlain(label, parseMode) + " (" + unescapeMarkdown(url) + ")")
+		default:
+			b.WriteString(tok.text)
+		}
+	}
+	return b.String()
+}
+
+// unescapeMarkdown removes backslash escapes
+func unescapeMarkdown(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			i++
+		}
+		b.WriteByte(text[i])
+	}
+	return b.String()
+}
+
+var (
+	markdownV2CodeEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+	markdownV2LinkEscaper = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+	htmlAttributeEscaper  = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+)
+
+// telegramStyle returns the rich text style of parseMode
+func telegramStyle(parseMode string) richtext.Style {
+	switch parseMode {
+	case TelegramMarkdownV2:
+		return richtext.Style{
+			Escape: markdownV2Escaper.Replace,
+			Bold:   func(inner string) string { return "*" + inner + "*" },
+			Italic: func(inner string) string { return "_" + inner + "_" },
+			Code:   func(code string) string { return "`" + markdownV2CodeEscaper.Replace(code) + "`" },
+			Pre: func(language, code string) string {
+				return "```" + language + "\n" + markdownV2CodeEscaper.Replace(code) + "\n```"
+			},
+			Link: func(url, inner string) string {
+				if inner == "" {
+					inner = markdownV2Escaper.Replace(url)
+				}
+				return "[" + inner + "](" + markdownV2LinkEscaper.Replace(url) + ")"
+			},
+			Mention: func(id, name string) string {
+				return telegramMention(id, name, TelegramMarkdownV2)
+			},
+			Quote: func(inner string) string { return richtext.PrefixLines(inner, ">") },
+			Bullet: func(ordered bool, n int) string {
+				return markdownV2Escaper.Replace(richtext.Bullets(ordered, n))
+			},
+		}
+
+	case TelegramHTML:
+		return richtext.Style{
+			Escape: htmlEscaper.Replace,
+			Bold:   func(inner string) string { return "<b>" + inner + "</b>" },
+			Italic: func(inner string) string { return "<i>" + inner + "</i>" },
+			Code:   func(code string) string { return "<code>" + htmlEscaper.Replace(code) + "</code>" },
+			Pre: func(language, code string) string {
+				if language == "" {
+					return "<pre>" + htmlEscaper.Replace(code) + "</pre>"
+				}
+				return `<pre><code class="language-` + htmlAttributeEscaper.Replace(language) + `">` +
+					htmlEscaper.Replace(code) + "</code></pre>"
+			},
+			Link: func(url, inner string) string {
+				if inner == "" {
+					inner = htmlEscaper.Replace(url)
+				}
+				return `<a href="` + htmlAttributeEscaper.Replace(url) + `">` + inner + "</a>"
+			},
+			Mention: func(id, name string) string {
+				return telegramMention(id, name, TelegramHTML)
+			},
+			Quote:  func(inner string) string { return "<blockquote>" + inner + "</blockquote>" },
+			Bullet: richtext.Bullets,
+		}
+
+	case TelegramMarkdown:
+		// legacy Markdown can't escape inside code, so backticks are replaced
+		return richtext.Style{
+			Escape: markdownEscaper.Replace,
+			Bold:   func(inner string) string { return "*" + inner + "*" },
+			Italic: func(inner string) string { return "_" + inner + "_" },
+			Code:   func(code string) string { return "`" + strings.ReplaceAll(code, "`", "'") + "`" },
+			Pre: func(language, code string) string {
+				return "```" + language + "\n" + strings.ReplaceAll(code, "`", "'") + "\n```"
+			},
+			Link: func(url, inner string) string {
+				if inner == "" {
+					return markdownEscaper.Replace(url)
+				}
+				return "[" + inner + "](" + url + ")"
+			},
+			Mention: func(id, name string) string {
+				return telegramMention(id, name, TelegramMarkdown)
+			},
+			Quote:  func(inner string) string { return richtext.PrefixLines(inner, "> ") },
+			Bullet: richtext.Bullets,
+		}
+	}
+
+	return richtext.PlainText
+}
+
+// telegramMention links to a user by numeric ID, or shows an @username
+func telegramMention(id, name, parseMode string) string {
+	if name == "" {
+		name = id
+	}
+	if id == "" || strings.Trim(id, "0123456789") != "" {
+		return EscapeTelegram("@"+strings.TrimPrefix(name, "@"), parseMode)
+	}
+
+	url := "tg://user?id=" + id
+	switch parseMode {
+	case TelegramHTML:
+		return `<a href="` + url + `">` + htmlEscaper.Replace(name) + "</a>"
+	case TelegramMarkdownV2, TelegramMarkdown:
+		return "[" + EscapeTelegram(name, parseMode) + "](" + url + ")"
+	}
+	return "@" + name
+}
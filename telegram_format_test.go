@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEscapeTelegram(t *testing.T) {
+	tests := []struct {
+		parseMode string
+		text      string
+		want      string
+	}{
+		{TelegramMarkdownV2, "db_host-1.prod [eu] (x) *!", `db\_host\-1\.prod \[eu\] \(x\) \*\!`},
+		{TelegramMarkdownV2, `C:\tmp`, `C:\\tmp`},
+		{TelegramMarkdown, "db_host *x* `y` [z]", "db\\_host \\*x\\* \\`y\\` \\[z]"},
+		{TelegramHTML, "a < b && c > d", "a &lt; b &amp;&amp; c &gt; d"},
+		{TelegramPlainText, "<b>*as is*</b>", "<b>*as is*</b>"},
+	}
+
+	for _, tt := range tests {
+		if got := EscapeTelegram(tt.text, tt.parseMode); got != tt.want {
+			t.Errorf("EscapeTelegram(%q, %s) = %q, want %q", tt.text, tt.parseMode, got, tt.want)
+		}
+	}
+}
+
+func TestTelegramPlain(t *testing.T) {
+	tests := []struct {
+		parseMode string
+		text      string
+		want      string
+	}{
+		{TelegramMarkdownV2, `*Deploy* of v1\.2 [notes](https://example.com/a\)b)`, "Deploy of v1.2 notes (https://example.com/a)b)"},
+		{TelegramMarkdownV2, "```go\nfmt.Println()\n```", "fmt.Println()\n"},
+		{TelegramMarkdown, "unbalanced *bold", "unbalanced bold"},
+		{TelegramHTML, "<b>Error</b> in &lt;main&gt; <i>unclosed", "Error in <main> unclosed"},
+	}
+
+	for _, tt := range tests {
+		if got := telegramPlain(tt.text, tt.parseMode); got != tt.want {
+			t.Errorf("telegramPlain(%q, %s) = %q, want %q", tt.text, tt.parseMode, got, tt.want)
+		}
+	}
+}
+
+func TestTelegramCaption(t *testing.T) {
+	words := strings.Repeat("word ", 300)
+	tests := []struct {
+		parseMode string
+		text      string
+		prefix    string
+		suffix    string
+	}{
+		{TelegramPlainText, words, "word", "…"},
+		{TelegramMarkdownV2, "*" + words + "*", "*word", "*…"},
+		{TelegramHTML, "<b>" + words + "</b>", "<b>word", "</b>…"},
+	}
+
+	for _, tt := range tests {
+		got := telegramCaption(tt.text, tt.parseMode)
+		if n := utf8.RuneCountInString(got); n > telegramMaxCaptionLength {
+			t.Errorf("%s: expected at most %d runes, got %d", tt.parseMode, telegramMaxCaptionLength, n)
+		}
+		if !strings.HasPrefix(got, tt.prefix) || !strings.HasSuffix(got, tt.suffix) {
+			t.Errorf("%s: expected the formatting to be closed before the ellipsis, got %q", tt.parseMode, got)
+		}
+	}
+
+	if got := telegramCaption("*short*", TelegramMarkdownV2); got != "*short*" {
+		t.Errorf("Expected short captions to be unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,249 @@
+package notify
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// telegramPollTimeout is the longest getUpdates long polling timeout
+	telegramPollTimeout = 25 * time.Second
+
+	// telegramPollRetry is the delay before polling again after an error
+	telegramPollRetry = 5 * time.Second
+
+	// telegramPollMargin is the time left to the HTTP client to receive a
+	// getUpdates response after the long polling timeout
+	telegramPollMargin = 5 * time.Second
+)
+
+// telegramUpdate is the part of an Update the notifier handles
+type telegramUpdate struct {
+	UpdateID      int `json:"update_id"`
+	CallbackQuery *struct {
+		ID   string `json:"id"`
+		From struct {
+			ID        int64  `json:"id"`
+			Username  string `json:"username"`
+			FirstName string `json:"first_name"`
+		} `json:"from"`
+		Message *struct {
+			MessageID int `json:"message_id"`
+			Chat      struct {
+				ID int64 `json:"id"`
+			} `json:"chat"`
+		} `json:"message"`
+		Data string `json:"data"`
+	} `json:"callback_query"`
+}
+
+// WebhookHandler returns the HTTP handler of the bot's webhook, set with
+// setWebhook. It dispatches callback queries to interactions, which can
+// reply and update messages through the notifier. Requests must carry
+// secretToken, the secret_token passed to setWebhook, in
+// X-Telegram-Bot-Api-Secret-Token. Without a secretToken, every request is
+// rejected.
+func (t *TelegramNotifier) WebhookHandler(secretToken string, interactions *Interactions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if secretToken == "" {
+			// the webhook URL alone doesn't authenticate Telegram
+			http.Error(w, "secret token not configured", http.StatusInternalServerError)
+			return
+		}
+
+		token := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secretToken)) != 1 {
+			http.Error(w, "invalid secret token", http.StatusUnauthorized)
+			return
+		}
+
+		var update telegramUpdate
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxInteractionBytes)).Decode(&update); err != nil {
+			http.Error(w, "invalid update", http.StatusBadRequest)
+			return
+		}
+
+		// Telegram redelivers failed updates, so failures are only
+		// reported to the user
+		t.handleUpdate(r.Context(), update, interactions)
+	})
+}
+
+// PollInteractions receives callback queries with getUpdates long polling
+// and dispatches them to interactions until ctx is done. Telegram doesn't
+// deliver updates by polling while a webhook is set. The HTTP client's
+// timeout, when set, must be at least 6 seconds to leave time for long
+// polling.
+func (t *TelegramNotifier) PollInteractions(ctx context.Context, interactions *Interactions) error {
+	timeout, err := t.pollTimeout()
+	if err != nil {
+		return err
+	}
+
+	offset := 0
+	for {
+		var updates []telegramUpdate
+		payload := map[string]interface{}{
+			"offset":          offset,
+			"timeout":         int(timeout / time.Second),
+			"allowed_updates": []string{"callback_query"},
+		}
+		err := t.sendRequest(ctx, "getUpdates", payload, &updates)
+
+		var apiErr *telegramAPIError
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.As(err, &apiErr) && (apiErr.status == http.StatusUnauthorized ||
+			apiErr.status == http.StatusNotFound || apiErr.status == http.StatusConflict):
+			// a wrong token or a webhook won't go away by retrying
+			return err
+		case err != nil:
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(telegramPollRetry):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			t.handleUpdate(ctx, update, interactions)
+			offset = update.UpdateID + 1
+		}
+	}
+}
+
+// pollTimeout returns the long polling timeout, leaving the HTTP client
+// time to receive the response. A client timeout too short for a timeout of
+// at least a second is an error, since polling would turn into a busy loop.
+func (t *TelegramNotifier) pollTimeout() (time.Duration, error) {
+	timeout := telegramPollTimeout
+	if t.client.Timeout > 0 && t.client.Timeout-telegramPollMargin < timeout {
+		timeout = (t.client.Timeout - telegramPollMargin).Truncate(time.Second)
+	}
+	if timeout < time.Second {
+		return 0, &NotificationError{
+			Provider: "telegram",
+			Message:  fmt.Sprintf("HTTP client timeout %s is too short for long polling (want at least %s)", t.client.Timeout, telegramPollMargin+time.Second),
+		}
+	}
+	return timeout, nil
+}
+
+// handleUpdate dispatches a callback query and answers it, showing the
+// user whether the action failed
+func (t *TelegramNotifier) handleUpdate(ctx context.Context, update telegramUpdate, interactions *Interactions) {
+	query := update.CallbackQuery
+	if query == nil {
+		return
+	}
+
+	actionID, value, _ := strings.Cut(query.Data, "|")
+	in := &Interaction{
+		Provider:  t.name,
+		User:      User{ID: strconv.FormatInt(query.From.ID, 10), Name: query.From.Username},
+		ActionID:  actionID,
+		Value:     value,
+		responder: t,
+	}
+	if in.User.Name == "" {
+		in.User.Name = query.From.FirstName
+	}
+	if query.Message != nil {
+		in.MessageRef = MessageRef{
+			Channel: strconv.FormatInt(query.Message.Chat.ID, 10),
+			ID:      strconv.Itoa(query.Message.MessageID),
+		}
+	}
+
+	answer := map[string]interface{}{"callback_query_id": query.ID}
+	if err := interactions.Dispatch(ctx, in); err != nil {
+		answer["text"] = "Action failed"
+	}
+	t.sendRequest(ctx, "answerCallbackQuery", answer, nil)
+}
+
+// reply sends msg as a reply to a message
+func (t *TelegramNotifier) reply(ctx context.Context, ref MessageRef, msg *Message) error {
+	replyTo, err := telegramMessageID(ref)
+	if err != nil {
+		return err
+	}
+	keyboard, err := telegramKeyboard(msg.Actions)
+	if err != nil {
+		return err
+	}
+
+	messageID, err := t.sendText(ctx, ref.Channel, t.format(msg), keyboard, replyTo, msg)
+	if err != nil {
+		return err
+	}
+	return t.sendImages(ctx, ref.Channel, messageID, msg)
+}
+
+// update replaces the text and keyboard of a message by msg
+func (t *TelegramNotifier) update(ctx context.Context, ref MessageRef, msg *Message) error {
+	messageID, err := telegramMessageID(ref)
+	if err != nil {
+		return err
+	}
+	keyboard, err := telegramKeyboard(msg.Actions)
+	if err != nil {
+		return err
+	}
+
+	text := t.format(msg)
+	err = t.editMessage(ctx, ref.Channel, messageID, text, t.parseMode, keyboard)
+	if isEntityError(err) {
+		err = t.editMessage(ctx, ref.Channel, messageID, telegramPlain(text, t.parseMode), TelegramPlainText, keyboard)
+	}
+	return err
+}
+
+// editMessage replaces the text and keyboard of a message. Editing a
+// message without changes succeeds.
+func (t *TelegramNotifier) editMessage(ctx context.Context, chatID string, messageID int, text, parseMode string, keyboard map[string]interface{}) error {
+	payload := map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": messageID,
+		"text":       text,
+	}
+	if parseMode != TelegramPlainText {
+		payload["parse_mode"] = parseMode
+	}
+	if keyboard != nil {
+		payload["reply_markup"] = keyboard
+	}
+
+	err := t.sendRequest(ctx, "editMessageText", payload, nil)
+	var apiErr *telegramAPIError
+	if errors.As(err, &apiErr) && strings.Contains(apiErr.description, "message is not modified") {
+		return nil
+	}
+	return err
+}
+
+// telegramMessageID returns the message ID of ref
+func telegramMessageID(ref MessageRef) (int, error) {
+	messageID, err := strconv.Atoi(ref.ID)
+	if err != nil || ref.Channel == "" {
+		return 0, &NotificationError{
+			Provider: "telegram",
+			Message:  "interaction has no message",
+			Err:      err,
+		}
+	}
+	return messageID, nil
+}
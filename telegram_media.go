@@ -0,0 +1,431 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Telegram media types
+const (
+	TelegramPhoto    = "photo"
+	TelegramDocument = "document"
+	TelegramVideo    = "video"
+	TelegramAudio    = "audio"
+)
+
+// TelegramFile is a file to send: a URL or file_id, a local file or a
+// reader. Local files and readers are uploaded as multipart/form-data.
+type TelegramFile struct {
+	// URL is an HTTP URL Telegram downloads, or the file_id of a file
+	// already on Telegram's servers
+	URL string
+
+	// Path is a local file to upload
+	Path string
+
+	// Reader is read and uploaded as Name
+	Reader io.Reader
+
+	// Name is the filename of an uploaded Reader (defaults to "file")
+	Name string
+}
+
+// FileURL returns a file Telegram downloads from url, or a file_id
+func FileURL(url string) TelegramFile {
+	return TelegramFile{URL: url}
+}
+
+// FilePath returns a local file to upload
+func FilePath(path string) TelegramFile {
+	return TelegramFile{Path: path}
+}
+
+// FileReader returns a file uploaded from r under name
+func FileReader(name string, r io.Reader) TelegramFile {
+	return TelegramFile{Name: name, Reader: r}
+}
+
+// TelegramMedia is an item of a media group
+type TelegramMedia struct {
+	// Type is TelegramPhoto, TelegramVideo, TelegramDocument or TelegramAudio
+	Type string
+
+	File TelegramFile
+
+	// Caption is shown below the item
+	Caption string
+}
+
+// TelegramMediaOptions are the options of sending media
+type TelegramMediaOptions struct {
+	// ChatID is the chat to send to (defaults to the first default chat)
+	ChatID string
+
+	// Caption is shown below the media, escaped like message text unless
+	// RawText is set. Media groups show it below the first item.
+	Caption string
+
+	// ParseMode is the parse mode of captions (defaults to the notifier's)
+	ParseMode string
+
+	// ReplyTo is the ID of a message to reply to
+	ReplyTo int
+
+	// Silent sends the media without a notification sound
+	Silent bool
+
+	// Actions are buttons shown below the media. Media groups can't have
+	// buttons.
+	Actions []Action
+}
+
+// SendDocument sends a document and returns the ID of the message
+func (t *TelegramNotifier) SendDocument(ctx context.Context, file TelegramFile, opts TelegramMediaOptions) (int, error) {
+	return t.sendMedia(ctx, TelegramMedia{Type: TelegramDocument, File: file, Caption: opts.Caption}, opts)
+}
+
+// SendPhotoFile sends a photo and returns the ID of the message
+func (t *TelegramNotifier) SendPhotoFile(ctx context.Context, file TelegramFile, opts TelegramMediaOptions) (int, error) {
+	return t.sendMedia(ctx, TelegramMedia{Type: TelegramPhoto, File: file, Caption: opts.Caption}, opts)
+}
+
+// SendVideo sends a video and returns the ID of the message
+func (t *TelegramNotifier) SendVideo(ctx context.Context, file TelegramFile, opts TelegramMediaOptions) (int, error) {
+	return t.sendMedia(ctx, TelegramMedia{Type: TelegramVideo, File: file, Caption: opts.Caption}, opts)
+}
+
+// SendAudio sends an audio file and returns the ID of the message
+func (t *TelegramNotifier) SendAudio(ctx context.Context, file TelegramFile, opts TelegramMediaOptions) (int, error) {
+	return t.sendMedia(ctx, TelegramMedia{Type: TelegramAudio, File: file, Caption: opts.Caption}, opts)
+}
+
+// SendMediaGroup sends photos, videos, documents or audio files as albums
+// and returns the IDs of the messages. Telegram groups up to 10 items, so
+// more are sent as several groups, and a single item on its own. Documents
+// and audio files can only be grouped with items of the same type.
+func (t *TelegramNotifier) SendMediaGroup(ctx context.Context, media []TelegramMedia, opts TelegramMediaOptions) ([]int, error) {
+	if len(media) == 0 {
+		return nil, &NotificationError{
+			Provider: "telegram",
+			Message:  "media group is empty",
+		}
+	}
+
+	media = append([]TelegramMedia(nil), media...)
+	if opts.Caption != "" && media[0].Caption == "" {
+		media[0].Caption = opts.Caption
+	}
+
+	var ids []int
+	for len(media) > 0 {
+		n := len(media)
+		if n > telegramMaxMediaGroup {
+			n = telegramMaxMediaGroup
+		}
+		group := media[:n]
+		media = media[n:]
+
+		var groupIDs []int
+		var err error
+		if len(group) == 1 {
+			var id int
+			id, err = t.sendMedia(ctx, group[0], opts)
+			groupIDs = []int{id}
+		} else {
+			groupIDs, err = t.sendGroup(ctx, group, opts)
+		}
+		switch {
+		case err != nil && len(ids) > 0:
+			// earlier groups were delivered
+			return ids, partialDelivery(err)
+		case err != nil:
+			return ids, err
+		}
+		ids = append(ids, groupIDs...)
+	}
+
+	return ids, nil
+}
+
+// SendFiles sends files as documents in a media group, captioned with their
+// titles. opts.Comment is the caption of the first file if it has no title,
+// and opts.ThreadID the ID of a message to reply to.
+func (t *TelegramNotifier) SendFiles(ctx context.Context, files []File, opts FileOptions) error {
+	mediaOpts := TelegramMediaOptions{ChatID: opts.Channel, Caption: opts.Comment}
+	if opts.ThreadID != "" {
+		replyTo, err := strconv.Atoi(opts.ThreadID)
+		if err != nil {
+			return &NotificationError{
+				Provider: "telegram",
+				Message:  fmt.Sprintf("invalid message ID %q", opts.ThreadID),
+				Err:      err,
+			}
+		}
+		mediaOpts.ReplyTo = replyTo
+	}
+
+	media := make([]TelegramMedia, len(files))
+	for i, file := range files {
+		media[i] = TelegramMedia{Type: TelegramDocument, File: FileReader(file.name(), file.Reader), Caption: file.Title}
+	}
+
+	_, err := t.SendMediaGroup(ctx, media, mediaOpts)
+	return err
+}
+
+// sendMedia sends a single media item
+func (t *TelegramNotifier) sendMedia(ctx context.Context, media TelegramMedia, opts TelegramMediaOptions) (int, error) {
+	method, err := telegramMediaMethod(media.Type)
+	if err != nil {
+		return 0, err
+	}
+
+	params, err := t.mediaParams(opts)
+	if err != nil {
+		return 0, err
+	}
+	t.setCaption(params, media.Caption, opts)
+
+	upload, err := media.File.open(media.Type)
+	if err != nil {
+		return 0, err
+	}
+	var uploads []telegramUpload
+	if upload.reader != nil {
+		defer upload.close()
+		uploads = append(uploads, upload)
+	} else {
+		params[media.Type] = upload.value
+	}
+
+	var sent telegramMessage
+	err = t.upload(ctx, method, params, uploads, &sent)
+	return sent.MessageID, err
+}
+
+// sendGroup sends 2 to 10 items as a media group
+func (t *TelegramNotifier) sendGroup(ctx context.Context, group []TelegramMedia, opts TelegramMediaOptions) ([]int, error) {
+	if len(opts.Actions) > 0 {
+		return nil, &NotificationError{
+			Provider: "telegram",
+			Message:  "media groups can't have actions",
+		}
+	}
+
+	params, err := t.mediaParams(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var uploads []telegramUpload
+	defer func() {
+		for _, upload := range uploads {
+			upload.close()
+		}
+	}()
+
+	items := make([]map[string]interface{}, len(group))
+	for i, media := range group {
+		if _, err := telegramMediaMethod(media.Type); err != nil {
+			return nil, err
+		}
+
+		field := "file" + strconv.Itoa(i)
+		upload, err := media.File.open(field)
+		if err != nil {
+			return nil, err
+		}
+
+		item := map[string]interface{}{"type": media.Type, "media": upload.value}
+		if upload.reader != nil {
+			uploads = append(uploads, upload)
+			item["media"] = "attach://" + field
+		}
+		t.setCaption(item, media.Caption, opts)
+		items[i] = item
+	}
+
+	params["media"] = items
+
+	var sent []telegramMessage
+	if err := t.upload(ctx, "sendMediaGroup", params, uploads, &sent); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(sent))
+	for i, message := range sent {
+		ids[i] = message.MessageID
+	}
+	return ids, nil
+}
+
+// mediaParams returns the request parameters of opts
+func (t *TelegramNotifier) mediaParams(opts TelegramMediaOptions) (map[string]interface{}, error) {
+	chatID := opts.ChatID
+	if chatID == "" {
+		chatID = t.chatID
+	}
+
+	params := map[string]interface{}{"chat_id": chatID}
+	if opts.ReplyTo != 0 {
+		params["reply_parameters"] = map[string]interface{}{
+			"message_id":                  opts.ReplyTo,
+			"allow_sending_without_reply": true,
+		}
+	}
+	if opts.Silent {
+		params["disable_notification"] = true
+	}
+
+	keyboard, err := telegramKeyboard(opts.Actions)
+	if err != nil {
+		return nil, err
+	}
+	if keyboard != nil {
+		params["reply_markup"] = keyboard
+	}
+	return params, nil
+}
+
+// setCaption sets the escaped caption and its parse mode in params
+func (t *TelegramNotifier) setCaption(params map[string]interface{}, caption string, opts TelegramMediaOptions) {
+	if caption == "" {
+		return
+	}
+
+	parseMode := opts.ParseMode
+	if parseMode == "" {
+		parseMode = t.parseMode
+	}
+	if t.rawText && parseMode != TelegramPlainText {
+		caption = telegramCaption(caption, parseMode)
+	} else {
+		caption = EscapeTelegram(telegramCaption(caption, TelegramPlainText), parseMode)
+	}
+
+	params["caption"] = caption
+	if parseMode != TelegramPlainText {
+		params["parse_mode"] = parseMode
+	}
+}
+
+// telegramMediaMethod returns the Bot API method sending a media type
+func telegramMediaMethod(mediaType string) (string, error) {
+	switch mediaType {
+	case TelegramPhoto:
+		return "sendPhoto", nil
+	case TelegramDocument:
+		return "sendDocument", nil
+	case TelegramVideo:
+		return "sendVideo", nil
+	case TelegramAudio:
+		return "sendAudio", nil
+	}
+
+	return "", &NotificationError{
+		Provider: "telegram",
+		Message:  fmt.Sprintf("unknown media type %q", mediaType),
+	}
+}
+
+// telegramUpload is a file part of a multipart request, or the URL of a
+// file that isn't uploaded
+type telegramUpload struct {
+	field  string
+	name   string
+	value  string
+	reader io.Reader
+	closer io.Closer
+}
+
+func (u telegramUpload) close() {
+	if u.closer != nil {
+		u.closer.Close()
+	}
+}
+
+// open prepares the file for a request, uploading it as field
+func (f TelegramFile) open(field string) (telegramUpload, error) {
+	switch {
+	case f.Reader != nil:
+		name := f.Name
+		if name == "" {
+			name = "file"
+		}
+		return telegramUpload{field: field, name: name, reader: f.Reader}, nil
+
+	case f.Path != "":
+		file, err := os.Open(f.Path)
+		if err != nil {
+			return telegramUpload{}, &NotificationError{
+				Provider: "telegram",
+				Message:  "failed to open file",
+				Err:      err,
+			}
+		}
+		return telegramUpload{field: field, name: filepath.Base(f.Path), reader: file, closer: file}, nil
+
+	case f.URL != "":
+		return telegramUpload{value: f.URL}, nil
+	}
+
+	return telegramUpload{}, &NotificationError{
+		Provider: "telegram",
+		Message:  "file has no URL, path or reader",
+	}
+}
+
+// upload sends a multipart request streaming the uploaded files, or a JSON
+// request when there are none
+func (t *TelegramNotifier) upload(ctx context.Context, method string, params map[string]interface{}, uploads []telegramUpload, result interface{}) error {
+	if len(uploads) == 0 {
+		return t.sendRequest(ctx, method, params, result)
+	}
+
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+
+	go func() {
+		writer.CloseWithError(writeForm(form, params, uploads))
+	}()
+
+	err := t.post(ctx, method, form.FormDataContentType(), body, result)
+	body.Close()
+	return err
+}
+
+// writeForm writes params and uploads as a multipart form. Parameters other
+// than strings are JSON-encoded.
+func writeForm(form *multipart.Writer, params map[string]interface{}, uploads []telegramUpload) error {
+	for name, value := range params {
+		field, ok := value.(string)
+		if !ok {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			field = string(data)
+		}
+		if err := form.WriteField(name, field); err != nil {
+			return err
+		}
+	}
+
+	for _, upload := range uploads {
+		part, err := form.CreateFormFile(upload.field, upload.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, upload.reader); err != nil {
+			return err
+		}
+	}
+
+	return form.Close()
+}
@@ -0,0 +1,403 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TelegramMaxMessageLength is the longest text Telegram accepts in a message
+const TelegramMaxMessageLength = 4096
+
+// telegramMaxCaptionLength is the longest caption Telegram accepts
+const telegramMaxCaptionLength = 1024
+
+// Ways of sending texts longer than the message limit
+const (
+	// TelegramLongMessageSplit sends long texts as several messages
+	TelegramLongMessageSplit = "split"
+
+	// TelegramLongMessageDocument sends long texts as a text document
+	TelegramLongMessageDocument = "document"
+)
+
+// markerReserve is the room kept for a "(12/34)" chunk marker
+const markerReserve = len("\n\n\\(999\\/999\\)")
+
+// telegramEntity is formatting open at some point of a text, which a chunk
+// boundary has to close and the next chunk reopen
+type telegramEntity struct {
+	open  string
+	close string
+}
+
+// telegramToken is an indivisible piece of text, possibly opening or closing
+// formatting
+type telegramToken struct {
+	text string
+
+	// push opens an entity, pop closes the innermost entity with that close text
+	push *telegramEntity
+	pop  string
+}
+
+// splitTelegramText splits text into chunks of at most limit runes. Chunks
+// break at paragraphs, lines or words where possible and never inside an
+// escape sequence, HTML tag or link; formatting and code blocks open at a
+// boundary are closed and reopened in the next chunk. With markers, room is
+// left for a chunk marker.
+func splitTelegramText(text, parseMode string, limit int, markers bool) []string {
+	if utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+
+	if markers {
+		limit -= markerReserve
+	}
+
+	tokens := tokenizeTelegram(text, parseMode)
+
+	// stacks[i] is the formatting open before tokens[i]
+	stacks := make([][]telegramEntity, len(tokens)+1)
+	var stack []telegramEntity
+	for i, tok := range tokens {
+		stacks[i] = stack
+		switch {
+		case tok.push != nil:
+			stack = append(stack[:len(stack):len(stack)], *tok.push)
+		case tok.pop != "":
+			for j := len(stack) - 1; j >= 0; j-- {
+				if stack[j].close == tok.pop {
+					stack = stack[:j:j]
+					break
+				}
+			}
+		}
+	}
+	stacks[len(tokens)] = stack
+
+	var chunks []string
+	for start := 0; start < len(tokens); {
+		// skip blank lines between chunks outside of code
+		for start < len(tokens) && len(stacks[start]) == 0 && strings.TrimSpace(tokens[start].text) == "" {
+			start++
+		}
+		if start == len(tokens) {
+			break
+		}
+
+		end := telegramBreak(tokens, stacks, start, limit)
+
+		var b strings.Builder
+		for _, entity := range stacks[start] {
+			b.WriteString(entity.open)
+		}
+		for _, tok := range tokens[start:end] {
+			b.WriteString(tok.text)
+		}
+		chunk := b.String()
+		if len(stacks[end]) == 0 {
+			chunk = strings.TrimRightFunc(chunk, unicode.IsSpace)
+		}
+		b.Reset()
+		b.WriteString(chunk)
+		open := stacks[end]
+		for i := len(open) - 1; i >= 0; i-- {
+			b.WriteString(open[i].close)
+		}
+
+		chunks = append(chunks, b.String())
+		start = end
+	}
+
+	if markers && len(chunks) > 1 {
+		for i := range chunks {
+			chunks[i] += "\n\n" + telegramMarker(i+1, len(chunks), parseMode)
+		}
+	}
+
+	return chunks
+}
+
+// telegramBreak returns the token index the chunk starting at start should
+// end at. It prefers paragraph, line and word breaks outside formatting,
+// in the second half of the chunk if there are any, and cuts at the limit
+// when there are none.
+func telegramBreak(tokens []telegramToken, stacks [][]telegramEntity, start, limit int) int {
+	width := 0
+	for _, entity := range stacks[start] {
+		width += utf8.RuneCountInString(entity.open)
+	}
+
+	best, bestScore, last := start+1, 0, start+1
+	for end := start + 1; end <= len(tokens); end++ {
+		width += utf8.RuneCountInString(tokens[end-1].text)
+
+		closing := 0
+		for _, entity := range stacks[end] {
+			closing += utf8.RuneCountInString(entity.close)
+		}
+		if width+closing > limit && end > start+1 {
+			break
+		}
+		last = end
+
+		score := breakScore(tokens, stacks, end)
+		if score > 0 && width >= limit/2 {
+			score += 16
+		}
+		if score > 0 && score >= bestScore {
+			best, bestScore = end, score
+		}
+	}
+
+	if bestScore == 0 {
+		return last
+	}
+	return best
+}
+
+// breakScore rates a chunk boundary before tokens[end]
+func breakScore(tokens []telegramToken, stacks [][]telegramEntity, end int) int {
+	if end == len(tokens) {
+		return 8
+	}
+
+	score := 0
+	previous := tokens[end-1].text
+	switch {
+	case previous == "\n" && end >= 2 && tokens[end-2].text == "\n":
+		score = 3
+	case previous == "\n":
+		score = 2
+	case strings.TrimSpace(previous) == "":
+		score = 1
+	}
+	if len(stacks[end]) == 0 {
+		score += 4
+	}
+	return score
+}
+
+// telegramMarker formats a "(1/3)" chunk marker for parseMode
+func telegramMarker(part, parts int, parseMode string) string {
+	if parseMode == "MarkdownV2" {
+		return fmt.Sprintf("\\(%d/%d\\)", part, parts)
+	}
+	return fmt.Sprintf("(%d/%d)", part, parts)
+}
+
+// tokenizeTelegram splits text into tokens according to parseMode
+func tokenizeTelegram(text, parseMode string) []telegramToken {
+	switch parseMode {
+	case "Markdown", "MarkdownV2":
+		return tokenizeMarkdown(text, parseMode == "MarkdownV2")
+	case "HTML":
+		return tokenizeHTML(text)
+	}
+	return tokenizeRunes(text)
+}
+
+// tokenizeRunes makes every rune of text a token
+func tokenizeRunes(text string) []telegramToken {
+	tokens := make([]telegramToken, 0, len(text))
+	for _, r := range text {
+		tokens = append(tokens, telegramToken{text: string(r)})
+	}
+	return tokens
+}
+
+// tokenizeMarkdown tokenizes Telegram Markdown, or MarkdownV2 when v2 is set
+func tokenizeMarkdown(text string, v2 bool) []telegramToken {
+	delimiters := []string{"*", "_"}
+	if v2 {
+		delimiters = []string{"||", "__", "*", "_", "~"}
+	}
+
+	var tokens []telegramToken
+	var open []string
+	isOpen := func(delimiter string) bool {
+		for _, d := range open {
+			if d == delimiter {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+
+		switch {
+		case strings.HasPrefix(rest, "```"):
+			// code block: an opening fence with its language, the code, the closing fence
+			fence := "```"
+			if nl := strings.IndexByte(rest[3:], '\n'); nl >= 0 && !strings.Contains(rest[3:3+nl], "`") {
+				fence = rest[:3+nl+1]
+			}
+			end := strings.Index(rest[len(fence):], "```")
+			if end < 0 {
+				tokens = append(tokens, tokenizeRunes(rest)...)
+				return tokens
+			}
+			tokens = append(tokens, telegramToken{text: fence, push: &telegramEntity{open: fence, close: "```"}})
+			tokens = append(tokens, tokenizeCode(rest[len(fence):len(fence)+end], v2)...)
+			tokens = append(tokens, telegramToken{text: "```", pop: "```"})
+			i += len(fence) + end + 3
+			continue
+
+		case rest[0] == '`':
+			end := strings.IndexByte(rest[1:], '`')
+			if end < 0 {
+				break
+			}
+			tokens = append(tokens, telegramToken{text: "`", push: &telegramEntity{open: "`", close: "`"}})
+			tokens = append(tokens, tokenizeCode(rest[1:1+end], v2)...)
+			tokens = append(tokens, telegramToken{text: "`", pop: "`"})
+			i += end + 2
+			continue
+
+		case rest[0] == '\\' && len(rest) > 1:
+			_, size := utf8.DecodeRuneInString(rest[1:])
+			tokens = append(tokens, telegramToken{text: rest[:1+size]})
+			i += 1 + size
+			continue
+
+		case rest[0] == '[':
+			if n := markdownLinkLength(rest); n > 0 {
+				tokens = append(tokens, telegramToken{text: rest[:n]})
+				i += n
+				continue
+			}
+		}
+
+		matched := false
+		for _, d := range delimiters {
+			if !strings.HasPrefix(rest, d) {
+				continue
+			}
+			if isOpen(d) {
+				tokens = append(tokens, telegramToken{text: d, pop: d})
+				for j := len(open) - 1; j >= 0; j-- {
+					if open[j] == d {
+						open = open[:j]
+						break
+					}
+				}
+			} else {
+				tokens = append(tokens, telegramToken{text: d, push: &telegramEntity{open: d, close: d}})
+				open = append(open, d)
+			}
+			i += len(d)
+			matched = true
+			break
+		}
+		if matched {
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(rest)
+		tokens = append(tokens, telegramToken{text: rest[:size]})
+		i += size
+	}
+
+	return tokens
+}
+
+// tokenizeCode tokenizes the inside of a code entity, where MarkdownV2 still
+// has escapes
+func tokenizeCode(code string, v2 bool) []telegramToken {
+	if !v2 {
+		return tokenizeRunes(code)
+	}
+
+	var tokens []telegramToken
+	for i := 0; i < len(code); {
+		_, size := utf8.DecodeRuneInString(code[i:])
+		if code[i] == '\\' && i+1 < len(code) {
+			_, next := utf8.DecodeRuneInString(code[i+1:])
+			size += next
+		}
+		tokens = append(tokens, telegramToken{text: code[i : i+size]})
+		i += size
+	}
+	return tokens
+}
+
+// markdownLinkLength returns the length of the [text](url) link text starts
+// with, or 0 if it doesn't start with one
+func markdownLinkLength(text string) int {
+	closing := -1
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '\n':
+			return 0
+		case ']':
+			closing = i
+		}
+		if closing >= 0 {
+			break
+		}
+	}
+	if closing < 0 || !strings.HasPrefix(text[closing+1:], "(") {
+		return 0
+	}
+
+	for i := closing + 2; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '\n':
+			return 0
+		case ')':
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// tokenizeHTML tokenizes Telegram HTML, keeping tags and character
+// references whole
+func tokenizeHTML(text string) []telegramToken {
+	var tokens []telegramToken
+	for i := 0; i < len(text); {
+		rest := text[i:]
+
+		switch rest[0] {
+		case '<':
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				break
+			}
+			tag := rest[:end+1]
+			name := strings.TrimPrefix(tag[1:len(tag)-1], "/")
+			if n := strings.IndexAny(name, " \t\n"); n >= 0 {
+				name = name[:n]
+			}
+			name = strings.ToLower(name)
+
+			if strings.HasPrefix(tag, "</") {
+				tokens = append(tokens, telegramToken{text: tag, pop: "</" + name + ">"})
+			} else {
+				tokens = append(tokens, telegramToken{text: tag, push: &telegramEntity{open: tag, close: "</" + name + ">"}})
+			}
+			i += len(tag)
+			continue
+
+		case '&':
+			if end := strings.IndexByte(rest, ';'); end > 0 && end <= 10 && !strings.ContainsAny(rest[1:end], " \n&<") {
+				tokens = append(tokens, telegramToken{text: rest[:end+1]})
+				i += end + 1
+				continue
+			}
+		}
+
+		_, size := utf8.DecodeRuneInString(rest)
+		tokens = append(tokens, telegramToken{text: rest[:size]})
+		i += size
+	}
+	return tokens
+}
@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func checkChunks(t *testing.T, chunks []string, limit int) {
+	t.Helper()
+	for i, chunk := range chunks {
+		if n := utf8.RuneCountInString(chunk); n > limit {
+			t.Errorf("Chunk %d has %d runes, over the limit of %d", i, n, limit)
+		}
+	}
+}
+
+func TestSplitTelegramTextShort(t *testing.T) {
+	chunks := splitTelegramText("short *text*", "Markdown", 100, true)
+	if len(chunks) != 1 || chunks[0] != "short *text*" {
+		t.Errorf("Expected text unchanged, got %q", chunks)
+	}
+}
+
+func TestSplitTelegramTextParagraphs(t *testing.T) {
+	paragraph := strings.Repeat("word ", 15) + "end."
+	text := strings.Join([]string{paragraph, paragraph, paragraph, paragraph}, "\n\n")
+
+	chunks := splitTelegramText(text, "", 200, false)
+	checkChunks(t, chunks, 200)
+
+	if len(chunks) != 2 {
+		t.Fatalf("Expected 2 chunks, got %d: %q", len(chunks), chunks)
+	}
+	for _, chunk := range chunks {
+		if !strings.HasSuffix(chunk, "end.") || !strings.HasPrefix(chunk, "word") {
+			t.Errorf("Expected chunks to break between paragraphs, got %q", chunk)
+		}
+	}
+	if strings.Join(chunks, "\n\n") != text {
+		t.Error("Expected chunks to add up to the text")
+	}
+}
+
+func TestSplitTelegramTextWords(t *testing.T) {
+	text := strings.Repeat("lorem ipsum ", 100)
+
+	chunks := splitTelegramText(text, "", 100, false)
+	checkChunks(t, chunks, 100)
+
+	for _, chunk := range chunks {
+		if strings.HasPrefix(chunk, " ") || strings.HasSuffix(chunk, "lore") {
+			t.Errorf("Expected chunks to break between words, got %q", chunk)
+		}
+	}
+}
+
+func TestSplitTelegramTextHardCut(t *testing.T) {
+	text := strings.Repeat("x", 250)
+
+	chunks := splitTelegramText(text, "", 100, false)
+	checkChunks(t, chunks, 100)
+
+	if len(chunks) != 3 || strings.Join(chunks, "") != text {
+		t.Errorf("Expected 3 chunks adding up to the text, got %q", chunks)
+	}
+}
+
+func TestSplitTelegramTextCodeBlock(t *testing.T) {
+	var trace strings.Builder
+	for i := 0; i < 40; i++ {
+		trace.WriteString("at main.handler(server.go:42)\n")
+	}
+	text := "*Panic*\n\n```go\n" + trace.String() + "```"
+
+	for _, parseMode := range []string{"Markdown", "MarkdownV2"} {
+		chunks := splitTelegramText(text, parseMode, 300, false)
+		checkChunks(t, chunks, 300)
+
+		if len(chunks) < 4 {
+			t.Fatalf("%s: expected the trace to be split, got %d chunks", parseMode, len(chunks))
+		}
+		for i, chunk := range chunks {
+			if strings.Count(chunk, "```")%2 != 0 {
+				t.Errorf("%s: chunk %d has an unclosed code block: %q", parseMode, i, chunk)
+			}
+			if i > 0 && !strings.HasPrefix(chunk, "```go\n") {
+				t.Errorf("%s: expected chunk %d to reopen the code block, got %q", parseMode, i, chunk)
+			}
+			if !strings.HasSuffix(chunk, ")\n```") {
+				t.Errorf("%s: expected chunk %d to end at a line break, got %q", parseMode, i, chunk)
+			}
+		}
+	}
+}
+
+func TestSplitTelegramTextHTML(t *testing.T) {
+	text := "<b>Error</b> &lt;main&gt;\n<pre><code class=\"language-go\">" +
+		strings.Repeat("panic: runtime error &amp; more\n", 20) + "</code></pre>"
+
+	chunks := splitTelegramText(text, "HTML", 200, false)
+	checkChunks(t, chunks, 200)
+
+	for i, chunk := range chunks {
+		if strings.Count(chunk, "<pre>") != strings.Count(chunk, "</pre>") ||
+			strings.Count(chunk, "<code") != strings.Count(chunk, "</code>") {
+			t.Errorf("Chunk %d has unbalanced tags: %q", i, chunk)
+		}
+		if strings.Contains(chunk, "&am\n") || strings.HasSuffix(strings.TrimSuffix(chunk, "</code></pre>"), "&") {
+			t.Errorf("Chunk %d splits a character reference: %q", i, chunk)
+		}
+		if i > 0 && !strings.HasPrefix(chunk, "<pre><code class=\"language-go\">") {
+			t.Errorf("Expected chunk %d to reopen the code block, got %q", i, chunk)
+		}
+	}
+}
+
+func TestSplitTelegramTextKeepsEscapesAndLinks(t *testing.T) {
+	text := strings.Repeat("a\\_b [link](https://example.com/a_b) ", 30)
+
+	chunks := splitTelegramText(text, "MarkdownV2", 50, false)
+	checkChunks(t, chunks, 50)
+
+	for i, chunk := range chunks {
+		if strings.HasSuffix(chunk, "\\") || strings.Count(chunk, "[") != strings.Count(chunk, ")") {
+			t.Errorf("Chunk %d splits an escape or link: %q", i, chunk)
+		}
+	}
+}
+
+func TestSplitTelegramTextMarkers(t *testing.T) {
+	text := strings.Repeat("line of text\n", 50)
+
+	for parseMode, marker := range map[string]string{"HTML": "(1/", "MarkdownV2": "\\(1/"} {
+		chunks := splitTelegramText(text, parseMode, 200, true)
+		checkChunks(t, chunks, 200)
+
+		if !strings.Contains(chunks[0], "\n\n"+marker) {
+			t.Errorf("%s: expected a marker on the first chunk, got %q", parseMode, chunks[0])
+		}
+		last := chunks[len(chunks)-1]
+		if want := telegramMarker(len(chunks), len(chunks), parseMode); !strings.HasSuffix(last, want) {
+			t.Errorf("%s: expected the last chunk to end with %q, got %q", parseMode, want, last)
+		}
+	}
+}
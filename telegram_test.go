@@ -0,0 +1,940 @@
+package notify_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/milano15662/notify"
+	"github.com/milano15662/notify/notifytest"
+	"github.com/milano15662/notify/richtext"
+)
+
+func newTestTelegram(t *testing.T, srv *notifytest.TelegramServer, config notify.TelegramConfig) *notify.TelegramNotifier {
+	t.Helper()
+	config.APIURL = srv.URL
+	if config.BotToken == "" {
+		config.BotToken = "123456:ABC"
+	}
+
+	telegram, err := notify.NewTelegramNotifier(config)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	return telegram
+}
+
+func TestTelegramSendWithOptions(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42"})
+	err := telegram.SendWithOptions(context.Background(), &notify.Message{
+		Title:    "Deploy",
+		Text:     "v1.2.3 is live",
+		Priority: notify.PriorityLow,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := srv.CallsTo("sendMessage")
+	if len(calls) != 1 {
+		t.Fatalf("Expected 1 sendMessage call, got %d", len(calls))
+	}
+
+	call := calls[0]
+	if call.Token != "123456:ABC" || call.Param("chat_id") != "42" || call.Param("parse_mode") != "MarkdownV2" {
+		t.Errorf("Unexpected call: %+v", call)
+	}
+	if call.Param("text") != "*Deploy*\n\nv1\\.2\\.3 is live" {
+		t.Errorf("Unexpected text %q", call.Param("text"))
+	}
+	if call.Params["disable_notification"] != true {
+		t.Error("Expected low priority messages to be silent")
+	}
+}
+
+func TestTelegramFanOut(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	srv.Chats = []string{"1", "2"}
+	defer srv.Close()
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatIDs: []string{"1", "2", "3"}})
+	err := telegram.Send(context.Background(), "hello")
+	if err == nil || !strings.Contains(err.Error(), "failed to send to 1 of 3 chats") {
+		t.Errorf("Expected a partial failure, got %v", err)
+	}
+	if len(srv.CallsTo("sendMessage")) != 2 {
+		t.Errorf("Expected 2 delivered messages, got %d", len(srv.CallsTo("sendMessage")))
+	}
+
+	if err := telegram.SendWithOptions(context.Background(), &notify.Message{Text: "direct", Channel: "2"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestTelegramAPIErrors(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42"})
+
+	srv.RateLimit(1, 3*time.Second)
+	err := telegram.Send(context.Background(), "hello")
+	if err == nil || !strings.Contains(err.Error(), "status 429") {
+		t.Errorf("Expected a rate limit error, got %v", err)
+	}
+
+	srv.Chats = []string{"42"}
+	err = telegram.SendWithOptions(context.Background(), &notify.Message{Text: "hello", Channel: "7"})
+	if err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Errorf("Expected a chat error, got %v", err)
+	}
+
+	if _, ok := err.(*notify.NotificationError); !ok {
+		t.Errorf("Expected a *NotificationError, got %T", err)
+	}
+}
+
+func TestTelegramTransportErrorsHideToken(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42", BotToken: "not-a-standard-token"})
+	srv.Close()
+
+	err := telegram.Send(context.Background(), "hello")
+	if err == nil || strings.Contains(err.Error(), "not-a-standard-token") || !strings.Contains(err.Error(), "/bot<redacted>/sendMessage") {
+		t.Errorf("Expected a transport error without the token, got %v", err)
+	}
+}
+
+func TestTelegramProviderAPIURL(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	notifier, err := notify.NewProvider("telegram", map[string]interface{}{
+		"bot_token": "123456:ABC",
+		"chat_id":   "42",
+		"api_url":   srv.URL + "/",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	if err := notifier.Send(context.Background(), "hello"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(srv.CallsTo("sendMessage")) != 1 {
+		t.Error("Expected the message to reach the configured API URL")
+	}
+}
+
+func TestTelegramConformance(t *testing.T) {
+	notifytest.RunConformance(t, func(t *testing.T) *notifytest.Harness {
+		srv := notifytest.NewTelegramServer()
+		srv.Chats = []string{"42", "43"}
+		t.Cleanup(srv.Close)
+
+		return &notifytest.Harness{
+			Notifier: newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42", ParseMode: "HTML"}),
+			Delivered: func() []notifytest.Delivered {
+				var delivered []notifytest.Delivered
+				for _, call := range srv.CallsTo("sendMessage") {
+					delivered = append(delivered, notifytest.Delivered{Channel: call.Param("chat_id"), Text: call.Param("text")})
+				}
+				return delivered
+			},
+			DefaultChannel: "42",
+			OtherChannel:   "43",
+			SetFailing:     srv.SetDown,
+			Hold:           srv.Hold,
+		}
+	})
+}
+
+func TestTelegramSplitsLongMessages(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	var trace strings.Builder
+	for i := 0; i < 300; i++ {
+		trace.WriteString("goroutine 1 [running]: main.handle_request(server_main.go:42)\n")
+	}
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42", ParseMode: notify.TelegramMarkdown, RawText: true, ChunkMarkers: true})
+	err := telegram.SendWithOptions(context.Background(), &notify.Message{
+		Title: "Panic",
+		Text:  "```\n" + trace.String() + "```",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := srv.CallsTo("sendMessage")
+	if len(calls) < 5 || len(srv.Calls()) != len(calls) {
+		t.Fatalf("Expected the message to be sent in several accepted parts, got %d of %d", len(calls), len(srv.Calls()))
+	}
+	for i, call := range calls {
+		text := call.Param("text")
+		if want := fmt.Sprintf("(%d/%d)", i+1, len(calls)); !strings.HasSuffix(text, want) {
+			t.Errorf("Expected part %d to end with %q, got %q", i+1, want, text[len(text)-20:])
+		}
+	}
+	if !strings.HasPrefix(calls[0].Param("text"), "*Panic*") {
+		t.Error("Expected the first part to start with the title")
+	}
+}
+
+func TestTelegramLongMessagesAsDocument(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	text := strings.Repeat("log line\n", 1000)
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42", LongMessages: notify.TelegramLongMessageDocument})
+	if err := telegram.SendWithOptions(context.Background(), &notify.Message{Title: "Build log", Text: text}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := srv.CallsTo("sendDocument")
+	if len(calls) != 1 || len(srv.CallsTo("sendMessage")) != 0 {
+		t.Fatalf("Expected a single sendDocument call, got %+v", srv.Calls())
+	}
+	file := calls[0].Files["document"]
+	if file.Filename != "message.txt" || string(file.Data) != "*Build log*\n\n"+text {
+		t.Errorf("Unexpected document %q (%d bytes)", file.Filename, len(file.Data))
+	}
+	if calls[0].Param("caption") != "Build log" {
+		t.Errorf("Expected the title as caption, got %q", calls[0].Param("caption"))
+	}
+
+	if err := telegram.Send(context.Background(), "short"); err != nil || len(srv.CallsTo("sendMessage")) != 1 {
+		t.Errorf("Expected short texts to be sent as messages, got %v", err)
+	}
+}
+
+func TestTelegramLongMessagesOption(t *testing.T) {
+	_, err := notify.NewTelegramNotifier(notify.TelegramConfig{BotToken: "1:A", ChatID: "1", LongMessages: "truncate"})
+	if err == nil {
+		t.Error("Expected an error for an unknown long message mode")
+	}
+}
+
+func TestTelegramEscapesUserData(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	msg := &notify.Message{
+		Title: "db_host-01 [prod] <b> & (v1.2)",
+		Text:  "disk_usage > 90% on *db_host-01* `/var/lib`! See [runbook](x)",
+	}
+
+	// Legacy Markdown sends texts as written, see TestTelegramLegacyMarkdown
+	for _, parseMode := range []string{notify.TelegramMarkdownV2, notify.TelegramHTML, notify.TelegramPlainText} {
+		srv.Reset()
+		telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42", ParseMode: parseMode})
+		if err := telegram.SendWithOptions(context.Background(), msg); err != nil {
+			t.Errorf("%s: unexpected error: %v", parseMode, err)
+			continue
+		}
+
+		calls := srv.Calls()
+		if len(calls) != 1 || calls[0].Status != 200 {
+			t.Errorf("%s: expected a single accepted call, got %+v", parseMode, calls)
+		}
+	}
+}
+
+func TestTelegramLegacyMarkdown(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42", ParseMode: "Markdown"})
+	if err := telegram.SendWithOptions(context.Background(), &notify.Message{Title: "db_host", Text: "*Disk* full on `db-01`, see [runbook](https://wiki/disk)"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	call := srv.Calls()[0]
+	if call.Param("parse_mode") != notify.TelegramMarkdown || call.Status != 200 {
+		t.Fatalf("Expected an accepted legacy Markdown message, got %+v", call)
+	}
+	if want := "*db\\_host*\n\n*Disk* full on `db-01`, see [runbook](https://wiki/disk)"; call.Param("text") != want {
+		t.Errorf("Expected the hand-formatted text to be sent unescaped, got %q", call.Param("text"))
+	}
+}
+
+func TestTelegramRetriesAsPlainText(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42", ParseMode: notify.TelegramMarkdownV2, RawText: true})
+	if err := telegram.SendWithOptions(context.Background(), &notify.Message{Title: "Alert", Text: "unbalanced *bold on host.example"}); err != nil {
+		t.Fatalf("Expected the plain text retry to succeed, got %v", err)
+	}
+
+	calls := srv.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 calls, got %+v", calls)
+	}
+	if !strings.Contains(calls[0].Error, "can't parse entities") {
+		t.Errorf("Expected the first call to fail parsing, got %q", calls[0].Error)
+	}
+	if calls[1].Param("parse_mode") != "" || calls[1].Param("text") != "Alert\n\nunbalanced bold on host.example" {
+		t.Errorf("Expected a plain text retry, got %v", calls[1].Params)
+	}
+}
+
+func TestTelegramParseModeOption(t *testing.T) {
+	_, err := notify.NewTelegramNotifier(notify.TelegramConfig{BotToken: "1:A", ChatID: "1", ParseMode: "markdown2"})
+	if err == nil {
+		t.Error("Expected an error for an unknown parse mode")
+	}
+
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42", ParseMode: "html"})
+	telegram.Send(context.Background(), "a < b")
+	if call := srv.Calls()[0]; call.Param("parse_mode") != "HTML" || call.Param("text") != "a &lt; b" {
+		t.Errorf("Expected parse modes to match ignoring case, got %v", call.Params)
+	}
+}
+
+func TestTelegramRichText(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	doc := richtext.New().
+		Bold("Deploy failed").Text(" on ").Code("db_host-1").Text(" for ").Mention("12345", "Alice").Line().
+		Link("https://ci.example.com/builds/(42)", "Build log").
+		Pre("go", "panic: `boom` \\ here").
+		Quote("see logs").
+		List("check migrations", "roll back").
+		Document()
+
+	want := map[string]string{
+		notify.TelegramMarkdownV2: "*Deploy failed* on `db_host-1` for [Alice](tg://user?id=12345)\n" +
+			"[Build log](https://ci.example.com/builds/(42\\))\n\n" +
+			"```go\npanic: \\`boom\\` \\\\ here\n```\n\n" +
+			">see logs\n\n" +
+			"• check migrations\n• roll back",
+		notify.TelegramHTML: "<b>Deploy failed</b> on <code>db_host-1</code> for <a href=\"tg://user?id=12345\">Alice</a>\n" +
+			"<a href=\"https://ci.example.com/builds/(42)\">Build log</a>\n\n" +
+			"<pre><code class=\"language-go\">panic: `boom` \\ here</code></pre>\n\n" +
+			"<blockquote>see logs</blockquote>\n\n" +
+			"• check migrations\n• roll back",
+		notify.TelegramPlainText: "Deploy failed on db_host-1 for @Alice\n" +
+			"Build log (https://ci.example.com/builds/(42))\n\n" +
+			"panic: `boom` \\ here\n\n" +
+			"> see logs\n\n" +
+			"• check migrations\n• roll back",
+	}
+
+	for parseMode, text := range want {
+		srv.Reset()
+		telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42", ParseMode: parseMode})
+		if err := telegram.SendWithOptions(context.Background(), &notify.Message{Rich: doc}); err != nil {
+			t.Errorf("%s: unexpected error: %v", parseMode, err)
+			continue
+		}
+
+		calls := srv.Calls()
+		if len(calls) != 1 || calls[0].Status != 200 {
+			t.Fatalf("%s: expected a single accepted call, got %+v", parseMode, calls)
+		}
+		if got := calls[0].Param("text"); got != text {
+			t.Errorf("%s: unexpected text:\n%s\nwant:\n%s", parseMode, got, text)
+		}
+	}
+}
+
+func TestTelegramAttachments(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42"})
+	err := telegram.SendWithOptions(context.Background(), &notify.Message{
+		Title: "CPU alert",
+		Text:  "usage is at 95%",
+		Attachments: []notify.Attachment{
+			{
+				Title:    "db_host-1",
+				Color:    "danger",
+				Fields:   []notify.Field{{Title: "Region", Value: "eu-west-1"}, {Title: "Load", Value: "9.5"}},
+				Footer:   "Prometheus (prod)",
+				ImageURL: "https://grafana.example.com/render/cpu.png",
+			},
+			{Title: "Memory", ImageURL: "https://grafana.example.com/render/mem.png"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := srv.Calls()
+	if len(calls) != 2 || calls[0].Method != "sendMessage" || calls[1].Method != "sendMediaGroup" || calls[1].Status != 200 {
+		t.Fatalf("Expected a message and a media group, got %+v", calls)
+	}
+
+	text := calls[0].Param("text")
+	for _, want := range []string{"🔴 *db\\_host\\-1*", "Region: eu-west-1\nLoad:   9.5", "_Prometheus \\(prod\\)_", "*Memory*"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected the text to contain %q, got:\n%s", want, text)
+		}
+	}
+
+	var group struct {
+		Media []struct {
+			Media   string `json:"media"`
+			Caption string `json:"caption"`
+		} `json:"media"`
+		ReplyParameters struct {
+			MessageID int `json:"message_id"`
+		} `json:"reply_parameters"`
+	}
+	data, _ := json.Marshal(calls[1].Params)
+	json.Unmarshal(data, &group)
+
+	if len(group.Media) != 2 || group.Media[0].Caption != "db_host-1" || group.Media[1].Media != "https://grafana.example.com/render/mem.png" {
+		t.Errorf("Unexpected media group %+v", group.Media)
+	}
+	if group.ReplyParameters.MessageID != 1 {
+		t.Errorf("Expected the media group to reply to message 1, got %d", group.ReplyParameters.MessageID)
+	}
+
+	srv.Reset()
+	telegram.SendWithOptions(context.Background(), &notify.Message{
+		Text:        "graph",
+		Attachments: []notify.Attachment{{ImageURL: "https://grafana.example.com/render/cpu.png"}},
+	})
+	if photos := srv.CallsTo("sendPhoto"); len(photos) != 1 || photos[0].Param("photo") != "https://grafana.example.com/render/cpu.png" {
+		t.Errorf("Expected a single image to be sent as a photo, got %+v", srv.Calls())
+	}
+}
+
+func TestTelegramSendDocument(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(path, []byte("host,load\ndb-1,9.5\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42"})
+	id, err := telegram.SendDocument(context.Background(), notify.FilePath(path), notify.TelegramMediaOptions{
+		Caption: "Load report (daily)",
+		ReplyTo: 7,
+		Silent:  true,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if id != 1 {
+		t.Errorf("Expected message ID 1, got %d", id)
+	}
+
+	calls := srv.CallsTo("sendDocument")
+	if len(calls) != 1 {
+		t.Fatalf("Expected a sendDocument call, got %+v", srv.Calls())
+	}
+	call := calls[0]
+	if file := call.Files["document"]; file.Filename != "report.csv" || string(file.Data) != "host,load\ndb-1,9.5\n" {
+		t.Errorf("Unexpected upload %q: %q", file.Filename, file.Data)
+	}
+	if call.Param("chat_id") != "42" || call.Param("disable_notification") != "true" {
+		t.Errorf("Unexpected params %+v", call.Params)
+	}
+	if call.Param("caption") != "Load report \\(daily\\)" || call.Param("parse_mode") != notify.TelegramMarkdownV2 {
+		t.Errorf("Expected an escaped MarkdownV2 caption, got %q (%s)", call.Param("caption"), call.Param("parse_mode"))
+	}
+	if call.Param("reply_parameters") != `{"allow_sending_without_reply":true,"message_id":7}` {
+		t.Errorf("Unexpected reply parameters %s", call.Param("reply_parameters"))
+	}
+
+	if _, err := telegram.SendDocument(context.Background(), notify.FilePath(filepath.Join(t.TempDir(), "missing")), notify.TelegramMediaOptions{}); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestTelegramTruncatesRawCaptions(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42", ParseMode: notify.TelegramHTML, RawText: true})
+	caption := "<b>" + strings.Repeat("load is high ", 100) + "</b>"
+	if _, err := telegram.SendPhotoFile(context.Background(), notify.FileURL("https://grafana.example.com/render/cpu.png"), notify.TelegramMediaOptions{Caption: caption}); err != nil {
+		t.Fatalf("Expected the long raw caption to be truncated, got %v", err)
+	}
+
+	got := srv.CallsTo("sendPhoto")[0].Param("caption")
+	if !strings.HasPrefix(got, "<b>load") || !strings.HasSuffix(got, "</b>…") {
+		t.Errorf("Expected a truncated caption with closed formatting, got %q", got)
+	}
+}
+
+func TestTelegramSendMediaFromReader(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42", ParseMode: notify.TelegramHTML})
+	ctx := context.Background()
+	opts := notify.TelegramMediaOptions{ChatID: "7", Caption: "<b>Clip</b>", ParseMode: notify.TelegramPlainText}
+
+	if _, err := telegram.SendVideo(ctx, notify.FileReader("clip.mp4", strings.NewReader("video")), opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := telegram.SendAudio(ctx, notify.FileReader("", strings.NewReader("audio")), opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := telegram.SendPhotoFile(ctx, notify.FileURL("AgACAgIAAxkBAAI"), notify.TelegramMediaOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := srv.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("Expected 3 calls, got %+v", calls)
+	}
+	if file := calls[0].Files["video"]; calls[0].Method != "sendVideo" || file.Filename != "clip.mp4" || string(file.Data) != "video" {
+		t.Errorf("Unexpected video call %+v", calls[0])
+	}
+	if calls[0].Param("chat_id") != "7" || calls[0].Param("caption") != "<b>Clip</b>" || calls[0].Param("parse_mode") != "" {
+		t.Errorf("Expected a plain text caption, got %+v", calls[0].Params)
+	}
+	if file := calls[1].Files["audio"]; calls[1].Method != "sendAudio" || file.Filename != "file" || string(file.Data) != "audio" {
+		t.Errorf("Unexpected audio call %+v", calls[1])
+	}
+	if calls[2].Method != "sendPhoto" || calls[2].Param("photo") != "AgACAgIAAxkBAAI" || len(calls[2].Files) != 0 {
+		t.Errorf("Expected the file_id to be sent without an upload, got %+v", calls[2])
+	}
+}
+
+func TestTelegramSendMediaGroup(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42"})
+
+	var media []notify.TelegramMedia
+	for i := 0; i < 11; i++ {
+		file := notify.FileURL(fmt.Sprintf("https://example.com/%d.png", i))
+		if i%2 == 0 {
+			file = notify.FileReader(fmt.Sprintf("%d.png", i), strings.NewReader(fmt.Sprint(i)))
+		}
+		media = append(media, notify.TelegramMedia{Type: notify.TelegramPhoto, File: file})
+	}
+
+	ids, err := telegram.SendMediaGroup(context.Background(), media, notify.TelegramMediaOptions{Caption: "Graphs!", ReplyTo: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ids) != 11 || ids[0] != 1 || ids[10] != 11 {
+		t.Errorf("Expected 11 message IDs, got %v", ids)
+	}
+
+	calls := srv.Calls()
+	if len(calls) != 2 || calls[0].Method != "sendMediaGroup" || calls[1].Method != "sendPhoto" {
+		t.Fatalf("Expected a media group and a photo, got %+v", calls)
+	}
+
+	var group []struct {
+		Type      string `json:"type"`
+		Media     string `json:"media"`
+		Caption   string `json:"caption"`
+		ParseMode string `json:"parse_mode"`
+	}
+	if err := json.Unmarshal([]byte(calls[0].Param("media")), &group); err != nil || len(group) != 10 {
+		t.Fatalf("Unexpected media %s", calls[0].Param("media"))
+	}
+	if group[0].Media != "attach://file0" || group[1].Media != "https://example.com/1.png" {
+		t.Errorf("Unexpected media items %+v", group[:2])
+	}
+	if group[0].Caption != "Graphs\\!" || group[0].ParseMode != notify.TelegramMarkdownV2 || group[1].Caption != "" {
+		t.Errorf("Expected the caption on the first item only, got %+v", group[:2])
+	}
+	if len(calls[0].Files) != 5 || string(calls[0].Files["file4"].Data) != "4" {
+		t.Errorf("Expected 5 uploads, got %d", len(calls[0].Files))
+	}
+	if file := calls[1].Files["photo"]; file.Filename != "10.png" || calls[1].Param("reply_parameters") == "" {
+		t.Errorf("Unexpected last photo %+v", calls[1])
+	}
+
+	if _, err := telegram.SendMediaGroup(context.Background(), nil, notify.TelegramMediaOptions{}); err == nil {
+		t.Error("Expected an error for an empty media group")
+	}
+	if _, err := telegram.SendMediaGroup(context.Background(), []notify.TelegramMedia{{Type: "sticker", File: notify.FileURL("x")}}, notify.TelegramMediaOptions{}); err == nil {
+		t.Error("Expected an error for an unknown media type")
+	}
+}
+
+func TestTelegramSendFiles(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42"})
+	var _ notify.FileSender = telegram
+
+	err := telegram.SendFiles(context.Background(), []notify.File{
+		{Name: "trace.txt", Reader: strings.NewReader("panic: boom")},
+		{Name: "heap.pprof", Reader: strings.NewReader("pprof"), Title: "Heap profile"},
+	}, notify.FileOptions{Channel: "7", Comment: "Crash on db-1", ThreadID: "12"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := srv.CallsTo("sendMediaGroup")
+	if len(calls) != 1 {
+		t.Fatalf("Expected a media group, got %+v", srv.Calls())
+	}
+	call := calls[0]
+	if call.Param("chat_id") != "7" || string(call.Files["file0"].Data) != "panic: boom" || call.Files["file1"].Filename != "heap.pprof" {
+		t.Errorf("Unexpected call %+v", call)
+	}
+	if !strings.Contains(call.Param("reply_parameters"), `"message_id":12`) {
+		t.Errorf("Expected a reply to message 12, got %s", call.Param("reply_parameters"))
+	}
+
+	var group []struct {
+		Type    string `json:"type"`
+		Caption string `json:"caption"`
+	}
+	json.Unmarshal([]byte(call.Param("media")), &group)
+	if len(group) != 2 || group[0].Type != "document" || group[0].Caption != "Crash on db\\-1" || group[1].Caption != "Heap profile" {
+		t.Errorf("Unexpected media %+v", group)
+	}
+
+	srv.Reset()
+	if err := telegram.SendFiles(context.Background(), []notify.File{{Reader: strings.NewReader("x")}}, notify.FileOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if docs := srv.CallsTo("sendDocument"); len(docs) != 1 || docs[0].Files["document"].Filename != "file" {
+		t.Errorf("Expected a single file to be sent as a document, got %+v", srv.Calls())
+	}
+
+	if err := telegram.SendFiles(context.Background(), nil, notify.FileOptions{ThreadID: "1700000000.000100"}); err == nil {
+		t.Error("Expected an error for a non-numeric message ID")
+	}
+}
+
+func TestTelegramActions(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42", MaxMessageLength: 100})
+	err := telegram.SendWithOptions(context.Background(), &notify.Message{
+		Title: "DB down",
+		Text:  strings.Repeat("connection refused\n", 10),
+		Actions: []notify.Action{
+			notify.CallbackAction("ack", "Acknowledge", "alert-7"),
+			notify.LinkAction("Open runbook", "https://runbooks.example.com/db"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := srv.CallsTo("sendMessage")
+	if len(calls) < 2 {
+		t.Fatalf("Expected a split message, got %d calls", len(calls))
+	}
+	for _, call := range calls[:len(calls)-1] {
+		if call.Param("reply_markup") != "" {
+			t.Errorf("Expected the keyboard on the last part only, got %s", call.Param("reply_markup"))
+		}
+	}
+
+	var markup struct {
+		InlineKeyboard [][]struct {
+			Text         string `json:"text"`
+			URL          string `json:"url"`
+			CallbackData string `json:"callback_data"`
+		} `json:"inline_keyboard"`
+	}
+	json.Unmarshal([]byte(calls[len(calls)-1].Param("reply_markup")), &markup)
+	if len(markup.InlineKeyboard) != 1 || len(markup.InlineKeyboard[0]) != 2 {
+		t.Fatalf("Unexpected keyboard %+v", markup)
+	}
+	if button := markup.InlineKeyboard[0][0]; button.Text != "Acknowledge" || button.CallbackData != "ack|alert-7" {
+		t.Errorf("Unexpected callback button %+v", button)
+	}
+	if button := markup.InlineKeyboard[0][1]; button.Text != "Open runbook" || button.URL != "https://runbooks.example.com/db" {
+		t.Errorf("Unexpected link button %+v", button)
+	}
+
+	srv.Reset()
+	_, err = telegram.SendDocument(context.Background(), notify.FileURL("https://example.com/report.pdf"), notify.TelegramMediaOptions{
+		Actions: []notify.Action{notify.LinkAction("Open", "https://example.com")},
+	})
+	if calls := srv.CallsTo("sendDocument"); err != nil || len(calls) != 1 || !strings.Contains(calls[0].Param("reply_markup"), "inline_keyboard") {
+		t.Errorf("Expected a document with a keyboard, got %v, %+v", err, srv.Calls())
+	}
+
+	srv.Reset()
+	err = telegram.SendWithOptions(context.Background(), &notify.Message{
+		Text:    "hi",
+		Actions: []notify.Action{notify.CallbackAction(strings.Repeat("x", 65), "Too long", "")},
+	})
+	if err == nil || len(srv.Calls()) != 0 {
+		t.Errorf("Expected an error before sending, got %v", err)
+	}
+
+	// the callback data would be read back as action "ack" with value "all|7"
+	err = telegram.SendWithOptions(context.Background(), &notify.Message{
+		Text:    "hi",
+		Actions: []notify.Action{notify.CallbackAction("ack|all", "Ack all", "7")},
+	})
+	if err == nil || !strings.Contains(err.Error(), `"|"`) || len(srv.Calls()) != 0 {
+		t.Errorf("Expected an action ID with \"|\" to be rejected, got %v", err)
+	}
+}
+
+func TestTelegramWebhookHandler(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42"})
+	interactions := notify.NewInteractions()
+
+	var received *notify.Interaction
+	interactions.Handle("ack", func(ctx context.Context, in *notify.Interaction) error {
+		received = in
+		if err := in.Reply(ctx, &notify.Message{Text: "Acknowledged by " + in.User.Name}); err != nil {
+			return err
+		}
+		return in.Update(ctx, &notify.Message{Text: "DB down (acknowledged)"})
+	})
+	interactions.Handle("fail", func(ctx context.Context, in *notify.Interaction) error {
+		return errors.New("boom")
+	})
+	handler := telegram.WebhookHandler("s3cret", interactions)
+
+	post := func(token string, update map[string]interface{}) int {
+		body, _ := json.Marshal(update)
+		req := httptest.NewRequest("POST", "/telegram", bytes.NewReader(body))
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := post("s3cret", srv.CallbackQuery("42", 7, 1001, "ack|alert-7")); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	want := notify.MessageRef{Channel: "42", ID: "7"}
+	if received == nil || received.Provider != "telegram" || received.ActionID != "ack" || received.Value != "alert-7" ||
+		received.User != (notify.User{ID: "1001", Name: "user1001"}) || received.MessageRef != want {
+		t.Fatalf("Unexpected interaction %+v", received)
+	}
+
+	replies := srv.CallsTo("sendMessage")
+	if len(replies) != 1 || replies[0].Param("text") != "Acknowledged by user1001" ||
+		!strings.Contains(replies[0].Param("reply_parameters"), `"message_id":7`) {
+		t.Errorf("Expected a reply to the message, got %+v", replies)
+	}
+	edits := srv.CallsTo("editMessageText")
+	if len(edits) != 1 || edits[0].Param("message_id") != "7" || edits[0].Param("reply_markup") != "" {
+		t.Errorf("Expected the message to be edited without a keyboard, got %+v", edits)
+	}
+	answers := srv.CallsTo("answerCallbackQuery")
+	if len(answers) != 1 || answers[0].Param("text") != "" {
+		t.Errorf("Expected the callback query to be answered, got %+v", answers)
+	}
+
+	srv.Reset()
+	if code := post("s3cret", srv.CallbackQuery("42", 7, 1001, "fail")); code != http.StatusOK {
+		t.Errorf("Expected 200 for a failed handler, got %d", code)
+	}
+	if answers := srv.CallsTo("answerCallbackQuery"); len(answers) != 1 || answers[0].Param("text") != "Action failed" {
+		t.Errorf("Expected the failure to be shown, got %+v", answers)
+	}
+
+	srv.Reset()
+	if code := post("wrong", srv.CallbackQuery("42", 7, 1001, "ack")); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong secret token, got %d", code)
+	}
+	if len(srv.Calls()) != 0 {
+		t.Errorf("Expected no API calls, got %+v", srv.Calls())
+	}
+
+	handler = telegram.WebhookHandler("", interactions)
+	if code := post("", srv.CallbackQuery("42", 7, 1001, "ack")); code != http.StatusInternalServerError {
+		t.Errorf("Expected requests to be rejected without a secret token, got %d", code)
+	}
+	if len(srv.Calls()) != 0 {
+		t.Errorf("Expected no API calls without a secret token, got %+v", srv.Calls())
+	}
+}
+
+func TestTelegramPollInteractions(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	srv.Token = "123456:ABC"
+	defer srv.Close()
+
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42"})
+	interactions := notify.NewInteractions()
+
+	received := make(chan *notify.Interaction, 2)
+	interactions.HandleDefault(func(ctx context.Context, in *notify.Interaction) error {
+		received <- in
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- telegram.PollInteractions(ctx, interactions) }()
+
+	srv.AddUpdate(srv.CallbackQuery("42", 7, 1001, "ack|alert-7"))
+	srv.AddUpdate(srv.CallbackQuery("42", 8, 1002, "mute"))
+	for _, id := range []string{"ack", "mute"} {
+		select {
+		case in := <-received:
+			if in.ActionID != id {
+				t.Errorf("Expected action %s, got %+v", id, in)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for action %s", id)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("PollInteractions didn't return after cancel")
+	}
+
+	polls := srv.CallsTo("getUpdates")
+	if len(polls) == 0 || polls[0].Param("allowed_updates") != `["callback_query"]` {
+		t.Fatalf("Unexpected polls %+v", polls)
+	}
+
+	idle := notifytest.NewTelegramServer()
+	defer idle.Close()
+	short := newTestTelegram(t, idle, notify.TelegramConfig{ChatID: "42", HTTPClient: &http.Client{Timeout: 5 * time.Second}})
+	if err := short.PollInteractions(context.Background(), interactions); err == nil || !strings.Contains(err.Error(), "too short") {
+		t.Errorf("Expected an error for a client timeout too short to long poll, got %v", err)
+	}
+	if calls := idle.Calls(); len(calls) != 0 {
+		t.Errorf("Expected no polls with a short client timeout, got %+v", calls)
+	}
+
+	// the canceled poll may still be reading the server's token, so reject
+	// another notifier's instead of changing it
+	other := newTestTelegram(t, srv, notify.TelegramConfig{ChatID: "42", BotToken: "654321:XYZ"})
+	if err := other.PollInteractions(context.Background(), interactions); err == nil {
+		t.Error("Expected an error for a rejected token")
+	}
+}
+
+// failingTransport answers the calls of Bot API methods fail selects with
+// 502 Bad Gateway, counting calls per method from 1, and passes other
+// requests on
+type failingTransport struct {
+	fail func(method string, call int) bool
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (f *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+
+	f.mu.Lock()
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	f.calls[method]++
+	call := f.calls[method]
+	f.mu.Unlock()
+
+	if !f.fail(method, call) {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	return &http.Response{
+		StatusCode: http.StatusBadGateway,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"ok":false,"error_code":502,"description":"Bad Gateway"}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestTelegramPartialDeliveryIsNotRetried(t *testing.T) {
+	srv := notifytest.NewTelegramServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		config notify.TelegramConfig
+		msg    *notify.Message
+		fail   func(method string, call int) bool
+		method string
+		sent   int
+	}{
+		{
+			name:   "images after the text",
+			config: notify.TelegramConfig{ChatID: "42"},
+			msg:    &notify.Message{Text: "graph", Attachments: []notify.Attachment{{ImageURL: "https://grafana.example.com/render/cpu.png"}}},
+			fail:   func(method string, call int) bool { return method == "sendPhoto" },
+			method: "sendMessage",
+			sent:   1,
+		},
+		{
+			name:   "second part",
+			config: notify.TelegramConfig{ChatID: "42"},
+			msg:    &notify.Message{Text: strings.Repeat("x", 5000)},
+			fail:   func(method string, call int) bool { return method == "sendMessage" && call == 2 },
+			method: "sendMessage",
+			sent:   1,
+		},
+		{
+			name:   "second chat",
+			config: notify.TelegramConfig{ChatIDs: []string{"42", "43"}},
+			msg:    &notify.Message{Text: "hi"},
+			fail:   func(method string, call int) bool { return method == "sendMessage" && call == 2 },
+			method: "sendMessage",
+			sent:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv.Reset()
+			tt.config.HTTPClient = &http.Client{Transport: &failingTransport{fail: tt.fail}}
+			telegram := newTestTelegram(t, srv, tt.config)
+
+			manager := notify.NewManager()
+			manager.Register(telegram)
+			manager.SetRetryPolicy(notify.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+			err := manager.SendWithOptions(context.Background(), "telegram", tt.msg)
+			if !errors.Is(err, notify.ErrPartialDelivery) || notify.Retryable(err) {
+				t.Errorf("Expected a permanent partial delivery error, got %v", err)
+			}
+			if sent := srv.CallsTo(tt.method); len(sent) != tt.sent {
+				t.Errorf("Expected the delivered part not to be resent, got %d %s calls", len(sent), tt.method)
+			}
+		})
+	}
+
+	srv.Reset()
+	telegram := newTestTelegram(t, srv, notify.TelegramConfig{
+		ChatID:     "42",
+		HTTPClient: &http.Client{Transport: &failingTransport{fail: func(method string, call int) bool { return call == 1 }}},
+	})
+	if err := telegram.Send(context.Background(), "hi"); errors.Is(err, notify.ErrPartialDelivery) || !notify.Retryable(err) {
+		t.Errorf("Expected a failure before anything was delivered to be retryable, got %v", err)
+	}
+}
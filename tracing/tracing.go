@@ -0,0 +1,254 @@
+// Package tracing adds OpenTelemetry spans around notification delivery.
+//
+// Wrap decorates any Notifier, including custom ones, with a span per send.
+// WrapManager adds a parent span around each Broadcast call, so the spans of
+// the individual wrapped notifiers nest below it:
+//
+//	manager := tracing.WrapManager(notify.NewManager())
+//	manager.Register(tracing.Wrap(slackNotifier))
+//	manager.Broadcast(ctx, "deployed") // notify.broadcast → notify.send slack
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/milano15662/notify"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name of the tracer
+const ScopeName = "github.com/milano15662/notify/tracing"
+
+// Span attribute keys
+const (
+	AttrProvider    = attribute.Key("notify.provider")
+	AttrChannel     = attribute.Key("notify.channel")
+	AttrPriority    = attribute.Key("notify.priority")
+	AttrMessageSize = attribute.Key("notify.message.size")
+	AttrAttachments = attribute.Key("notify.message.attachments")
+	AttrProviders   = attribute.Key("notify.providers")
+	AttrFailures    = attribute.Key("notify.failures")
+)
+
+// Option configures tracing
+type Option func(*config)
+
+type config struct {
+	provider trace.TracerProvider
+}
+
+// WithTracerProvider sets the tracer provider (defaults to the global one)
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = provider
+	}
+}
+
+// newTracer creates a tracer from options
+func newTracer(opts []Option) trace.Tracer {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.provider == nil {
+		c.provider = otel.GetTracerProvider()
+	}
+	return c.provider.Tracer(ScopeName)
+}
+
+// Notifier wraps a notify.Notifier with a span per send
+type Notifier struct {
+	notifier notify.Notifier
+	tracer   trace.Tracer
+}
+
+// Wrap returns notifier decorated with tracing. The span of each send is a
+// child of the span in the send's context.
+func Wrap(notifier notify.Notifier, opts ...Option) *Notifier {
+	return &Notifier{notifier: notifier, tracer: newTracer(opts)}
+}
+
+// Middleware returns notify.Middleware that wraps notifiers like Wrap, for
+// use with Manager.Use
+func Middleware(opts ...Option) notify.Middleware {
+	tracer := newTracer(opts)
+	return func(notifier notify.Notifier) notify.Notifier {
+		return &Notifier{notifier: notifier, tracer: tracer}
+	}
+}
+
+// Unwrap returns the wrapped notifier
+func (n *Notifier) Unwrap() notify.Notifier {
+	return n.notifier
+}
+
+// Name returns the wrapped notifier's name
+func (n *Notifier) Name() string {
+	return n.notifier.Name()
+}
+
+// Send sends a simple text message inside a span
+func (n *Notifier) Send(ctx context.Context, message string) error {
+	ctx, span := n.start(ctx, &notify.Message{Text: message})
+	defer span.End()
+
+	err := n.notifier.Send(ctx, message)
+	setError(span, err)
+	return err
+}
+
+// SendWithOptions sends a message with options inside a span
+func (n *Notifier) SendWithOptions(ctx context.Context, msg *notify.Message) error {
+	ctx, span := n.start(ctx, msg)
+	defer span.End()
+
+	err := n.notifier.SendWithOptions(ctx, msg)
+	setError(span, err)
+	return err
+}
+
+// start starts a send span carrying the message attributes
+func (n *Notifier) start(ctx context.Context, msg *notify.Message) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{AttrProvider.String(n.notifier.Name())}
+	if msg != nil {
+		attrs = append(attrs, messageAttributes(msg)...)
+	}
+
+	return n.tracer.Start(ctx, "notify.send "+n.notifier.Name(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// Manager wraps a notify.Manager with a span per Broadcast call. Other
+// methods are those of the embedded Manager.
+type Manager struct {
+	*notify.Manager
+	tracer trace.Tracer
+}
+
+// WrapManager returns manager decorated with tracing
+func WrapManager(manager *notify.Manager, opts ...Option) *Manager {
+	return &Manager{Manager: manager, tracer: newTracer(opts)}
+}
+
+// Broadcast sends a message to all providers inside a span
+func (m *Manager) Broadcast(ctx context.Context, message string) []error {
+	ctx, span := m.start(ctx, "notify.broadcast", &notify.Message{Text: message})
+	defer span.End()
+
+	errs := m.Manager.Broadcast(ctx, message)
+	setErrors(span, errs)
+	return errs
+}
+
+// BroadcastWithOptions sends a message with options to all providers inside a span
+func (m *Manager) BroadcastWithOptions(ctx context.Context, msg *notify.Message) []error {
+	ctx, span := m.start(ctx, "notify.broadcast", msg)
+	defer span.End()
+
+	errs := m.Manager.BroadcastWithOptions(ctx, msg)
+	setErrors(span, errs)
+	return errs
+}
+
+// BroadcastAsync sends a message to all providers concurrently. The span
+// ends once every result has been received.
+func (m *Manager) BroadcastAsync(ctx context.Context, message string) <-chan notify.NotificationResult {
+	ctx, span := m.start(ctx, "notify.broadcast_async", &notify.Message{Text: message})
+	return endWithResults(span, m.Manager.BroadcastAsync(ctx, message))
+}
+
+// BroadcastAsyncWithOptions sends a message with options to all providers
+// concurrently. The span ends once every result has been received.
+func (m *Manager) BroadcastAsyncWithOptions(ctx context.Context, msg *notify.Message) <-chan notify.NotificationResult {
+	ctx, span := m.start(ctx, "notify.broadcast_async", msg)
+	return endWithResults(span, m.Manager.BroadcastAsyncWithOptions(ctx, msg))
+}
+
+// start starts a broadcast span
+func (m *Manager) start(ctx context.Context, name string, msg *notify.Message) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{AttrProviders.StringSlice(m.List())}
+	if msg != nil {
+		attrs = append(attrs, messageAttributes(msg)...)
+	}
+
+	return m.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endWithResults relays results and ends span after the last one
+func endWithResults(span trace.Span, results <-chan notify.NotificationResult) <-chan notify.NotificationResult {
+	out := make(chan notify.NotificationResult, cap(results))
+	go func() {
+		defer close(out)
+		defer span.End()
+
+		var errs []error
+		for result := range results {
+			if result.Error != nil {
+				errs = append(errs, result.Error)
+			}
+			out <- result
+		}
+		setErrors(span, errs)
+	}()
+	return out
+}
+
+// messageAttributes describes a message without exposing its content
+func messageAttributes(msg *notify.Message) []attribute.KeyValue {
+	size := len(msg.Title) + len(msg.Text)
+	for _, att := range msg.Attachments {
+		size += len(att.Title) + len(att.Text)
+		for _, field := range att.Fields {
+			size += len(field.Title) + len(field.Value)
+		}
+	}
+
+	attrs := []attribute.KeyValue{AttrMessageSize.Int(size)}
+	if msg.Channel != "" {
+		attrs = append(attrs, AttrChannel.String(msg.Channel))
+	}
+	if msg.Priority != "" {
+		attrs = append(attrs, AttrPriority.String(msg.Priority))
+	}
+	if len(msg.Attachments) > 0 {
+		attrs = append(attrs, AttrAttachments.Int(len(msg.Attachments)))
+	}
+	return attrs
+}
+
+// setError records err on span, with secrets redacted. Successful sends
+// leave the status unset.
+func setError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	err = redact(err)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// setErrors records the failures of a broadcast on span
+func setErrors(span trace.Span, errs []error) {
+	span.SetAttributes(AttrFailures.Int(len(errs)))
+	if len(errs) == 0 {
+		return
+	}
+	for _, err := range errs {
+		span.RecordError(redact(err))
+	}
+	span.SetStatus(codes.Error, fmt.Sprintf("%d deliveries failed", len(errs)))
+}
+
+// redact returns err with bot tokens, webhook URLs and credentials removed
+// from its message, so they aren't exported with spans
+func redact(err error) error {
+	return errors.New(notify.Redact(err.Error()))
+}
@@ -0,0 +1,205 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/milano15662/notify"
+	"github.com/milano15662/notify/notifytest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newRecorder() (*tracetest.SpanRecorder, Option) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return recorder, WithTracerProvider(provider)
+}
+
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestWrapSendWithOptions(t *testing.T) {
+	recorder, opt := newRecorder()
+	n := Wrap(notifytest.New("slack"), opt)
+
+	if n.Name() != "slack" {
+		t.Errorf("Expected name slack, got %s", n.Name())
+	}
+
+	msg := &notify.Message{Title: "Deploy", Text: "done", Channel: "#ops", Priority: notify.PriorityHigh}
+	if err := n.SendWithOptions(context.Background(), msg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "notify.send slack" {
+		t.Errorf("Unexpected span name %q", span.Name())
+	}
+	if span.Status().Code != codes.Unset {
+		t.Errorf("Expected status Unset, got %v", span.Status())
+	}
+
+	want := map[attribute.Key]attribute.Value{
+		AttrProvider:    attribute.StringValue("slack"),
+		AttrChannel:     attribute.StringValue("#ops"),
+		AttrPriority:    attribute.StringValue("high"),
+		AttrMessageSize: attribute.IntValue(10),
+	}
+	for key, value := range want {
+		if got, ok := attr(span, key); !ok || got != value {
+			t.Errorf("Expected %s=%v, got %v", key, value.Emit(), got.Emit())
+		}
+	}
+}
+
+func TestWrapRecordsErrorAndParent(t *testing.T) {
+	recorder, opt := newRecorder()
+	n := Wrap(notifytest.New("telegram", notifytest.FailAlways(errors.New("rate limited"))), opt)
+
+	ctx, parent := WrapManager(notify.NewManager(), opt).tracer.Start(context.Background(), "request")
+	err := n.Send(ctx, "hello")
+	parent.End()
+
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+
+	span := recorder.Ended()[0]
+	if span.Status().Code != codes.Error || span.Status().Description != "rate limited" {
+		t.Errorf("Expected error status, got %v", span.Status())
+	}
+	if len(span.Events()) != 1 || span.Events()[0].Name != "exception" {
+		t.Errorf("Expected an exception event, got %v", span.Events())
+	}
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("Expected the span to be a child of the context span")
+	}
+}
+
+func TestWrapRedactsErrors(t *testing.T) {
+	recorder, opt := newRecorder()
+	secret := "123456:ABCdef"
+	n := Wrap(notifytest.New("telegram", notifytest.FailAlways(errors.New("post https://api.telegram.org/bot"+secret+"/sendMessage: timeout"))), opt)
+
+	if err := n.Send(context.Background(), "hello"); err == nil {
+		t.Fatal("Expected error")
+	}
+
+	span := recorder.Ended()[0]
+	if strings.Contains(span.Status().Description, secret) || !strings.Contains(span.Status().Description, "<redacted>") {
+		t.Errorf("Expected a redacted status, got %q", span.Status().Description)
+	}
+	for _, event := range span.Events() {
+		for _, kv := range event.Attributes {
+			if strings.Contains(kv.Value.Emit(), secret) {
+				t.Errorf("Expected %s to be redacted, got %q", kv.Key, kv.Value.Emit())
+			}
+		}
+	}
+}
+
+func TestManagerBroadcast(t *testing.T) {
+	recorder, opt := newRecorder()
+
+	manager := WrapManager(notify.NewManager(), opt)
+	manager.Register(Wrap(notifytest.New("ok"), opt))
+	manager.Register(Wrap(notifytest.New("broken", notifytest.FailAlways(errors.New("down"))), opt))
+
+	if errs := manager.BroadcastWithOptions(context.Background(), &notify.Message{Text: "hi"}); len(errs) != 1 {
+		t.Fatalf("Expected 1 error, got %v", errs)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+
+	root := spans[2]
+	if root.Name() != "notify.broadcast" {
+		t.Fatalf("Expected the broadcast span to end last, got %q", root.Name())
+	}
+	if got, _ := attr(root, AttrFailures); got.AsInt64() != 1 {
+		t.Errorf("Expected 1 failure, got %v", got.Emit())
+	}
+	if root.Status().Code != codes.Error {
+		t.Errorf("Expected error status, got %v", root.Status())
+	}
+	for _, span := range spans[:2] {
+		if span.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("Expected %s to be a child of the broadcast span", span.Name())
+		}
+	}
+}
+
+func TestManagerBroadcastAsync(t *testing.T) {
+	recorder, opt := newRecorder()
+
+	manager := WrapManager(notify.NewManager(), opt)
+	manager.Register(Wrap(notifytest.New("a"), opt))
+	manager.Register(Wrap(notifytest.New("b"), opt))
+
+	count := 0
+	for result := range manager.BroadcastAsync(context.Background(), "hi") {
+		if result.Error != nil {
+			t.Errorf("Unexpected error: %v", result.Error)
+		}
+		count++
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 results, got %d", count)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 || spans[2].Name() != "notify.broadcast_async" {
+		t.Fatalf("Expected 2 send spans and a broadcast span, got %d", len(spans))
+	}
+	if spans[2].Status().Code != codes.Unset {
+		t.Errorf("Expected status Unset, got %v", spans[2].Status())
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder, opt := newRecorder()
+
+	manager := notify.NewManager()
+	manager.Use(Middleware(opt))
+	manager.Register(notifytest.New("ok"))
+	manager.Register(notifytest.New("broken", notifytest.FailAlways(errors.New("down"))))
+
+	if errs := manager.BroadcastWithOptions(context.Background(), &notify.Message{Text: "hi"}); len(errs) != 1 {
+		t.Fatalf("Expected 1 error, got %v", errs)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	if len(spans) != 2 {
+		t.Fatalf("Expected a span per provider, got %d", len(spans))
+	}
+	if spans["notify.send ok"].Status().Code != codes.Unset || spans["notify.send broken"].Status().Code != codes.Error {
+		t.Errorf("Expected per-provider statuses, got %v", spans)
+	}
+
+	stub := notifytest.New("stub")
+	var found *notifytest.Notifier
+	if !notify.As(Middleware(opt)(stub), &found) || found != stub {
+		t.Error("Expected notify.As to find the traced notifier")
+	}
+}
@@ -0,0 +1,241 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// URLParser creates a notifier from a service URL
+type URLParser func(u *url.URL) (Notifier, error)
+
+var (
+	schemes   = make(map[string]URLParser)
+	schemesMu sync.RWMutex
+)
+
+// RegisterScheme registers a parser for service URLs with the given scheme,
+// replacing any parser previously registered for it. It panics if the
+// scheme is empty or the parser is nil.
+func RegisterScheme(scheme string, parser URLParser) {
+	if scheme == "" {
+		panic("notify: RegisterScheme scheme is empty")
+	}
+	if parser == nil {
+		panic(fmt.Sprintf("notify: RegisterScheme parser for %q is nil", scheme))
+	}
+
+	schemesMu.Lock()
+	defer schemesMu.Unlock()
+
+	schemes[strings.ToLower(scheme)] = parser
+}
+
+// Schemes returns all registered service URL schemes
+func Schemes() []string {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+
+	names := make([]string, 0, len(schemes))
+	for scheme := range schemes {
+		names = append(names, scheme)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewFromURL creates a notifier from a service URL such as
+// telegram://TOKEN@telegram?chats=123,456 or slack://hook/T000/B000/XXX.
+// The optional name query parameter sets the instance name.
+//
+// Schemes without a registered URLParser fall back to the provider factory
+// of the same kind, with the query parameters as options
+// (e.g., custom://?api_key=KEY&room=ops).
+func NewFromURL(rawURL string) (Notifier, error) {
+	u, err := parseServiceURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	schemesMu.RLock()
+	parser, exists := schemes[u.Scheme]
+	schemesMu.RUnlock()
+
+	if exists {
+		return parser(u)
+	}
+
+	if _, exists := LookupProvider(u.Scheme); !exists {
+		return nil, &NotificationError{
+			Provider: u.Scheme,
+			Message:  fmt.Sprintf("unsupported service URL scheme %q", u.Scheme),
+		}
+	}
+
+	raw := make(map[string]interface{})
+	for key, values := range urlParams(u) {
+		if len(values) == 1 {
+			raw[key] = values[0]
+		} else {
+			list := make([]interface{}, len(values))
+			for i, value := range values {
+				list[i] = value
+			}
+			raw[key] = list
+		}
+	}
+
+	return NewProvider(u.Scheme, raw)
+}
+
+// newFromURLNamed creates a notifier from a service URL, defaulting its
+// instance name to name
+func newFromURLNamed(rawURL, name string) (Notifier, error) {
+	u, err := parseServiceURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if urlName(u) == "" {
+		query := u.Query()
+		query.Set("name", name)
+		u.RawQuery = query.Encode()
+	}
+
+	return NewFromURL(u.String())
+}
+
+// urlInstanceName returns the name a service URL asks for, or its scheme
+func urlInstanceName(rawURL string) string {
+	u, err := parseServiceURL(rawURL)
+	if err != nil {
+		return "url"
+	}
+
+	if name := urlName(u); name != "" {
+		return name
+	}
+	return u.Scheme
+}
+
+// FromURLs creates a Manager with one notifier per service URL
+func FromURLs(urls ...string) (*Manager, error) {
+	manager := NewManager()
+	for _, rawURL := range urls {
+		notifier, err := NewFromURL(rawURL)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := manager.Register(notifier); err != nil {
+			return nil, err
+		}
+	}
+
+	return manager, nil
+}
+
+// parseServiceURL parses a service URL without echoing it in errors,
+// since service URLs usually carry credentials
+func parseServiceURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, &NotificationError{
+			Provider: "url",
+			Message:  "invalid service URL",
+			Err:      err,
+		}
+	}
+
+	if u.Scheme == "" {
+		return nil, &NotificationError{
+			Provider: "url",
+			Message:  "service URL has no scheme",
+		}
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	return u, nil
+}
+
+// urlName returns the instance name requested by a service URL
+func urlName(u *url.URL) string {
+	return u.Query().Get("name")
+}
+
+// urlParams returns the query parameters of a service URL with lowercased keys
+func urlParams(u *url.URL) url.Values {
+	params := make(url.Values)
+	for key, values := range u.Query() {
+		params[strings.ToLower(key)] = values
+	}
+	return params
+}
+
+// parseSlackURL handles slack://hook/T000/B000/XXX (incoming webhook) and
+// slack://TOKEN@CHANNEL (Web API) URLs
+func parseSlackURL(u *url.URL) (Notifier, error) {
+	params := urlParams(u)
+	config := SlackConfig{
+		Name:           urlName(u),
+		DefaultChannel: params.Get("channel"),
+		Username:       params.Get("username"),
+		IconEmoji:      params.Get("icon"),
+	}
+
+	if u.Host == "hook" && u.User == nil {
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, &NotificationError{
+				Provider: "slack",
+				Message:  "webhook URL must look like slack://hook/T000/B000/XXX",
+			}
+		}
+		config.WebhookURL = "https://hooks.slack.com/services/" + strings.Join(parts, "/")
+	} else {
+		if u.User == nil || u.User.Username() == "" {
+			return nil, &NotificationError{
+				Provider: "slack",
+				Message:  "token URL must look like slack://TOKEN@CHANNEL",
+			}
+		}
+		config.Token = u.User.Username()
+		if config.DefaultChannel == "" {
+			config.DefaultChannel = u.Host
+		}
+	}
+
+	return NewSlackNotifier(config)
+}
+
+// parseTelegramURL handles telegram://TOKEN@telegram?chats=123,456&parsemode=HTML URLs
+func parseTelegramURL(u *url.URL) (Notifier, error) {
+	if u.User == nil {
+		return nil, &NotificationError{
+			Provider: "telegram",
+			Message:  "URL must look like telegram://TOKEN@telegram?chats=CHAT_ID",
+		}
+	}
+
+	// Bot tokens contain a colon, so they parse as user:password
+	token := u.User.Username()
+	if password, ok := u.User.Password(); ok {
+		token += ":" + password
+	}
+
+	params := urlParams(u)
+	return NewTelegramNotifier(TelegramConfig{
+		Name:      urlName(u),
+		BotToken:  token,
+		ChatIDs:   splitList(params.Get("chats")),
+		ParseMode: params.Get("parsemode"),
+		APIURL:    params.Get("apiurl"),
+	})
+}